@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"net/url"
+	"strings"
+
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const redacted = "REDACTED"
+
+// Parts of the names of settings whose values are secret
+var secretNames = []string{"password", "secret", "token", "credential", "apikey", "api_key", "api-key", "private"}
+
+// Config is the configuration the executor is running with.
+type Config struct {
+	Flags       map[string]string `json:"flags"`
+	Annotations map[string]string `json:"annotations"`
+	Environment map[string]string `json:"environment"`
+}
+
+// Redacted returns a copy of the configuration with secret values, and passwords in URLs, removed.
+func (c Config) Redacted() Config {
+	return Config{
+		Flags:       redact(c.Flags),
+		Annotations: redact(c.Annotations),
+		Environment: redact(c.Environment),
+	}
+}
+
+func redact(settings map[string]string) map[string]string {
+	result := make(map[string]string, len(settings))
+	for name, value := range settings {
+		result[name] = redactValue(name, value)
+	}
+	return result
+}
+
+// redactPredictor returns a copy of a predictor spec with secret values of its containers' environment removed.
+func redactPredictor(spec *v1.PredictorSpec) *v1.PredictorSpec {
+	redacted := spec.DeepCopy()
+	for _, componentSpec := range redacted.ComponentSpecs {
+		if componentSpec == nil {
+			continue
+		}
+		for _, containers := range [][]corev1.Container{componentSpec.Spec.InitContainers, componentSpec.Spec.Containers} {
+			for i := range containers {
+				for j := range containers[i].Env {
+					env := &containers[i].Env[j]
+					env.Value = redactValue(env.Name, env.Value)
+				}
+			}
+		}
+	}
+	return redacted
+}
+
+func redactValue(name string, value string) string {
+	if value == "" {
+		return value
+	}
+	lowerName := strings.ToLower(name)
+	for _, secret := range secretNames {
+		if strings.Contains(lowerName, secret) {
+			return redacted
+		}
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return u.String()
+		}
+	}
+	return value
+}
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gorilla/mux"
+	"github.com/seldonio/seldon-core/executor/predictor"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"go.uber.org/zap"
+)
+
+const (
+	GraphPath  = "/graph"
+	ConfigPath = "/config"
+	NodesPath  = "/nodes"
+	PprofPath  = "/pprof"
+	// Gets and sets the log level as {"level": "debug"}
+	LogLevelPath = "/loglevel"
+	// Profiles are served under this prefix while profiling is enabled
+	PprofHandlerPrefix = "/debug/pprof/"
+)
+
+// Server serves the executor's state on a port separate from predictions, for operators debugging a pod.
+type Server struct {
+	Router           *mux.Router
+	predictor        *v1.PredictorSpec
+	protocol         string
+	fullHealthChecks bool
+	config           Config
+	// 1 while pprof handlers are served
+	pprofEnabled int32
+	log          logr.Logger
+}
+
+// ServerOption configures an admin server.
+type ServerOption func(s *Server)
+
+// WithLogLevel serves the level of the executor's logs, so it can be changed while the executor runs.
+func WithLogLevel(level *zap.AtomicLevel) ServerOption {
+	return func(s *Server) {
+		s.Router.Handle(LogLevelPath, level).Methods("GET", "PUT")
+	}
+}
+
+// GraphResponse is the graph the executor sends new requests through.
+type GraphResponse struct {
+	Version   string            `json:"version"`
+	Predictor *v1.PredictorSpec `json:"predictor"`
+}
+
+// NodeStatus is the readiness and recent errors of a graph node.
+type NodeStatus struct {
+	Name   string                   `json:"name"`
+	Ready  bool                     `json:"ready"`
+	Reason string                   `json:"reason,omitempty"`
+	Errors predictor.NodeErrorCount `json:"errors"`
+}
+
+// PprofState is whether profiling is enabled.
+type PprofState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// NewServer creates an admin server for a predictor, serving the graph and config with their secrets redacted.
+func NewServer(predictorSpec *v1.PredictorSpec, protocol string, fullHealthChecks bool, config Config, pprofEnabled bool, log logr.Logger, options ...ServerOption) *Server {
+	s := &Server{
+		Router:           mux.NewRouter(),
+		predictor:        predictorSpec,
+		protocol:         protocol,
+		fullHealthChecks: fullHealthChecks,
+		config:           config.Redacted(),
+		log:              log.WithName("AdminServer"),
+	}
+	s.setPprof(pprofEnabled)
+	s.Router.HandleFunc(GraphPath, s.graph).Methods("GET")
+	s.Router.HandleFunc(ConfigPath, s.configuration).Methods("GET")
+	s.Router.HandleFunc(NodesPath, s.nodes).Methods("GET")
+	s.Router.HandleFunc(PprofPath, s.pprofState).Methods("GET")
+	s.Router.HandleFunc(PprofPath, s.togglePprof).Methods("PUT", "POST")
+	s.Router.HandleFunc(PprofHandlerPrefix+"cmdline", s.profiling(pprof.Cmdline))
+	s.Router.HandleFunc(PprofHandlerPrefix+"profile", s.profiling(pprof.Profile))
+	s.Router.HandleFunc(PprofHandlerPrefix+"symbol", s.profiling(pprof.Symbol))
+	s.Router.HandleFunc(PprofHandlerPrefix+"trace", s.profiling(pprof.Trace))
+	s.Router.PathPrefix(PprofHandlerPrefix).HandlerFunc(s.profiling(pprof.Index))
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// CreateHttpServer creates a server for the admin endpoints on a port.
+func (s *Server) CreateHttpServer(port int) *http.Server {
+	address := fmt.Sprintf("0.0.0.0:%d", port)
+	s.log.Info("Listening", "Address", address)
+	return &http.Server{
+		Handler:     s.Router,
+		Addr:        address,
+		IdleTimeout: 65 * time.Second,
+	}
+}
+
+func (s *Server) respond(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log.Error(err, "Failed to write response")
+	}
+}
+
+func (s *Server) graph(w http.ResponseWriter, req *http.Request) {
+	spec := predictor.ActiveSpec(s.predictor)
+	s.respond(w, http.StatusOK, &GraphResponse{
+		Version:   predictor.GraphVersion(spec),
+		Predictor: redactPredictor(spec),
+	})
+}
+
+func (s *Server) configuration(w http.ResponseWriter, req *http.Request) {
+	s.respond(w, http.StatusOK, s.config)
+}
+
+// nodes checks the readiness of every node in the graph at once.
+func (s *Server) nodes(w http.ResponseWriter, req *http.Request) {
+	spec := predictor.ActiveSpec(s.predictor)
+	nodes := v1.GetPredictiveUnitList(&spec.Graph)
+	errors := predictor.NodeErrors()
+	statuses := make([]NodeStatus, len(nodes))
+	wg := sync.WaitGroup{}
+	for i, node := range nodes {
+		statuses[i] = NodeStatus{Name: node.Name, Errors: errors[node.Name]}
+		wg.Add(1)
+		go func(status *NodeStatus, node *v1.PredictiveUnit) {
+			defer wg.Done()
+			if err := predictor.NodeReady(s.protocol, node, s.fullHealthChecks); err != nil {
+				status.Reason = err.Error()
+				return
+			}
+			status.Ready = true
+		}(&statuses[i], node)
+	}
+	wg.Wait()
+	s.respond(w, http.StatusOK, statuses)
+}
+
+func (s *Server) setPprof(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&s.pprofEnabled, value)
+}
+
+func (s *Server) pprofState(w http.ResponseWriter, req *http.Request) {
+	s.respond(w, http.StatusOK, &PprofState{Enabled: atomic.LoadInt32(&s.pprofEnabled) == 1})
+}
+
+func (s *Server) togglePprof(w http.ResponseWriter, req *http.Request) {
+	state := &PprofState{}
+	if err := json.NewDecoder(req.Body).Decode(state); err != nil {
+		http.Error(w, fmt.Sprintf("invalid pprof state: %v", err), http.StatusBadRequest)
+		return
+	}
+	s.setPprof(state.Enabled)
+	s.log.Info("Toggled pprof", "enabled", state.Enabled)
+	s.respond(w, http.StatusOK, state)
+}
+
+// profiling serves a pprof handler only while profiling is enabled.
+func (s *Server) profiling(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&s.pprofEnabled) != 1 {
+			http.Error(w, "pprof is disabled", http.StatusNotFound)
+			return
+		}
+		handler(w, req)
+	}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/go-logr/logr"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/k8s"
+)
+
+// Endpoints which authorization rules can be applied to
+const (
+	EndpointPredict  = "predict"
+	EndpointFeedback = "feedback"
+	EndpointMetadata = "metadata"
+
+	bearerPrefix = "bearer "
+	anyValue     = "*"
+)
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// AuthenticationError is returned for requests without a valid token.
+type AuthenticationError struct {
+	Reason string
+}
+
+func (e *AuthenticationError) Error() string {
+	return fmt.Sprintf("authentication failed: %s", e.Reason)
+}
+
+// AuthorizationError is returned for valid tokens whose claims don't allow access to an endpoint.
+type AuthorizationError struct {
+	Endpoint string
+}
+
+func (e *AuthorizationError) Error() string {
+	if e.Endpoint == "" {
+		return "access denied"
+	}
+	return fmt.Sprintf("access denied to %s endpoint", e.Endpoint)
+}
+
+// Rule allows access to endpoints for tokens with a claim matching one of the values.
+type Rule struct {
+	Endpoints []string
+	Claim     string
+	Values    []string
+}
+
+func (r Rule) appliesTo(endpoint string) bool {
+	for _, e := range r.Endpoints {
+		if e == anyValue || e == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matches(claims map[string]interface{}) bool {
+	claimValues, ok := claimValues(claims, r.Claim)
+	if !ok {
+		return false
+	}
+	for _, value := range r.Values {
+		if value == anyValue {
+			return true
+		}
+		for _, claimValue := range claimValues {
+			if claimValue == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimValues returns the values of a claim, which can be nested using dots. String claims are split
+// on spaces, as for OAuth scopes.
+func claimValues(claims map[string]interface{}, name string) ([]string, bool) {
+	var value interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v), true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, true
+	default:
+		return []string{fmt.Sprint(v)}, true
+	}
+}
+
+// ParseRules parses rules separated by semicolons, each of the form <endpoints>:<claim>:<values> where
+// endpoints are separated by commas and values by |. Either can be * to match anything.
+// For example predict,metadata:groups:data-science|admins;feedback:scope:feedback
+func ParseRules(value string) ([]Rule, error) {
+	var rules []Rule
+	for _, ruleStr := range strings.Split(value, ";") {
+		ruleStr = strings.TrimSpace(ruleStr)
+		if ruleStr == "" {
+			continue
+		}
+		parts := strings.SplitN(ruleStr, ":", 3)
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid rule %q, expected <endpoints>:<claim>:<values>", ruleStr)
+		}
+		rule := Rule{Claim: strings.TrimSpace(parts[1])}
+		for _, endpoint := range strings.Split(parts[0], ",") {
+			endpoint = strings.TrimSpace(endpoint)
+			switch endpoint {
+			case EndpointPredict, EndpointFeedback, EndpointMetadata, anyValue:
+				rule.Endpoints = append(rule.Endpoints, endpoint)
+			default:
+				return nil, fmt.Errorf("invalid endpoint %q in rule %q", endpoint, ruleStr)
+			}
+		}
+		for _, v := range strings.Split(parts[2], "|") {
+			rule.Values = append(rule.Values, strings.TrimSpace(v))
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Authenticator validates JWT bearer tokens and authorizes access to endpoints from their claims.
+type Authenticator struct {
+	keys      *KeySet
+	issuer    string
+	audiences []string
+	rules     []Rule
+	parser    *jwt.Parser
+}
+
+func NewAuthenticator(keys *KeySet, issuer string, audiences []string, rules []Rule) *Authenticator {
+	return &Authenticator{
+		keys:      keys,
+		issuer:    issuer,
+		audiences: audiences,
+		rules:     rules,
+		parser:    &jwt.Parser{ValidMethods: signingMethods},
+	}
+}
+
+// NewAuthenticatorFromAnnotations creates an authenticator from the deployment's annotations, returning
+// nil if no JWKS is configured.
+func NewAuthenticatorFromAnnotations(annotations map[string]string, log logr.Logger) (*Authenticator, error) {
+	source := annotations[k8s.ANNOTATION_JWT_JWKS]
+	if source == "" {
+		return nil, nil
+	}
+	refreshInterval := DefaultJWKSRefreshInterval
+	if val := annotations[k8s.ANNOTATION_JWT_JWKS_REFRESH]; val != "" {
+		var err error
+		if refreshInterval, err = time.ParseDuration(val); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", k8s.ANNOTATION_JWT_JWKS_REFRESH)
+		}
+	}
+	var audiences []string
+	for _, audience := range strings.Split(annotations[k8s.ANNOTATION_JWT_AUDIENCES], ",") {
+		if audience = strings.TrimSpace(audience); audience != "" {
+			audiences = append(audiences, audience)
+		}
+	}
+	rules, err := ParseRules(annotations[k8s.ANNOTATION_JWT_RULES])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", k8s.ANNOTATION_JWT_RULES)
+	}
+	keys, err := NewKeySet(source, refreshInterval, log)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthenticator(keys, annotations[k8s.ANNOTATION_JWT_ISSUER], audiences, rules), nil
+}
+
+// Authenticate validates the bearer token of an Authorization header and returns its claims.
+func (a *Authenticator) Authenticate(authorization string) (map[string]interface{}, error) {
+	if authorization == "" {
+		return nil, &AuthenticationError{Reason: "missing bearer token"}
+	}
+	if len(authorization) < len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return nil, &AuthenticationError{Reason: "authorization is not a bearer token"}
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(strings.TrimSpace(authorization[len(bearerPrefix):]), claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.keys.Key(kid)
+	})
+	if err != nil {
+		return nil, &AuthenticationError{Reason: err.Error()}
+	}
+	if a.issuer != "" && !claims.VerifyIssuer(a.issuer, true) {
+		return nil, &AuthenticationError{Reason: "invalid issuer"}
+	}
+	if len(a.audiences) > 0 {
+		valid := false
+		for _, audience := range a.audiences {
+			if claims.VerifyAudience(audience, true) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, &AuthenticationError{Reason: "invalid audience"}
+		}
+	}
+	return claims, nil
+}
+
+// Authorize checks the claims of a token allow access to an endpoint. Any valid token is allowed if
+// there are no rules, otherwise one of the rules for the endpoint has to match.
+func (a *Authenticator) Authorize(claims map[string]interface{}, endpoint string) error {
+	if len(a.rules) == 0 {
+		return nil
+	}
+	for _, rule := range a.rules {
+		if rule.appliesTo(endpoint) && rule.matches(claims) {
+			return nil
+		}
+	}
+	return &AuthorizationError{Endpoint: endpoint}
+}
+
+// Check authenticates the bearer token of an Authorization header and authorizes it for an endpoint.
+func (a *Authenticator) Check(authorization string, endpoint string) (map[string]interface{}, error) {
+	claims, err := a.Authenticate(authorization)
+	if err != nil {
+		return nil, err
+	}
+	return claims, a.Authorize(claims, endpoint)
+}
+
+// EncodeClaims returns the claims as JSON restricted to ASCII, so it can be passed in headers and gRPC metadata.
+func EncodeClaims(claims map[string]interface{}) (string, error) {
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r < utf8.RuneSelf {
+			sb.WriteByte(b[0])
+		} else if r > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&sb, `\u%04x\u%04x`, r1, r2)
+		} else {
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		}
+		b = b[size:]
+	}
+	return sb.String(), nil
+}
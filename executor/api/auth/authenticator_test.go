@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	set := jsonWebKeySet{Keys: []jsonWebKey{
+		{Kid: "rsa", Kty: "RSA", Use: "sig", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+		{Kid: "ec", Kty: "EC", Crv: "P-256", X: encodeBigInt(ecKey.X), Y: encodeBigInt(ecKey.Y)},
+		{Kid: "enc", Kty: "RSA", Use: "enc", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))},
+	}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func createKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rsaKey, mustECKey(t)
+}
+
+func TestAuthenticate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	rsaKey, ecKey := createKeys(t)
+	jwksPath := writeJWKS(t, rsaKey, ecKey)
+
+	a, err := NewAuthenticatorFromAnnotations(map[string]string{
+		k8s.ANNOTATION_JWT_JWKS:      jwksPath,
+		k8s.ANNOTATION_JWT_ISSUER:    "https://issuer.example.com",
+		k8s.ANNOTATION_JWT_AUDIENCES: "seldon, other",
+	}, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(a).ToNot(BeNil())
+
+	valid := jwt.MapClaims{
+		"iss": "https://issuer.example.com",
+		"aud": "seldon",
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	claims, err := a.Authenticate(signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, valid))
+	g.Expect(err).To(BeNil())
+	g.Expect(claims["sub"]).To(Equal("alice"))
+
+	_, err = a.Authenticate(signToken(t, jwt.SigningMethodES256, "ec", ecKey, valid))
+	g.Expect(err).To(BeNil())
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"missing", ""},
+		{"not bearer", "Basic dXNlcjpwYXNz"},
+		{"garbage", "Bearer abc.def.ghi"},
+		{"unknown key", signToken(t, jwt.SigningMethodRS256, "other", rsaKey, valid)},
+		{"encryption key", signToken(t, jwt.SigningMethodRS256, "enc", rsaKey, valid)},
+		{"wrong key", signToken(t, jwt.SigningMethodES256, "ec", mustECKey(t), valid)},
+		{"hmac", signToken(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), valid)},
+		{"expired", signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "https://issuer.example.com", "aud": "seldon", "exp": time.Now().Add(-time.Hour).Unix()})},
+		{"issuer", signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "https://other.example.com", "aud": "seldon"})},
+		{"audience", signToken(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{"iss": "https://issuer.example.com", "aud": "unknown"})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			_, err := a.Authenticate(test.authorization)
+			g.Expect(err).ToNot(BeNil())
+			_, ok := err.(*AuthenticationError)
+			g.Expect(ok).To(BeTrue())
+		})
+	}
+}
+
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAuthorize(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	rules, err := ParseRules("predict,metadata:groups:data-science|admins; feedback:scope:feedback; *:realm_access.roles:admin")
+	g.Expect(err).To(BeNil())
+	g.Expect(rules).To(HaveLen(3))
+	a := NewAuthenticator(nil, "", nil, rules)
+
+	scientist := map[string]interface{}{"groups": []interface{}{"data-science"}, "scope": "openid profile"}
+	g.Expect(a.Authorize(scientist, EndpointPredict)).To(BeNil())
+	g.Expect(a.Authorize(scientist, EndpointMetadata)).To(BeNil())
+	g.Expect(a.Authorize(scientist, EndpointFeedback)).ToNot(BeNil())
+
+	labeller := map[string]interface{}{"scope": "openid feedback"}
+	g.Expect(a.Authorize(labeller, EndpointFeedback)).To(BeNil())
+	g.Expect(a.Authorize(labeller, EndpointPredict)).ToNot(BeNil())
+
+	admin := map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"admin"}}}
+	g.Expect(a.Authorize(admin, EndpointFeedback)).To(BeNil())
+	g.Expect(a.Authorize(admin, "")).To(BeNil())
+
+	g.Expect(NewAuthenticator(nil, "", nil, nil).Authorize(labeller, EndpointPredict)).To(BeNil())
+
+	_, err = ParseRules("predict:groups")
+	g.Expect(err).ToNot(BeNil())
+	_, err = ParseRules("train:groups:admins")
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestNewAuthenticatorFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a, err := NewAuthenticatorFromAnnotations(map[string]string{}, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(a).To(BeNil())
+
+	_, err = NewAuthenticatorFromAnnotations(map[string]string{k8s.ANNOTATION_JWT_JWKS: filepath.Join(t.TempDir(), "missing.json")}, logf.Log)
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestEncodeClaims(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	encoded, err := EncodeClaims(map[string]interface{}{"name": "Zoë 🙂", "sub": "alice"})
+	g.Expect(err).To(BeNil())
+	g.Expect(encoded).To(Equal(`{"name":"Zo\u00eb \ud83d\ude42","sub":"alice"}`))
+
+	var decoded map[string]interface{}
+	g.Expect(json.Unmarshal([]byte(encoded), &decoded)).To(BeNil())
+	g.Expect(decoded["name"]).To(Equal("Zoë 🙂"))
+}
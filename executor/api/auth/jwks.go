@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultJWKSRefreshInterval = 10 * time.Minute
+	// Minimum time between fetches triggered by tokens signed with an unknown key
+	minJWKSRefreshInterval = 10 * time.Second
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// KeySet holds the public keys of a JSON Web Key Set loaded from a file or URL. Keys are reloaded
+// periodically and when a token is signed with a key which isn't known yet.
+type KeySet struct {
+	source          string
+	refreshInterval time.Duration
+	client          *http.Client
+	log             logr.Logger
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// Closed when the reload in progress, if any, finishes
+	fetching chan struct{}
+}
+
+// NewKeySet loads the key set from source, which is either an http(s) URL or a file path.
+func NewKeySet(source string, refreshInterval time.Duration, log logr.Logger) (*KeySet, error) {
+	ks := &KeySet{
+		source:          source,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+		log:             log.WithName("KeySet"),
+	}
+	keys, err := ks.fetch()
+	if err != nil {
+		return nil, err
+	}
+	ks.keys = keys
+	ks.fetchedAt = time.Now()
+	return ks, nil
+}
+
+// Key returns the public key with the given id. An empty id is only accepted if the set has a single key.
+// Keys are reloaded in the background so known keys are returned without waiting for the source. Only a
+// request for an unknown key waits for the reload, which is shared by all requests made meanwhile.
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	sinceFetch := time.Since(ks.fetchedAt)
+	key, found := ks.lookup(kid)
+	fetching := ks.fetching
+	if fetching == nil && (sinceFetch > ks.refreshInterval || (!found && sinceFetch > minJWKSRefreshInterval)) {
+		fetching = ks.startRefreshLocked()
+	}
+	ks.mu.Unlock()
+
+	if !found && fetching != nil {
+		<-fetching
+		ks.mu.Lock()
+		key, found = ks.lookup(kid)
+		ks.mu.Unlock()
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// startRefreshLocked reloads the keys in the background, returning a channel closed once they are reloaded.
+func (ks *KeySet) startRefreshLocked() chan struct{} {
+	// Record the attempt even if it fails so an unavailable source isn't retried for every request
+	ks.fetchedAt = time.Now()
+	fetching := make(chan struct{})
+	ks.fetching = fetching
+	go func() {
+		keys, err := ks.fetch()
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		if err != nil {
+			// Keep using the previous keys if the source is temporarily unavailable
+			ks.log.Error(err, "Failed to reload JWKS", "source", ks.source)
+		} else {
+			ks.keys = keys
+			ks.log.V(1).Info("Loaded JWKS", "source", ks.source, "keys", len(keys))
+		}
+		ks.fetching = nil
+		close(fetching)
+	}()
+	return fetching
+}
+
+func (ks *KeySet) fetch() (map[string]crypto.PublicKey, error) {
+	data, err := ks.read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read JWKS from %s", ks.source)
+	}
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse JWKS from %s", ks.source)
+	}
+	return keys, nil
+}
+
+func (ks *KeySet) read() ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "http://") && !strings.HasPrefix(ks.source, "https://") {
+		return ioutil.ReadFile(ks.source)
+	}
+	res, err := ks.client.Get(ks.source)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code %d", res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// parseKeySet returns the RSA and EC signing keys of a JSON Web Key Set by key id. Keys of other
+// types or only used for encryption are ignored.
+func parseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = parseRSAKey(jwk)
+		case "EC":
+			key, err = parseECKey(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q", jwk.Kid)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signing keys found")
+	}
+	return keys, nil
+}
+
+func parseRSAKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseECKey(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := decodeBigInt(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point is not on curve %s", jwk.Crv)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing key parameter")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestKeySetReloadsInBackground(t *testing.T) {
+	g := NewGomegaWithT(t)
+	rsaKey, _ := createKeys(t)
+	jwk := jsonWebKey{Kid: "old", Kty: "RSA", N: encodeBigInt(rsaKey.N), E: encodeBigInt(big.NewInt(int64(rsaKey.E)))}
+
+	var fetches int32
+	var mu sync.Mutex
+	keys := []jsonWebKey{jwk}
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reloads after the first fetch hang until unblocked, like a slow source
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-unblock
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_ = json.NewEncoder(w).Encode(jsonWebKeySet{Keys: keys})
+	}))
+	defer server.Close()
+
+	ks, err := NewKeySet(server.URL, time.Minute, logf.Log)
+	g.Expect(err).To(BeNil())
+	ks.mu.Lock()
+	ks.fetchedAt = time.Now().Add(-time.Hour)
+	ks.mu.Unlock()
+
+	// Known keys are returned while the reload is in progress
+	start := time.Now()
+	_, err = ks.Key("old")
+	g.Expect(err).To(BeNil())
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+	// Unknown keys wait for the reload in progress rather than starting others
+	mu.Lock()
+	jwk.Kid = "new"
+	keys = append(keys, jwk)
+	mu.Unlock()
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ks.Key("new")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		g.Expect(err).To(BeNil())
+	}
+	g.Expect(atomic.LoadInt32(&fetches)).To(Equal(int32(2)))
+}
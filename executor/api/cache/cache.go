@@ -0,0 +1,364 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	seldon "github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const (
+	DefaultMaxEntries = 10000
+
+	// Name of the cache of the whole graph's responses in metrics
+	GraphCacheName = "graph"
+
+	ResultHit  = "hit"
+	ResultMiss = "miss"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	ContentType     string
+	ContentEncoding string
+	// Fully qualified name of a gRPC message, empty for other payloads
+	MessageType string
+	Body        []byte
+}
+
+// Store holds cached responses by key until their TTL passes. Implementations must be safe for concurrent use,
+// so a store shared between replicas can be used in place of the in-memory one.
+type Store interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry, ttl time.Duration)
+}
+
+// MemoryStore keeps responses in memory, removing the least recently used when it holds maxEntries.
+type MemoryStore struct {
+	lru *LRU
+}
+
+// NewMemoryStore creates an in-memory store holding up to maxEntries responses.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{lru: NewLRU(maxEntries)}
+}
+
+func (s *MemoryStore) Get(key string) (*Entry, bool) {
+	value, ok := s.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return value.(*Entry), true
+}
+
+func (s *MemoryStore) Set(key string, entry *Entry, ttl time.Duration) {
+	s.lru.Set(key, entry, ttl)
+}
+
+// Len returns the number of responses held, including any expired but not yet removed.
+func (s *MemoryStore) Len() int {
+	return s.lru.Len()
+}
+
+// Cache returns the responses of a graph, or a graph node, to payloads it has been sent before. It should only
+// be used for graphs and nodes whose responses depend on nothing but their request.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	// Identifies the models whose responses are cached, so responses of other versions aren't returned
+	version string
+	// Whether payloads aren't logged for responses returned from the cache
+	SkipLogging bool
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+// NewCache creates a cache keeping responses of the models identified by version in store for ttl.
+func NewCache(store Store, ttl time.Duration, version string, skipLogging bool, name string, deploymentName string) *Cache {
+	requests := newCacheCounter().MustCurryWith(prometheus.Labels{metric.DeploymentNameMetric: deploymentName, metric.CacheMetric: name})
+	return &Cache{
+		store:       store,
+		ttl:         ttl,
+		version:     version,
+		SkipLogging: skipLogging,
+		hits:        requests.WithLabelValues(ResultHit),
+		misses:      requests.WithLabelValues(ResultMiss),
+	}
+}
+
+func newCacheCounter() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: metric.CacheRequestsMetricName,
+			Help: "Requests answered from a response cache or missing it",
+		},
+		[]string{metric.DeploymentNameMetric, metric.CacheMetric, metric.CacheResultMetric},
+	)
+	if err := prometheus.Register(counter); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			counter = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	return counter
+}
+
+// NewCacheFromSpec creates an in-memory cache from a cache spec.
+func NewCacheFromSpec(spec *v1.CacheSpec, version string, name string, deploymentName string) (*Cache, error) {
+	ttl, err := time.ParseDuration(spec.TTL)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid cache ttl %q for %s", spec.TTL, name)
+	}
+	maxEntries := DefaultMaxEntries
+	if spec.MaxEntries != nil {
+		if *spec.MaxEntries <= 0 {
+			return nil, fmt.Errorf("invalid cache maxEntries %d for %s", *spec.MaxEntries, name)
+		}
+		maxEntries = int(*spec.MaxEntries)
+	}
+	return NewCache(NewMemoryStore(maxEntries), ttl, version, spec.SkipLogging, name, deploymentName), nil
+}
+
+// Key returns the key of the response to a payload, from a hash of the payload without its request ids. The
+// scope, such as the method called, is part of the key.
+func (c *Cache) Key(msg payload.SeldonPayload, scope ...string) (string, error) {
+	body, err := canonicalBytes(msg)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, part := range append([]string{c.version}, scope...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Get returns the cached response for key, counting the hit or miss. The response carries the request ids of req,
+// if it has any, as responses are cached without the ids of the request they answered.
+func (c *Cache) Get(key string, req payload.SeldonPayload) (payload.SeldonPayload, bool) {
+	entry, ok := c.store.Get(key)
+	if ok {
+		if msg, err := toPayload(entry); err == nil {
+			c.hits.Inc()
+			if req != nil {
+				puid, id := requestIds(req)
+				msg = setResponseIds(msg, puid, id)
+			}
+			return msg, true
+		}
+	}
+	c.misses.Inc()
+	return nil, false
+}
+
+// Set caches the response for key without its request ids.
+func (c *Cache) Set(key string, msg payload.SeldonPayload) error {
+	entry, err := toEntry(setResponseIds(msg, "", ""))
+	if err != nil {
+		return err
+	}
+	c.store.Set(key, entry, c.ttl)
+	return nil
+}
+
+// requestIds returns the Seldon protocol PUID and the V2 protocol id of a request.
+func requestIds(msg payload.SeldonPayload) (string, string) {
+	switch m := msg.GetPayload().(type) {
+	case *seldon.SeldonMessage:
+		return m.GetMeta().GetPuid(), ""
+	case *inference.ModelInferRequest:
+		return "", m.GetId()
+	case []byte:
+		if msg.GetContentEncoding() != "" {
+			return "", ""
+		}
+		var value struct {
+			Id   string `json:"id"`
+			Meta struct {
+				Puid string `json:"puid"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(m, &value); err != nil {
+			return "", ""
+		}
+		return value.Meta.Puid, value.Id
+	}
+	return "", ""
+}
+
+// setResponseIds returns a response with its Seldon protocol PUID and V2 protocol id replaced by puid and id,
+// removing them where they are empty. The response is returned unchanged if it has no ids to replace.
+func setResponseIds(msg payload.SeldonPayload, puid string, id string) payload.SeldonPayload {
+	switch m := msg.GetPayload().(type) {
+	case *seldon.SeldonMessage:
+		if m.GetMeta().GetPuid() == puid {
+			return msg
+		}
+		m = proto.Clone(m).(*seldon.SeldonMessage)
+		if m.Meta == nil {
+			m.Meta = &seldon.Meta{}
+		}
+		m.Meta.Puid = puid
+		return &payload.ProtoPayload{Msg: m}
+	case *inference.ModelInferResponse:
+		if m.GetId() == id {
+			return msg
+		}
+		m = proto.Clone(m).(*inference.ModelInferResponse)
+		m.Id = id
+		return &payload.ProtoPayload{Msg: m}
+	case []byte:
+		if msg.GetContentEncoding() != "" {
+			return msg
+		}
+		if body, ok := setJSONIds(m, puid, id); ok {
+			return &payload.BytesPayload{Msg: body, ContentType: msg.GetContentType()}
+		}
+	}
+	return msg
+}
+
+// setJSONIds re-encodes a JSON object with its request ids replaced, returning false if the body isn't a JSON
+// object or already has those ids.
+func setJSONIds(body []byte, puid string, id string) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value map[string]interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	changed := false
+	if current, _ := value["id"].(string); current != id {
+		if id == "" {
+			delete(value, "id")
+		} else {
+			value["id"] = id
+		}
+		changed = true
+	}
+	meta, _ := value["meta"].(map[string]interface{})
+	if current, _ := meta["puid"].(string); current != puid {
+		if puid == "" {
+			delete(meta, "puid")
+		} else {
+			if meta == nil {
+				meta = map[string]interface{}{}
+				value["meta"] = meta
+			}
+			meta["puid"] = puid
+		}
+		changed = true
+	}
+	if !changed {
+		return nil, false
+	}
+	updated, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	return updated, true
+}
+
+// canonicalBytes encodes a payload so that requests differing only in their ids or in the order of their JSON
+// fields encode the same.
+func canonicalBytes(msg payload.SeldonPayload) ([]byte, error) {
+	switch m := msg.GetPayload().(type) {
+	case proto.Message:
+		m = proto.Clone(m)
+		switch r := m.(type) {
+		case *seldon.SeldonMessage:
+			if r.Meta != nil {
+				r.Meta.Puid = ""
+			}
+		case *inference.ModelInferRequest:
+			r.Id = ""
+		}
+		buf := proto.NewBuffer(nil)
+		buf.SetDeterministic(true)
+		if err := buf.Marshal(m); err != nil {
+			return nil, err
+		}
+		return append([]byte(proto.MessageName(m)+"\x00"), buf.Bytes()...), nil
+	case []byte:
+		if msg.GetContentEncoding() == "" {
+			if canonical, ok := canonicalJSON(m); ok {
+				return canonical, nil
+			}
+		}
+		return m, nil
+	default:
+		return msg.GetBytes()
+	}
+}
+
+// canonicalJSON re-encodes a JSON object with its keys sorted, removing the request ids of the Seldon and V2
+// protocols. It returns false if the body isn't a JSON object.
+func canonicalJSON(body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value map[string]interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	delete(value, "id")
+	if meta, ok := value["meta"].(map[string]interface{}); ok {
+		delete(meta, "puid")
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	return canonical, true
+}
+
+func toEntry(msg payload.SeldonPayload) (*Entry, error) {
+	if m, ok := msg.GetPayload().(proto.Message); ok {
+		body, err := proto.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		return &Entry{MessageType: proto.MessageName(m), Body: body}, nil
+	}
+	body, err := msg.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+	return &Entry{
+		ContentType:     msg.GetContentType(),
+		ContentEncoding: msg.GetContentEncoding(),
+		Body:            append([]byte(nil), body...),
+	}, nil
+}
+
+// toPayload creates a new payload from an entry each time so responses can't be changed in the cache.
+func toPayload(entry *Entry) (payload.SeldonPayload, error) {
+	if entry.MessageType == "" {
+		return &payload.BytesPayload{
+			Msg:             append([]byte(nil), entry.Body...),
+			ContentType:     entry.ContentType,
+			ContentEncoding: entry.ContentEncoding,
+		}, nil
+	}
+	messageType := proto.MessageType(entry.MessageType)
+	if messageType == nil {
+		return nil, fmt.Errorf("unknown cached message type %s", entry.MessageType)
+	}
+	msg := reflect.New(messageType.Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(entry.Body, msg); err != nil {
+		return nil, err
+	}
+	return &payload.ProtoPayload{Msg: msg}, nil
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func newTestCache() *Cache {
+	return NewCache(NewMemoryStore(10), time.Minute, "v1", false, "test", "mydep")
+}
+
+func TestKeyIgnoresRequestIds(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := newTestCache()
+
+	a, err := c.Key(&payload.BytesPayload{Msg: []byte(`{"meta":{"puid":"a"},"data":{"ndarray":[1,2]}}`)}, "predict")
+	g.Expect(err).To(BeNil())
+	b, err := c.Key(&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1,2]},"meta":{"puid":"b"}}`)}, "predict")
+	g.Expect(err).To(BeNil())
+	g.Expect(a).To(Equal(b))
+
+	other, err := c.Key(&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1,3]}}`)}, "predict")
+	g.Expect(err).To(BeNil())
+	g.Expect(other).ToNot(Equal(a))
+
+	scoped, err := c.Key(&payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1,2]}}`)}, "transform-input")
+	g.Expect(err).To(BeNil())
+	g.Expect(scoped).ToNot(Equal(a))
+
+	proto1, err := c.Key(&payload.ProtoPayload{Msg: &proto.SeldonMessage{Meta: &proto.Meta{Puid: "a"}, DataOneof: &proto.SeldonMessage_StrData{StrData: "x"}}})
+	g.Expect(err).To(BeNil())
+	proto2, err := c.Key(&payload.ProtoPayload{Msg: &proto.SeldonMessage{Meta: &proto.Meta{Puid: "b"}, DataOneof: &proto.SeldonMessage_StrData{StrData: "x"}}})
+	g.Expect(err).To(BeNil())
+	g.Expect(proto1).To(Equal(proto2))
+}
+
+func TestKeyIncludesVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+	msg := &payload.BytesPayload{Msg: []byte(`{"data":{"ndarray":[1,2]}}`)}
+	a, err := NewCache(NewMemoryStore(10), time.Minute, "v1", false, "test", "mydep").Key(msg)
+	g.Expect(err).To(BeNil())
+	b, err := NewCache(NewMemoryStore(10), time.Minute, "v2", false, "test", "mydep").Key(msg)
+	g.Expect(err).To(BeNil())
+	g.Expect(a).ToNot(Equal(b))
+}
+
+func TestCacheProtoPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := newTestCache()
+	response := &proto.SeldonMessage{DataOneof: &proto.SeldonMessage_StrData{StrData: "response"}}
+
+	_, ok := c.Get("key", nil)
+	g.Expect(ok).To(BeFalse())
+	g.Expect(c.Set("key", &payload.ProtoPayload{Msg: response})).To(BeNil())
+	cached, ok := c.Get("key", nil)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cached.GetPayload().(*proto.SeldonMessage).GetStrData()).To(Equal("response"))
+
+	// Changing a returned response doesn't change the cached one
+	cached.GetPayload().(*proto.SeldonMessage).DataOneof = &proto.SeldonMessage_StrData{StrData: "changed"}
+	cached, _ = c.Get("key", nil)
+	g.Expect(cached.GetPayload().(*proto.SeldonMessage).GetStrData()).To(Equal("response"))
+
+	g.Expect(testutil.ToFloat64(c.hits)).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(c.misses)).To(Equal(1.0))
+}
+
+func TestCacheBytesPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := newTestCache()
+	g.Expect(c.Set("key", &payload.BytesPayload{Msg: []byte(`{"data":1}`), ContentType: "application/json"})).To(BeNil())
+	cached, ok := c.Get("key", nil)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cached.GetPayload()).To(Equal([]byte(`{"data":1}`)))
+	g.Expect(cached.GetContentType()).To(Equal("application/json"))
+}
+
+func TestCacheResponseIds(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := newTestCache()
+
+	response := &payload.BytesPayload{Msg: []byte(`{"meta":{"puid":"a","tags":{"x":1}},"data":1}`), ContentType: "application/json"}
+	g.Expect(c.Set("seldon", response)).To(BeNil())
+	cached, ok := c.Get("seldon", &payload.BytesPayload{Msg: []byte(`{"meta":{"puid":"b"},"data":1}`)})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(cached.GetPayload()).To(MatchJSON(`{"meta":{"puid":"b","tags":{"x":1}},"data":1}`))
+	cached, _ = c.Get("seldon", &payload.BytesPayload{Msg: []byte(`{"data":1}`)})
+	g.Expect(cached.GetPayload()).To(MatchJSON(`{"meta":{"tags":{"x":1}},"data":1}`))
+
+	g.Expect(c.Set("v2", &payload.BytesPayload{Msg: []byte(`{"id":"a","outputs":[]}`)})).To(BeNil())
+	cached, _ = c.Get("v2", &payload.BytesPayload{Msg: []byte(`{"id":"b","inputs":[]}`)})
+	g.Expect(cached.GetPayload()).To(MatchJSON(`{"id":"b","outputs":[]}`))
+
+	g.Expect(c.Set("proto", &payload.ProtoPayload{Msg: &proto.SeldonMessage{Meta: &proto.Meta{Puid: "a"}}})).To(BeNil())
+	cached, _ = c.Get("proto", &payload.ProtoPayload{Msg: &proto.SeldonMessage{Meta: &proto.Meta{Puid: "b"}}})
+	g.Expect(cached.GetPayload().(*proto.SeldonMessage).GetMeta().GetPuid()).To(Equal("b"))
+
+	g.Expect(c.Set("v2proto", &payload.ProtoPayload{Msg: &inference.ModelInferResponse{Id: "a"}})).To(BeNil())
+	cached, _ = c.Get("v2proto", &payload.ProtoPayload{Msg: &inference.ModelInferRequest{Id: "b"}})
+	g.Expect(cached.GetPayload().(*inference.ModelInferResponse).GetId()).To(Equal("b"))
+}
+
+func TestNewCaches(t *testing.T) {
+	g := NewGomegaWithT(t)
+	maxEntries := int32(5)
+	spec := &v1.PredictorSpec{
+		Graph: v1.PredictiveUnit{
+			Name:     "transformer",
+			Children: []v1.PredictiveUnit{{Name: "model", Cache: &v1.CacheSpec{TTL: "1m", MaxEntries: &maxEntries, SkipLogging: true}}},
+		},
+	}
+	caches, err := NewCaches(spec, "graph", "mydep")
+	g.Expect(err).To(BeNil())
+	g.Expect(caches.GraphCache()).To(BeNil())
+	g.Expect(caches.Node("transformer")).To(BeNil())
+	g.Expect(caches.Node("model")).ToNot(BeNil())
+	g.Expect(caches.Node("model").SkipLogging).To(BeTrue())
+	g.Expect(caches.Node("model").ttl).To(Equal(time.Minute))
+
+	spec.Cache = &v1.CacheSpec{TTL: "10s"}
+	caches, err = NewCaches(spec, "graph", "mydep")
+	g.Expect(err).To(BeNil())
+	g.Expect(caches.GraphCache()).ToNot(BeNil())
+	g.Expect(caches.GraphCache().version).To(Equal("graph"))
+
+	spec.Cache.TTL = "soon"
+	_, err = NewCaches(spec, "graph", "mydep")
+	g.Expect(err).ToNot(BeNil())
+
+	caches, err = NewCaches(&v1.PredictorSpec{Graph: v1.PredictiveUnit{Name: "model"}}, "graph", "mydep")
+	g.Expect(err).To(BeNil())
+	g.Expect(caches).To(BeNil())
+	g.Expect(caches.Node("model")).To(BeNil())
+}
@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// Caches holds the response caches of a predictor's graph and of its nodes.
+type Caches struct {
+	// Caches the responses of the whole graph if set
+	Graph *Cache
+	// Caches the responses of the nodes with a cache spec
+	Nodes map[string]*Cache
+}
+
+// NewCaches creates caches for a predictor whose graph, or some of whose nodes, have a cache spec, returning nil
+// if none do. The graph's cache is keyed by graphVersion and each node's by its spec and image.
+func NewCaches(spec *v1.PredictorSpec, graphVersion string, deploymentName string) (*Caches, error) {
+	caches := &Caches{Nodes: map[string]*Cache{}}
+	if spec.Cache != nil {
+		graph, err := NewCacheFromSpec(spec.Cache, graphVersion, GraphCacheName, deploymentName)
+		if err != nil {
+			return nil, err
+		}
+		caches.Graph = graph
+	}
+	for _, node := range v1.GetPredictiveUnitList(&spec.Graph) {
+		if node.Cache == nil {
+			continue
+		}
+		c, err := NewCacheFromSpec(node.Cache, nodeVersion(spec, node), node.Name, deploymentName)
+		if err != nil {
+			return nil, err
+		}
+		caches.Nodes[node.Name] = c
+	}
+	if caches.Graph == nil && len(caches.Nodes) == 0 {
+		return nil, nil
+	}
+	return caches, nil
+}
+
+// nodeVersion hashes a node's spec, without its children, and the image of its container.
+func nodeVersion(spec *v1.PredictorSpec, node *v1.PredictiveUnit) string {
+	unit := *node
+	unit.Children = nil
+	b, err := json.Marshal(&unit)
+	if err != nil {
+		return ""
+	}
+	if container := v1.GetContainerForPredictiveUnit(spec, node.Name); container != nil {
+		b = append(b, container.Image...)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Node returns the cache of a node's responses, or nil if it has none.
+func (c *Caches) Node(name string) *Cache {
+	if c == nil {
+		return nil
+	}
+	return c.Nodes[name]
+}
+
+// GraphCache returns the cache of the whole graph's responses, or nil if it has none.
+func (c *Caches) GraphCache() *Cache {
+	if c == nil {
+		return nil
+	}
+	return c.Graph
+}
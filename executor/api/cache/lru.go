@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// LRU holds values until their TTL passes, removing the least recently used when it holds maxEntries. It is
+// safe for concurrent use.
+type LRU struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewLRU creates an LRU holding up to maxEntries values.
+func NewLRU(maxEntries int) *LRU {
+	return &LRU{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the value held for key if it hasn't expired.
+func (l *LRU) Get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if !l.now().Before(entry.expires) {
+		l.remove(element)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set holds value for key for ttl.
+func (l *LRU) Set(key string, value interface{}, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := &lruEntry{key: key, value: value, expires: l.now().Add(ttl)}
+	if element, ok := l.entries[key]; ok {
+		element.Value = entry
+		l.order.MoveToFront(element)
+		return
+	}
+	l.entries[key] = l.order.PushFront(entry)
+	for l.order.Len() > l.maxEntries {
+		l.remove(l.order.Back())
+	}
+}
+
+// Len returns the number of values held, including any expired but not yet removed.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LRU) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+}
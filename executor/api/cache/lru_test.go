@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLRUExpires(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Now()
+	l := NewLRU(10)
+	l.now = func() time.Time { return now }
+
+	l.Set("a", "value", time.Minute)
+	value, ok := l.Get("a")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(value).To(Equal("value"))
+
+	now = now.Add(time.Minute)
+	_, ok = l.Get("a")
+	g.Expect(ok).To(BeFalse())
+	g.Expect(l.Len()).To(Equal(0))
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	l := NewLRU(2)
+	l.Set("a", 1, time.Minute)
+	l.Set("b", 2, time.Minute)
+	_, ok := l.Get("a")
+	g.Expect(ok).To(BeTrue())
+
+	l.Set("c", 3, time.Minute)
+	g.Expect(l.Len()).To(Equal(2))
+	_, ok = l.Get("b")
+	g.Expect(ok).To(BeFalse())
+	_, ok = l.Get("a")
+	g.Expect(ok).To(BeTrue())
+	_, ok = l.Get("c")
+	g.Expect(ok).To(BeTrue())
+}
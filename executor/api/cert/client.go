@@ -0,0 +1,73 @@
+package cert
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const (
+	DefaultCertFileName = "tls.crt"
+	DefaultKeyFileName  = "tls.key"
+	DefaultCAFileName   = "ca.crt"
+)
+
+// Client certificates are shared by all connections using them so each is only watched once
+var (
+	clientReloadersMu sync.Mutex
+	clientReloaders   = map[string]*CertificateReloader{}
+)
+
+func getClientCertificateReloader(certPath string, keyPath string, log logr.Logger) (*CertificateReloader, error) {
+	clientReloadersMu.Lock()
+	defer clientReloadersMu.Unlock()
+	key := certPath + ":" + keyPath
+	if reloader, ok := clientReloaders[key]; ok {
+		return reloader, nil
+	}
+	reloader, err := NewCertificateReloader(certPath, keyPath, log)
+	if err != nil {
+		return nil, err
+	}
+	clientReloaders[key] = reloader
+	return reloader, nil
+}
+
+// NewClientTLSConfig creates the TLS configuration for calling an endpoint of the graph. Files which are
+// not named explicitly are optional: without a CA bundle the system roots are used and without a
+// certificate no client certificate is presented.
+func NewClientTLSConfig(endpointTLS *v1.EndpointTLS, log logr.Logger) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         endpointTLS.ServerName,
+		InsecureSkipVerify: endpointTLS.InsecureSkipVerify,
+	}
+	if endpointTLS.SecretName == "" {
+		return config, nil
+	}
+
+	caPath := endpointTLS.GetFilePath(endpointTLS.CAFileName, DefaultCAFileName)
+	if _, err := os.Stat(caPath); err == nil || endpointTLS.CAFileName != "" {
+		pool, err := LoadCertPool(caPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load CA bundle for secret %s", endpointTLS.SecretName)
+		}
+		config.RootCAs = pool
+	}
+
+	certPath := endpointTLS.GetFilePath(endpointTLS.CertFileName, DefaultCertFileName)
+	keyPath := endpointTLS.GetFilePath(endpointTLS.KeyFileName, DefaultKeyFileName)
+	if _, err := os.Stat(certPath); err == nil || endpointTLS.CertFileName != "" {
+		reloader, err := getClientCertificateReloader(certPath, keyPath, log)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load client certificate for secret %s", endpointTLS.SecretName)
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.Certificate(), nil
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,31 @@
+package cert
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestNewClientTLSConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config, err := NewClientTLSConfig(&v1.EndpointTLS{ServerName: "model.default", InsecureSkipVerify: true}, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(config.ServerName).To(Equal("model.default"))
+	g.Expect(config.InsecureSkipVerify).To(BeTrue())
+	g.Expect(config.RootCAs).To(BeNil())
+	g.Expect(config.GetClientCertificate).To(BeNil())
+
+	// Files in a secret which isn't mounted are optional unless named explicitly
+	config, err = NewClientTLSConfig(&v1.EndpointTLS{SecretName: "missing"}, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(config.GetClientCertificate).To(BeNil())
+
+	_, err = NewClientTLSConfig(&v1.EndpointTLS{SecretName: "missing", CAFileName: "ca.pem"}, logf.Log)
+	g.Expect(err).ToNot(BeNil())
+
+	_, err = NewClientTLSConfig(&v1.EndpointTLS{SecretName: "missing", CertFileName: "client.pem"}, logf.Log)
+	g.Expect(err).ToNot(BeNil())
+}
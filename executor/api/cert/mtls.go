@@ -0,0 +1,131 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Ask clients for a certificate without verifying it
+	ClientAuthRequest = "request"
+	// Verify client certificates against the CA if one is presented
+	ClientAuthVerify = "verify"
+	// Require clients to present a certificate signed by the CA
+	ClientAuthRequire = "require"
+)
+
+// ClientAuthType maps a client auth mode to the equivalent tls.ClientAuthType.
+func ClientAuthType(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "":
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthVerify:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("unknown client auth mode %s, must be one of %s, %s or %s", mode, ClientAuthRequest, ClientAuthVerify, ClientAuthRequire)
+}
+
+// LoadCertPool loads a PEM encoded CA bundle.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// ConfigureClientAuth updates the server TLS configuration to authenticate clients. The CA bundle is
+// only needed when client certificates are verified. If allowed name patterns are given, verified
+// client certificates must have a subject common name or SAN matching one of them, where * matches
+// any characters. Allowed names need a mode which verifies client certificates.
+func ConfigureClientAuth(config *tls.Config, mode string, caPath string, allowedNames []string) error {
+	clientAuth, err := ClientAuthType(mode)
+	if err != nil {
+		return err
+	}
+	if len(allowedNames) > 0 && clientAuth != tls.VerifyClientCertIfGiven && clientAuth != tls.RequireAndVerifyClientCert {
+		return fmt.Errorf("allowed client names need client auth mode %s or %s", ClientAuthVerify, ClientAuthRequire)
+	}
+	config.ClientAuth = clientAuth
+	if clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert {
+		pool, err := LoadCertPool(caPath)
+		if err != nil {
+			return fmt.Errorf("failed to load client CA bundle: %w", err)
+		}
+		config.ClientCAs = pool
+	}
+	if len(allowedNames) > 0 {
+		var patterns []*regexp.Regexp
+		for _, name := range allowedNames {
+			pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSpace(name)), `\*`, ".*") + "$"
+			patterns = append(patterns, regexp.MustCompile(pattern))
+		}
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyAllowedNames(state, patterns)
+		}
+	}
+	return nil
+}
+
+func verifyAllowedNames(state tls.ConnectionState, patterns []*regexp.Regexp) error {
+	if len(state.PeerCertificates) == 0 {
+		// Whether a certificate is needed at all is decided by the client auth mode
+		return nil
+	}
+	if len(state.VerifiedChains) == 0 {
+		return fmt.Errorf("client certificate must be verified to match allowed names")
+	}
+	leaf := state.VerifiedChains[0][0]
+	for _, name := range certificateNames(leaf) {
+		for _, pattern := range patterns {
+			if pattern.MatchString(name) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("client certificate %s does not match any allowed name", leaf.Subject.CommonName)
+}
+
+func certificateNames(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// ClientIdentity returns the identity of a verified client certificate: its first URI SAN, such
+// as a SPIFFE ID, or else its subject common name or first DNS SAN. Unverified certificates have
+// no identity.
+func ClientIdentity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	leaf := state.VerifiedChains[0][0]
+	switch {
+	case len(leaf.URIs) > 0:
+		return leaf.URIs[0].String()
+	case leaf.Subject.CommonName != "":
+		return leaf.Subject.CommonName
+	case len(leaf.DNSNames) > 0:
+		return leaf.DNSNames[0]
+	}
+	return ""
+}
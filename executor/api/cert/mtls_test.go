@@ -0,0 +1,142 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func createCertificate(g *GomegaWithT, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (tls.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).To(BeNil())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	g.Expect(err).To(BeNil())
+	leaf, err := x509.ParseCertificate(der)
+	g.Expect(err).To(BeNil())
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, key
+}
+
+// handshake connects a client presenting clientCerts to a server using serverConfig and returns the
+// server side connection state.
+func handshake(g *GomegaWithT, serverConfig *tls.Config, clientCerts []tls.Certificate) (tls.ConnectionState, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(BeNil())
+	defer lis.Close()
+	go func() {
+		conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{InsecureSkipVerify: true, Certificates: clientCerts})
+		if err == nil {
+			// Wait for the server to finish the handshake and close the connection
+			io.Copy(ioutil.Discard, conn)
+			conn.Close()
+		}
+	}()
+	conn, err := lis.Accept()
+	g.Expect(err).To(BeNil())
+	server := tls.Server(conn, serverConfig)
+	defer server.Close()
+	err = server.Handshake()
+	return server.ConnectionState(), err
+}
+
+func TestClientAuthType(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for mode, expected := range map[string]tls.ClientAuthType{
+		"":                tls.NoClientCert,
+		ClientAuthRequest: tls.RequestClientCert,
+		ClientAuthVerify:  tls.VerifyClientCertIfGiven,
+		ClientAuthRequire: tls.RequireAndVerifyClientCert,
+	} {
+		clientAuth, err := ClientAuthType(mode)
+		g.Expect(err).To(BeNil())
+		g.Expect(clientAuth).To(Equal(expected))
+	}
+	_, err := ClientAuthType("foo")
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestConfigureClientAuth(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	ca, caKey := createCertificate(g, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	serverCert, _ := createCertificate(g, &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "server"}}, ca.Leaf, caKey)
+	spiffeId, _ := url.Parse("spiffe://cluster.local/ns/default/sa/client")
+	clientCert, _ := createCertificate(g, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		URIs:         []*url.URL{spiffeId},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca.Leaf, caKey)
+	otherCert, _ := createCertificate(g, &x509.Certificate{SerialNumber: big.NewInt(4), Subject: pkix.Name{CommonName: "other"}}, nil, nil)
+
+	dir, err := ioutil.TempDir("", "ca")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+	caPath := filepath.Join(dir, "ca.crt")
+	g.Expect(ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate[0]}), 0600)).To(BeNil())
+
+	// The CA bundle is needed to verify clients
+	g.Expect(ConfigureClientAuth(&tls.Config{}, ClientAuthRequire, filepath.Join(dir, "missing.crt"), nil)).ToNot(BeNil())
+
+	config := &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	g.Expect(ConfigureClientAuth(config, ClientAuthRequire, caPath, []string{"spiffe://cluster.local/ns/default/*"})).To(BeNil())
+
+	state, err := handshake(g, config, []tls.Certificate{clientCert})
+	g.Expect(err).To(BeNil())
+	g.Expect(ClientIdentity(&state)).To(Equal("spiffe://cluster.local/ns/default/sa/client"))
+
+	_, err = handshake(g, config, nil)
+	g.Expect(err).ToNot(BeNil())
+
+	_, err = handshake(g, config, []tls.Certificate{otherCert})
+	g.Expect(err).ToNot(BeNil())
+
+	// Names which don't match are rejected
+	config = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	g.Expect(ConfigureClientAuth(config, ClientAuthVerify, caPath, []string{"admin*"})).To(BeNil())
+	_, err = handshake(g, config, []tls.Certificate{clientCert})
+	g.Expect(err).ToNot(BeNil())
+
+	// Clients without a certificate have no identity
+	state, err = handshake(g, config, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(ClientIdentity(&state)).To(Equal(""))
+
+	// Allowed names can't be checked against unverified certificates
+	g.Expect(ConfigureClientAuth(&tls.Config{}, ClientAuthRequest, "", []string{"client"})).ToNot(BeNil())
+	g.Expect(ConfigureClientAuth(&tls.Config{}, "", "", []string{"client"})).ToNot(BeNil())
+
+	// Unverified certificates have no identity
+	config = &tls.Config{Certificates: []tls.Certificate{serverCert}}
+	g.Expect(ConfigureClientAuth(config, ClientAuthRequest, "", nil)).To(BeNil())
+	state, err = handshake(g, config, []tls.Certificate{otherCert})
+	g.Expect(err).To(BeNil())
+	g.Expect(state.PeerCertificates).To(HaveLen(1))
+	g.Expect(ClientIdentity(&state)).To(Equal(""))
+}
@@ -0,0 +1,101 @@
+package cert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/util"
+)
+
+// CertificateReloader holds a TLS key pair loaded from disk and reloads it whenever the files change,
+// so certificates rotated by cert-manager or an updated Secret are used without restarting.
+type CertificateReloader struct {
+	certPath string
+	keyPath  string
+	log      logr.Logger
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	watcher  *fsnotify.Watcher
+	expiry   *prometheus.GaugeVec
+}
+
+// NewCertificateReloader loads the key pair and starts watching the directories containing it.
+// An error is returned if the initial key pair can not be loaded.
+func NewCertificateReloader(certPath string, keyPath string, log logr.Logger) (*CertificateReloader, error) {
+	c := &CertificateReloader{
+		certPath: certPath,
+		keyPath:  keyPath,
+		log:      log.WithName("CertificateReloader"),
+		expiry:   newCertificateExpiryGauge(),
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := util.WatchFiles([]string{certPath, keyPath}, "certificate", c.Reload, c.log)
+	if err != nil {
+		return nil, err
+	}
+	c.watcher = watcher
+	return c, nil
+}
+
+func newCertificateExpiryGauge() *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: metric.CertificateExpiryMetricName,
+			Help: "Expiry time of the TLS certificate in seconds since the epoch",
+		},
+		[]string{metric.CertificatePathMetric},
+	)
+	if err := prometheus.Register(gauge); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			gauge = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+	return gauge
+}
+
+// Reload loads the key pair from disk. If it is invalid the previous key pair is kept.
+func (c *CertificateReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load certificate %s", c.certPath)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse certificate %s", c.certPath)
+	}
+	cert.Leaf = leaf
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+
+	c.expiry.WithLabelValues(c.certPath).Set(float64(leaf.NotAfter.Unix()))
+	c.log.Info("Loaded certificate", "path", c.certPath, "expiry", leaf.NotAfter)
+	return nil
+}
+
+// Certificate returns the current key pair.
+func (c *CertificateReloader) Certificate() *tls.Certificate {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert
+}
+
+// GetCertificate can be used as the tls.Config GetCertificate callback.
+func (c *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.Certificate(), nil
+}
+
+// Close stops watching for changes.
+func (c *CertificateReloader) Close() error {
+	return c.watcher.Close()
+}
@@ -0,0 +1,80 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func writeKeyPair(g *GomegaWithT, dir string, commonName string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).To(BeNil())
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	g.Expect(err).To(BeNil())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	g.Expect(err).To(BeNil())
+
+	// Write to temporary files and rename so the reloader never sees a partially written pair
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "tls.crt.tmp"), certPem, 0600)).To(BeNil())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "tls.key.tmp"), keyPem, 0600)).To(BeNil())
+	g.Expect(os.Rename(filepath.Join(dir, "tls.key.tmp"), filepath.Join(dir, "tls.key"))).To(BeNil())
+	g.Expect(os.Rename(filepath.Join(dir, "tls.crt.tmp"), filepath.Join(dir, "tls.crt"))).To(BeNil())
+}
+
+func commonName(c *CertificateReloader) string {
+	cert, _ := c.GetCertificate(&tls.ClientHelloInfo{})
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestCertificateReloader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "certs")
+	g.Expect(err).To(BeNil())
+	defer os.RemoveAll(dir)
+
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+
+	_, err = NewCertificateReloader(certPath, keyPath, logf.Log)
+	g.Expect(err).ToNot(BeNil())
+
+	expiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	writeKeyPair(g, dir, "first", expiry)
+	c, err := NewCertificateReloader(certPath, keyPath, logf.Log)
+	g.Expect(err).To(BeNil())
+	defer c.Close()
+	g.Expect(commonName(c)).To(Equal("first"))
+	g.Expect(testutil.ToFloat64(c.expiry.WithLabelValues(certPath))).To(Equal(float64(expiry.Unix())))
+
+	// Rotated certificates are picked up
+	writeKeyPair(g, dir, "second", expiry.Add(time.Hour))
+	g.Eventually(func() string { return commonName(c) }, 5*time.Second).Should(Equal("second"))
+	g.Eventually(func() float64 { return testutil.ToFloat64(c.expiry.WithLabelValues(certPath)) }).Should(Equal(float64(expiry.Add(time.Hour).Unix())))
+
+	// Invalid certificates are ignored
+	g.Expect(ioutil.WriteFile(certPath, []byte("invalid"), 0600)).To(BeNil())
+	g.Expect(c.Reload()).ToNot(BeNil())
+	g.Consistently(func() string { return commonName(c) }, 500*time.Millisecond).Should(Equal("second"))
+}
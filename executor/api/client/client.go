@@ -19,6 +19,23 @@ const (
 	SeldonMetadataPath        = "/metadata"
 )
 
+// nodeNameKey holds the name of the graph node a call is made to in its context.
+type nodeNameKey struct{}
+
+// WithNodeName returns a context for calls to the named graph node. Clients look up the node's settings by this
+// name, as the model name they are called with is overridden by requests naming a model.
+func WithNodeName(ctx context.Context, nodeName string) context.Context {
+	return context.WithValue(ctx, nodeNameKey{}, nodeName)
+}
+
+// NodeName returns the name of the graph node a call is made to, or modelName if the context doesn't name one.
+func NodeName(ctx context.Context, modelName string) string {
+	if nodeName, ok := ctx.Value(nodeNameKey{}).(string); ok && nodeName != "" {
+		return nodeName
+	}
+	return modelName
+}
+
 type SeldonApiClient interface {
 	Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error)
 	TransformInput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error)
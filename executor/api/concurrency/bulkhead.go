@@ -0,0 +1,136 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BulkheadFullError is returned for calls to a graph node rejected because its bulkhead is full. It is
+// converted to the Unavailable code by gRPC.
+type BulkheadFullError struct {
+	Node string
+}
+
+func (e *BulkheadFullError) Error() string {
+	return fmt.Sprintf("bulkhead full for node %s: too many concurrent requests", e.Node)
+}
+
+func (e *BulkheadFullError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// IsBulkheadFull returns whether a call failed fast because a node's bulkhead was full, rather than the
+// node failing.
+func IsBulkheadFull(err error) bool {
+	var bulkheadErr *BulkheadFullError
+	return errors.As(err, &bulkheadErr)
+}
+
+// Bulkhead bounds the number of calls made to a single graph node at once so a slow node can't hold every
+// request, with a limited number of further calls waiting for a slot.
+type Bulkhead struct {
+	node   string
+	slots  chan struct{}
+	queue  chan struct{}
+	active prometheus.Gauge
+	queued prometheus.Gauge
+}
+
+// NewBulkhead creates a bulkhead allowing maxConcurrency calls to a node at once with at most maxQueue
+// more waiting.
+func NewBulkhead(node string, maxConcurrency int, maxQueue int, deploymentName string) *Bulkhead {
+	labels := prometheus.Labels{metric.DeploymentNameMetric: deploymentName, metric.ModelNameMetric: node}
+	return &Bulkhead{
+		node:   node,
+		slots:  make(chan struct{}, maxConcurrency),
+		queue:  make(chan struct{}, maxQueue),
+		active: registerNodeGaugeVec(metric.BulkheadActiveMetricName, "Requests being processed by a graph node").With(labels),
+		queued: registerNodeGaugeVec(metric.BulkheadQueuedMetricName, "Requests waiting for a graph node").With(labels),
+	}
+}
+
+func registerNodeGaugeVec(name string, help string) *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, []string{metric.DeploymentNameMetric, metric.ModelNameMetric})
+	if err := prometheus.Register(gauge); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			gauge = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+	return gauge
+}
+
+// Acquire waits for a slot to call the node, returning a function to release it once the call is done.
+// Calls are rejected straight away with a BulkheadFullError if the queue is full.
+func (b *Bulkhead) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case b.slots <- struct{}{}:
+		return b.start(), nil
+	default:
+	}
+	select {
+	case b.queue <- struct{}{}:
+	default:
+		return nil, &BulkheadFullError{Node: b.node}
+	}
+	b.queued.Inc()
+	defer func() {
+		<-b.queue
+		b.queued.Dec()
+	}()
+	select {
+	case b.slots <- struct{}{}:
+		return b.start(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *Bulkhead) start() func() {
+	b.active.Inc()
+	return func() {
+		b.active.Dec()
+		<-b.slots
+	}
+}
+
+// Bulkheads holds the bulkheads of the graph nodes with limited concurrency.
+type Bulkheads map[string]*Bulkhead
+
+// NewBulkheads creates bulkheads for the nodes of a graph which set maxConcurrency, returning nil if none do.
+func NewBulkheads(graph *v1.PredictiveUnit, deploymentName string) Bulkheads {
+	bulkheads := Bulkheads{}
+	bulkheads.add(graph, deploymentName)
+	if len(bulkheads) == 0 {
+		return nil
+	}
+	return bulkheads
+}
+
+func (b Bulkheads) add(node *v1.PredictiveUnit, deploymentName string) {
+	if node.MaxConcurrency != nil && *node.MaxConcurrency > 0 {
+		maxQueue := 0
+		if node.MaxQueueLength != nil {
+			maxQueue = int(*node.MaxQueueLength)
+		}
+		b[node.Name] = NewBulkhead(node.Name, int(*node.MaxConcurrency), maxQueue, deploymentName)
+	}
+	for i := range node.Children {
+		b.add(&node.Children[i], deploymentName)
+	}
+}
+
+// Acquire waits for a slot to call the named node, which always succeeds straight away for nodes without
+// a bulkhead.
+func (b Bulkheads) Acquire(ctx context.Context, node string) (func(), error) {
+	if bulkhead, ok := b[node]; ok {
+		return bulkhead.Acquire(ctx)
+	}
+	return func() {}, nil
+}
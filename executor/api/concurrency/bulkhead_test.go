@@ -0,0 +1,102 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBulkhead(t *testing.T) {
+	g := NewGomegaWithT(t)
+	b := NewBulkhead("model", 1, 1, "test")
+
+	release, err := b.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+
+	// One call can wait for the slot, further calls fail fast
+	acquired := make(chan func())
+	go func() {
+		release, err := b.Acquire(context.TODO())
+		if err == nil {
+			acquired <- release
+		}
+	}()
+	g.Eventually(func() int {
+		return len(b.queue)
+	}).Should(Equal(1))
+	_, err = b.Acquire(context.TODO())
+	g.Expect(err).To(Equal(&BulkheadFullError{Node: "model"}))
+	g.Consistently(acquired, 50*time.Millisecond).ShouldNot(Receive())
+
+	release()
+	var queuedRelease func()
+	g.Eventually(acquired).Should(Receive(&queuedRelease))
+	queuedRelease()
+
+	release, err = b.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+	release()
+}
+
+func TestBulkheadContextDone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	b := NewBulkhead("model", 1, 1, "test")
+
+	release, err := b.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Acquire(ctx)
+	g.Expect(err).To(Equal(context.DeadlineExceeded))
+
+	// The queue is free again once the waiting call gives up
+	ctx, cancel = context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = b.Acquire(ctx)
+	g.Expect(err).To(Equal(context.DeadlineExceeded))
+}
+
+func TestIsBulkheadFull(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	err := &BulkheadFullError{Node: "model"}
+	g.Expect(IsBulkheadFull(err)).To(BeTrue())
+	g.Expect(IsBulkheadFull(errors.Wrap(err, "predict"))).To(BeTrue())
+	g.Expect(IsBulkheadFull(fmt.Errorf("model failed"))).To(BeFalse())
+	g.Expect(IsBulkheadFull(&OverloadedError{Reason: ReasonQueueFull})).To(BeFalse())
+	g.Expect(status.Code(err)).To(Equal(codes.Unavailable))
+}
+
+func TestNewBulkheads(t *testing.T) {
+	g := NewGomegaWithT(t)
+	limit := func(i int32) *int32 {
+		return &i
+	}
+
+	g.Expect(NewBulkheads(&v1.PredictiveUnit{Name: "model"}, "test")).To(BeNil())
+
+	b := NewBulkheads(&v1.PredictiveUnit{
+		Name:           "router",
+		MaxConcurrency: limit(0),
+		Children: []v1.PredictiveUnit{
+			{Name: "a", MaxConcurrency: limit(2), MaxQueueLength: limit(5)},
+			{Name: "b"},
+		},
+	}, "test")
+	g.Expect(b).To(HaveLen(1))
+	g.Expect(cap(b["a"].slots)).To(Equal(2))
+	g.Expect(cap(b["a"].queue)).To(Equal(5))
+
+	release, err := b.Acquire(context.TODO(), "b")
+	g.Expect(err).To(BeNil())
+	release()
+}
@@ -0,0 +1,296 @@
+package concurrency
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultTargetLatency = time.Second
+	// The executor is reported as overloaded for this long after rejecting a request
+	overloadWindow = time.Second
+	// Multiplier applied to the adaptive limit when latency exceeds the target
+	backoffRatio = 0.9
+	minLimit     = 1
+
+	ReasonQueueFull    = "queue_full"
+	ReasonQueueTimeout = "queue_timeout"
+)
+
+// OverloadedError is returned for requests rejected because too many are in progress. It is converted to
+// the Unavailable code by gRPC.
+type OverloadedError struct {
+	Reason string
+}
+
+func (e *OverloadedError) Error() string {
+	switch e.Reason {
+	case ReasonQueueTimeout:
+		return "executor overloaded: timed out waiting for a request slot"
+	default:
+		return "executor overloaded: too many concurrent requests"
+	}
+}
+
+func (e *OverloadedError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// Limiter bounds the number of requests processed concurrently, queueing a limited number of further
+// requests. In adaptive mode the limit is lowered when latency exceeds a target and raised additively
+// while requests keep the limit saturated (AIMD).
+type Limiter struct {
+	maxLimit      int
+	maxQueue      int
+	queueTimeout  time.Duration
+	adaptive      bool
+	targetLatency time.Duration
+
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	waiters      *list.List
+	lastRejected time.Time
+
+	inFlightGauge prometheus.Gauge
+	limitGauge    prometheus.Gauge
+	shed          *prometheus.CounterVec
+}
+
+// NewLimiter creates a limiter allowing up to maxConcurrency requests at once with at most maxQueue more
+// waiting for up to queueTimeout, or until their context is done if zero. A targetLatency above zero
+// enables the adaptive limit, starting at maxConcurrency.
+func NewLimiter(maxConcurrency int, maxQueue int, queueTimeout time.Duration, targetLatency time.Duration, deploymentName string) *Limiter {
+	l := &Limiter{
+		maxLimit:      maxConcurrency,
+		maxQueue:      maxQueue,
+		queueTimeout:  queueTimeout,
+		adaptive:      targetLatency > 0,
+		targetLatency: targetLatency,
+		limit:         float64(maxConcurrency),
+		waiters:       list.New(),
+	}
+	labels := prometheus.Labels{metric.DeploymentNameMetric: deploymentName}
+	l.inFlightGauge = registerGaugeVec(metric.ConcurrencyInFlightMetricName, "Requests being processed by the graph").With(labels)
+	l.limitGauge = registerGaugeVec(metric.ConcurrencyLimitMetricName, "Maximum number of requests processed by the graph at once").With(labels)
+	l.shed = registerCounterVec().MustCurryWith(labels)
+	l.limitGauge.Set(l.limit)
+	return l
+}
+
+func registerGaugeVec(name string, help string) *prometheus.GaugeVec {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, []string{metric.DeploymentNameMetric})
+	if err := prometheus.Register(gauge); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			gauge = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+	return gauge
+}
+
+func registerCounterVec() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: metric.ConcurrencyShedMetricName,
+			Help: "Requests rejected because the executor was overloaded",
+		},
+		[]string{metric.DeploymentNameMetric, metric.ConcurrencyShedReasonMetric},
+	)
+	if err := prometheus.Register(counter); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			counter = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	return counter
+}
+
+// NewLimiterFromAnnotations creates a limiter from the deployment's annotations, returning nil if the
+// concurrency isn't limited.
+func NewLimiterFromAnnotations(annotations map[string]string, deploymentName string) (*Limiter, error) {
+	maxConcurrency, err := parseIntAnnotation(annotations, k8s.ANNOTATION_MAX_CONCURRENCY)
+	if err != nil || maxConcurrency == 0 {
+		return nil, err
+	}
+	maxQueue, err := parseIntAnnotation(annotations, k8s.ANNOTATION_CONCURRENCY_QUEUE_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+	queueTimeout, err := parseDurationAnnotation(annotations, k8s.ANNOTATION_CONCURRENCY_QUEUE_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	var targetLatency time.Duration
+	if adaptive := annotations[k8s.ANNOTATION_CONCURRENCY_ADAPTIVE]; adaptive != "" {
+		enabled, err := strconv.ParseBool(adaptive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_CONCURRENCY_ADAPTIVE, adaptive)
+		}
+		if enabled {
+			if targetLatency, err = parseDurationAnnotation(annotations, k8s.ANNOTATION_CONCURRENCY_TARGET_LATENCY); err != nil {
+				return nil, err
+			}
+			if targetLatency == 0 {
+				targetLatency = DefaultTargetLatency
+			}
+		}
+	}
+	return NewLimiter(maxConcurrency, maxQueue, queueTimeout, targetLatency, deploymentName), nil
+}
+
+func parseIntAnnotation(annotations map[string]string, name string) (int, error) {
+	val := annotations[name]
+	if val == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return i, nil
+}
+
+func parseDurationAnnotation(annotations map[string]string, name string) (time.Duration, error) {
+	val := annotations[name]
+	if val == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return d, nil
+}
+
+// Acquire waits for a request slot, returning a function to release it with the request's error once
+// processed. Requests are rejected with an OverloadedError if the queue is full or the queue timeout passes.
+func (l *Limiter) Acquire(ctx context.Context) (func(error), error) {
+	l.mu.Lock()
+	if l.inFlight < l.currentLimit() && l.waiters.Len() == 0 {
+		l.startLocked()
+		l.mu.Unlock()
+		return l.releaser(), nil
+	}
+	if l.waiters.Len() >= l.maxQueue {
+		err := l.rejectLocked(ReasonQueueFull)
+		l.mu.Unlock()
+		return nil, err
+	}
+	granted := make(chan struct{})
+	elem := l.waiters.PushBack(granted)
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case <-granted:
+		return l.releaser(), nil
+	case <-timeout:
+		err = &OverloadedError{Reason: ReasonQueueTimeout}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-granted:
+		// The slot was granted while giving up so use it
+		return l.releaser(), nil
+	default:
+	}
+	l.waiters.Remove(elem)
+	if _, ok := err.(*OverloadedError); ok {
+		return nil, l.rejectLocked(ReasonQueueTimeout)
+	}
+	return nil, err
+}
+
+// Overloaded returns whether requests have been rejected recently.
+func (l *Limiter) Overloaded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return !l.lastRejected.IsZero() && time.Since(l.lastRejected) < overloadWindow
+}
+
+// Limit returns the current limit on concurrent requests.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.currentLimit()
+}
+
+func (l *Limiter) currentLimit() int {
+	return int(l.limit)
+}
+
+func (l *Limiter) startLocked() {
+	l.inFlight++
+	l.inFlightGauge.Set(float64(l.inFlight))
+}
+
+func (l *Limiter) rejectLocked(reason string) error {
+	l.lastRejected = time.Now()
+	l.shed.WithLabelValues(reason).Inc()
+	return &OverloadedError{Reason: reason}
+}
+
+func (l *Limiter) releaser() func(error) {
+	start := time.Now()
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			l.release(time.Since(start), err)
+		})
+	}
+}
+
+func (l *Limiter) release(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.adaptive {
+		if latency > l.targetLatency || isTimeout(err) {
+			l.limit = math.Max(minLimit, math.Floor(l.limit*backoffRatio))
+		} else if l.inFlight >= l.currentLimit() {
+			// Only grow while the limit is being reached, otherwise it would grow without bound when idle
+			l.limit = math.Min(float64(l.maxLimit), l.limit+1/l.limit)
+		}
+		l.limitGauge.Set(float64(l.currentLimit()))
+	}
+	l.inFlight--
+	for l.inFlight < l.currentLimit() && l.waiters.Len() > 0 {
+		front := l.waiters.Front()
+		l.waiters.Remove(front)
+		l.inFlight++
+		close(front.Value.(chan struct{}))
+	}
+	l.inFlightGauge.Set(float64(l.inFlight))
+}
+
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
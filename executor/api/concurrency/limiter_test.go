@@ -0,0 +1,136 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimiterRejectsWithoutQueue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(1, 0, 0, 0, "no-queue")
+	release, err := l.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+	g.Expect(l.Overloaded()).To(BeFalse())
+
+	_, err = l.Acquire(context.TODO())
+	g.Expect(err).To(Equal(&OverloadedError{Reason: ReasonQueueFull}))
+	g.Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	g.Expect(l.Overloaded()).To(BeTrue())
+
+	release(nil)
+	release, err = l.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+	release(nil)
+}
+
+func TestLimiterQueue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(1, 1, 0, 0, "queue")
+	release, err := l.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+
+	acquired := make(chan error)
+	go func() {
+		release, err := l.Acquire(context.TODO())
+		if err == nil {
+			release(nil)
+		}
+		acquired <- err
+	}()
+	g.Eventually(func() int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.waiters.Len()
+	}).Should(Equal(1))
+
+	// The queue is full
+	_, err = l.Acquire(context.TODO())
+	g.Expect(err).To(Equal(&OverloadedError{Reason: ReasonQueueFull}))
+
+	release(nil)
+	g.Eventually(acquired).Should(Receive(BeNil()))
+	g.Expect(l.inFlight).To(Equal(0))
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(1, 1, 10*time.Millisecond, 0, "queue-timeout")
+	release, err := l.Acquire(context.TODO())
+	g.Expect(err).To(BeNil())
+	defer release(nil)
+
+	_, err = l.Acquire(context.TODO())
+	g.Expect(err).To(Equal(&OverloadedError{Reason: ReasonQueueTimeout}))
+	g.Expect(l.waiters.Len()).To(Equal(0))
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	l.queueTimeout = 0
+	_, err = l.Acquire(ctx)
+	g.Expect(err).To(Equal(context.Canceled))
+	g.Expect(l.waiters.Len()).To(Equal(0))
+}
+
+func TestLimiterAdaptive(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(10, 0, 0, 10*time.Millisecond, "adaptive")
+	g.Expect(l.Limit()).To(Equal(10))
+
+	// Slow and timed out requests lower the limit
+	l.inFlight++
+	l.release(0, context.DeadlineExceeded)
+	g.Expect(l.Limit()).To(Equal(9))
+	l.inFlight++
+	l.release(time.Second, nil)
+	g.Expect(l.Limit()).To(Equal(8))
+	for i := 0; i < 50; i++ {
+		l.inFlight++
+		l.release(time.Second, nil)
+	}
+	g.Expect(l.Limit()).To(Equal(1))
+
+	// Fast requests only raise it while it's reached
+	l.inFlight++
+	l.release(time.Millisecond, nil)
+	g.Expect(l.Limit()).To(Equal(2))
+	for i := 0; i < 10; i++ {
+		l.inFlight++
+		l.release(time.Millisecond, nil)
+	}
+	g.Expect(l.Limit()).To(Equal(2))
+}
+
+func TestNewLimiterFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l, err := NewLimiterFromAnnotations(map[string]string{}, "dep")
+	g.Expect(err).To(BeNil())
+	g.Expect(l).To(BeNil())
+
+	l, err = NewLimiterFromAnnotations(map[string]string{
+		k8s.ANNOTATION_MAX_CONCURRENCY:           "20",
+		k8s.ANNOTATION_CONCURRENCY_QUEUE_LENGTH:  "5",
+		k8s.ANNOTATION_CONCURRENCY_QUEUE_TIMEOUT: "100ms",
+		k8s.ANNOTATION_CONCURRENCY_ADAPTIVE:      "true",
+	}, "dep")
+	g.Expect(err).To(BeNil())
+	g.Expect(l.Limit()).To(Equal(20))
+	g.Expect(l.maxQueue).To(Equal(5))
+	g.Expect(l.queueTimeout).To(Equal(100 * time.Millisecond))
+	g.Expect(l.targetLatency).To(Equal(DefaultTargetLatency))
+
+	_, err = NewLimiterFromAnnotations(map[string]string{k8s.ANNOTATION_MAX_CONCURRENCY: "many"}, "dep")
+	g.Expect(err).ToNot(BeNil())
+	_, err = NewLimiterFromAnnotations(map[string]string{k8s.ANNOTATION_MAX_CONCURRENCY: "1", k8s.ANNOTATION_CONCURRENCY_QUEUE_TIMEOUT: "soon"}, "dep")
+	g.Expect(err).ToNot(BeNil())
+}
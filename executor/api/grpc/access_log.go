@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/protobuf/proto"
+	guuid "github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
+	"github.com/seldonio/seldon-core/executor/proto/tensorflow/serving"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AccessLogInterceptor logs the method, status, latency and message sizes of calls with their PUID and trace id.
+// Calls without a PUID are given one so the logged PUID matches the one sent through the graph.
+func AccessLogInterceptor(logger logr.Logger) grpc.UnaryServerInterceptor {
+	log := logger.WithName("AccessLog")
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		puid := firstValue(md, payload.SeldonPUIDHeader)
+		if puid == "" {
+			puid = guuid.New().String()
+			md.Set(payload.SeldonPUIDHeader, puid)
+		}
+		ctx = metadata.NewIncomingContext(ctx, md)
+
+		start := time.Now()
+		res, err := handler(ctx, req)
+		log.Info("Request",
+			"method", info.FullMethod,
+			"status", status.Code(err).String(),
+			"latencyMs", float64(time.Since(start))/float64(time.Millisecond),
+			"requestBytes", messageSize(req),
+			"responseBytes", messageSize(res),
+			"model", modelName(req),
+			"puid", puid,
+			"traceId", tracing.TraceID(opentracing.SpanFromContext(ctx)),
+		)
+		return res, err
+	}
+}
+
+func messageSize(msg interface{}) int {
+	if m, ok := msg.(proto.Message); ok && m != nil {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+// modelName returns the model a request is for, if it names one.
+func modelName(req interface{}) string {
+	switch r := req.(type) {
+	case interface{ GetModelName() string }:
+		return r.GetModelName()
+	case interface{ GetModelSpec() *serving.ModelSpec }:
+		return r.GetModelSpec().GetName()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccessLogInterceptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{})
+	interceptor := AccessLogInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/inference.GRPCInferenceService/ModelInfer"}
+	req := &inference.ModelInferRequest{ModelName: "classifier"}
+
+	var handledPuid string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		handledPuid = md.Get(payload.SeldonPUIDHeader)[0]
+		return &inference.ModelInferResponse{ModelName: "classifier"}, nil
+	}
+	_, err := interceptor(context.Background(), req, info, handler)
+	g.Expect(err).To(BeNil())
+	// The PUID given to the call is the one logged
+	g.Expect(handledPuid).NotTo(BeEmpty())
+	g.Expect(lines).To(HaveLen(1))
+	g.Expect(lines[0]).To(HavePrefix("AccessLog "))
+	g.Expect(lines[0]).To(ContainSubstring(`"method"="/inference.GRPCInferenceService/ModelInfer"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"status"="OK"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"model"="classifier"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"puid"="` + handledPuid + `"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"requestBytes"=12`))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(payload.SeldonPUIDHeader, "puid-1"))
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	}
+	_, err = interceptor(ctx, req, info, failing)
+	g.Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	g.Expect(lines[1]).To(ContainSubstring(`"status"="Unavailable"`))
+	g.Expect(lines[1]).To(ContainSubstring(`"puid"="puid-1"`))
+	g.Expect(lines[1]).To(ContainSubstring(`"responseBytes"=0`))
+}
@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationMetadata = "authorization"
+
+// Endpoints checked against the authorization rules for each method. Other methods of the inference
+// services need a valid token but are only allowed by rules for all endpoints.
+var authEndpoints = map[string]string{
+	"/seldon.protos.Seldon/Predict":                          auth.EndpointPredict,
+	"/seldon.protos.Seldon/SendFeedback":                     auth.EndpointFeedback,
+	"/seldon.protos.Seldon/ModelMetadata":                    auth.EndpointMetadata,
+	"/seldon.protos.Seldon/GraphMetadata":                    auth.EndpointMetadata,
+	"/tensorflow.serving.PredictionService/Predict":          auth.EndpointPredict,
+	"/tensorflow.serving.PredictionService/Classify":         auth.EndpointPredict,
+	"/tensorflow.serving.PredictionService/Regress":          auth.EndpointPredict,
+	"/tensorflow.serving.PredictionService/MultiInference":   auth.EndpointPredict,
+	"/tensorflow.serving.PredictionService/GetModelMetadata": auth.EndpointMetadata,
+	"/inference.GRPCInferenceService/ModelInfer":             auth.EndpointPredict,
+	"/inference.GRPCInferenceService/ModelMetadata":          auth.EndpointMetadata,
+	"/inference.GRPCInferenceService/ServerMetadata":         auth.EndpointMetadata,
+}
+
+// Status and health check methods, which are neither authenticated nor rate limited
+var healthMethods = map[string]bool{
+	"/grpc.health.v1.Health/Check":                    true,
+	"/tensorflow.serving.ModelService/GetModelStatus": true,
+	"/inference.GRPCInferenceService/ServerLive":      true,
+	"/inference.GRPCInferenceService/ServerReady":     true,
+	"/inference.GRPCInferenceService/ModelReady":      true,
+}
+
+// AuthInterceptor checks the bearer token of calls and passes its claims on in the claims metadata, removing
+// any claims sent by the client itself. Calls are not authenticated if authenticator is nil.
+func AuthInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		md.Delete(payload.SeldonJwtClaimsHeader)
+		if authenticator != nil && !healthMethods[info.FullMethod] {
+			claims, err := authenticator.Check(firstValue(md, authorizationMetadata), authEndpoints[info.FullMethod])
+			if err != nil {
+				var aerr *auth.AuthenticationError
+				if errors.As(err, &aerr) {
+					return nil, status.Error(codes.Unauthenticated, err.Error())
+				}
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			}
+			encoded, err := auth.EncodeClaims(claims)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			md.Set(payload.SeldonJwtClaimsHeader, encoded)
+		}
+		return handler(metadata.NewIncomingContext(ctx, md), req)
+	}
+}
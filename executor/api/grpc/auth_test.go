@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).To(BeNil())
+	jwks := fmt.Sprintf(`{"keys":[{"kid":"test","kty":"RSA","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	g.Expect(ioutil.WriteFile(path, []byte(jwks), 0644)).To(BeNil())
+	keys, err := auth.NewKeySet(path, auth.DefaultJWKSRefreshInterval, logf.Log)
+	g.Expect(err).To(BeNil())
+	rules, err := auth.ParseRules("predict:groups:ml")
+	g.Expect(err).To(BeNil())
+	authenticator := auth.NewAuthenticator(keys, "", nil, rules)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "alice", "groups": []string{"ml"}})
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	g.Expect(err).To(BeNil())
+
+	var claims []string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		claims = md.Get(payload.SeldonJwtClaimsHeader)
+		return nil, nil
+	}
+	call := func(interceptor grpc.UnaryServerInterceptor, method string, md metadata.MD) error {
+		claims = nil
+		_, err := interceptor(metadata.NewIncomingContext(context.TODO(), md), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	// Claims sent by clients are not trusted
+	err = call(AuthInterceptor(nil), "/seldon.protos.Seldon/Predict", metadata.Pairs(payload.SeldonJwtClaimsHeader, `{"sub":"admin"}`))
+	g.Expect(err).To(BeNil())
+	g.Expect(claims).To(BeEmpty())
+
+	interceptor := AuthInterceptor(authenticator)
+	err = call(interceptor, "/seldon.protos.Seldon/Predict", metadata.Pairs("authorization", "Bearer "+signed))
+	g.Expect(err).To(BeNil())
+	g.Expect(claims).To(Equal([]string{`{"groups":["ml"],"sub":"alice"}`}))
+
+	err = call(interceptor, "/seldon.protos.Seldon/Predict", metadata.MD{})
+	g.Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+	err = call(interceptor, "/seldon.protos.Seldon/SendFeedback", metadata.Pairs("authorization", "Bearer "+signed))
+	g.Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+
+	err = call(interceptor, "/grpc.health.v1.Health/Check", metadata.MD{})
+	g.Expect(err).To(BeNil())
+}
@@ -6,10 +6,12 @@ import (
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	"github.com/opentracing/opentracing-go"
+	"github.com/seldonio/seldon-core/executor/api/cert"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/k8s"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"strconv"
 	"time"
@@ -46,6 +48,21 @@ func AddClientInterceptors(predictor *v1.PredictorSpec, deploymentName, modelNam
 	return grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(interceptors...))
 }
 
+// TransportCredentials returns the dial option for connecting to a node of the graph, using TLS if
+// its endpoint requests it.
+func TransportCredentials(predictor *v1.PredictorSpec, nodeName string, log logr.Logger) (grpc.DialOption, error) {
+	if predictor != nil {
+		if pu := v1.GetPredictiveUnit(&predictor.Graph, nodeName); pu != nil && pu.Endpoint != nil && pu.Endpoint.TLS != nil {
+			config, err := cert.NewClientTLSConfig(pu.Endpoint.TLS, log)
+			if err != nil {
+				return nil, err
+			}
+			return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+		}
+	}
+	return grpc.WithInsecure(), nil
+}
+
 func unaryClientInterceptorWithTimeout(timeout time.Duration) func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
@@ -0,0 +1,44 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Prefixes of streaming methods which last as long as the client wants and so are not waited for on shutdown
+var untrackedStreamPrefixes = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// TrackFunc counts a call as in flight until the function it returns is called.
+type TrackFunc func(name string, puid string) func()
+
+// InFlightInterceptor counts calls as in flight so shutdown waits for them. Status and health checks are not counted.
+func InFlightInterceptor(track TrackFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if healthMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		done := track(info.FullMethod, firstValue(md, payload.SeldonPUIDHeader))
+		defer done()
+		return handler(ctx, req)
+	}
+}
+
+// InFlightStreamInterceptor counts streams as in flight until they end, apart from health watches and reflection.
+func InFlightStreamInterceptor(track TrackFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for _, prefix := range untrackedStreamPrefixes {
+			if strings.HasPrefix(info.FullMethod, prefix) {
+				return handler(srv, ss)
+			}
+		}
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		done := track(info.FullMethod, firstValue(md, payload.SeldonPUIDHeader))
+		defer done()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+type trackedCall struct {
+	name string
+	puid string
+}
+
+// testTracker records the calls in flight.
+type testTracker struct {
+	calls []trackedCall
+}
+
+func (t *testTracker) track(name string, puid string) func() {
+	t.calls = append(t.calls, trackedCall{name: name, puid: puid})
+	return func() {
+		t.calls = t.calls[:len(t.calls)-1]
+	}
+}
+
+func TestInFlightInterceptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tracker := &testTracker{}
+	interceptor := InFlightInterceptor(tracker.track)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(payload.SeldonPUIDHeader, "puid-1"))
+
+	var inFlight []trackedCall
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		inFlight = append([]trackedCall(nil), tracker.calls...)
+		return nil, nil
+	}
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/seldon.protos.Seldon/Predict"}, handler)
+	g.Expect(err).To(BeNil())
+	g.Expect(inFlight).To(HaveLen(1))
+	g.Expect(inFlight[0].name).To(Equal("/seldon.protos.Seldon/Predict"))
+	g.Expect(inFlight[0].puid).To(Equal("puid-1"))
+	g.Expect(tracker.calls).To(BeEmpty())
+
+	// Health checks are not counted
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	g.Expect(err).To(BeNil())
+	g.Expect(inFlight).To(BeEmpty())
+}
+
+func TestInFlightStreamInterceptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tracker := &testTracker{}
+	interceptor := InFlightStreamInterceptor(tracker.track)
+	stream := &testServerStream{ctx: context.Background()}
+
+	var inFlight []trackedCall
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		inFlight = append([]trackedCall(nil), tracker.calls...)
+		return nil
+	}
+	err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/inference.GRPCInferenceService/ModelStreamInfer"}, handler)
+	g.Expect(err).To(BeNil())
+	g.Expect(inFlight).To(HaveLen(1))
+	g.Expect(tracker.calls).To(BeEmpty())
+
+	// Health watches last as long as the client wants so are not counted
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}, handler)
+	g.Expect(err).To(BeNil())
+	g.Expect(inFlight).To(BeEmpty())
+}
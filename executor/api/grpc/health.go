@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// How often the readiness of the graph is re-evaluated for Watch streams
+	HealthWatchInterval = 5 * time.Second
+)
+
+// HealthServer implements the standard grpc.health.v1.Health service. Rather than
+// holding a fixed status, every check is evaluated by calling the ready function so
+// the result always reflects the state of the inference graph.
+type HealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	ready    func() error
+	services map[string]bool
+	log      logr.Logger
+}
+
+// NewHealthServer creates a health service which reports SERVING while ready returns
+// no error. Besides the overall server status ("") it answers for the given services.
+func NewHealthServer(ready func() error, services []string, log logr.Logger) *HealthServer {
+	known := map[string]bool{"": true}
+	for _, s := range services {
+		known[s] = true
+	}
+	return &HealthServer{
+		ready:    ready,
+		services: known,
+		log:      log,
+	}
+}
+
+// RegisterHealthServer registers a health service covering every service already registered on the server.
+func RegisterHealthServer(server *grpc.Server, ready func() error, log logr.Logger) *HealthServer {
+	var services []string
+	for name := range server.GetServiceInfo() {
+		services = append(services, name)
+	}
+	healthServer := NewHealthServer(ready, services, log)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	return healthServer
+}
+
+func (h *HealthServer) servingStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if err := h.ready(); err != nil {
+		h.log.Error(err, "Ready check failed")
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+func (h *HealthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if !h.services[req.GetService()] {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: h.servingStatus()}, nil
+}
+
+func (h *HealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	lastStatus := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	ticker := time.NewTicker(HealthWatchInterval)
+	defer ticker.Stop()
+	for {
+		servingStatus := grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		if h.services[req.GetService()] {
+			servingStatus = h.servingStatus()
+		}
+		// Only send updates when the status changes
+		if servingStatus != lastStatus {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return status.Errorf(codes.Canceled, "stream has ended: %v", err)
+			}
+			lastStatus = servingStatus
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestHealthCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var readyErr error
+	h := NewHealthServer(func() error { return readyErr }, []string{"seldon.protos.Seldon"}, logf.Log)
+
+	res, err := h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	g.Expect(err).To(BeNil())
+	g.Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+	res, err = h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "seldon.protos.Seldon"})
+	g.Expect(err).To(BeNil())
+	g.Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+	readyErr = errors.New("not ready")
+	res, err = h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	g.Expect(err).To(BeNil())
+	g.Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+
+	_, err = h.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+}
+
+func TestRegisterHealthServer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := grpc.NewServer()
+	proto.RegisterSeldonServer(server, &proto.UnimplementedSeldonServer{})
+	RegisterHealthServer(server, func() error { return nil }, logf.Log)
+
+	lis := bufconn.Listen(1024 * 1024)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+		return lis.Dial()
+	}), grpc.WithInsecure())
+	g.Expect(err).To(BeNil())
+	defer conn.Close()
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	res, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "seldon.protos.Seldon"})
+	g.Expect(err).To(BeNil())
+	g.Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	g.Expect(err).To(BeNil())
+	watchRes, err := stream.Recv()
+	g.Expect(err).To(BeNil())
+	g.Expect(watchRes.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+}
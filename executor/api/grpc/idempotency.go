@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/idempotency"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// IdempotencyInterceptor handles prediction calls with an idempotency-key header once, returning the stored
+// response with an idempotent-replayed header to retries within the TTL. Keys are scoped to the method, the client
+// and the request, with clients without a JWT identified by clientKey.
+func IdempotencyInterceptor(deduplicator *idempotency.Deduplicator, clientKey ratelimit.ClientKey) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if authEndpoints[info.FullMethod] != auth.EndpointPredict {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		key := firstValue(md, payload.IdempotencyKeyHeader)
+		if key == "" {
+			return handler(ctx, req)
+		}
+		body, err := requestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		key = idempotency.ScopedKey(info.FullMethod, clientIdentity(ctx, clientKey), key, body)
+		var res interface{}
+		var handlerErr error
+		response, result, err := deduplicator.Do(ctx, key, func() (*idempotency.Response, error) {
+			res, handlerErr = handler(ctx, req)
+			if handlerErr != nil {
+				return nil, handlerErr
+			}
+			return storedResponse(res)
+		})
+		if result == idempotency.ResultMiss {
+			// Responses which couldn't be stored are still returned
+			return res, handlerErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(payload.IdempotentReplayedHeader, "true"))
+		return storedMessage(response)
+	}
+}
+
+// clientIdentity returns the subject of the caller's validated JWT, or else the key identifying it for rate limits.
+func clientIdentity(ctx context.Context, key ratelimit.ClientKey) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if sub := ratelimit.ClaimValue(firstValue(md, payload.SeldonJwtClaimsHeader), "sub"); sub != "" {
+		return sub
+	}
+	return rateLimitClientKey(ctx, key)
+}
+
+// requestBody marshals the request deterministically so identical requests hash to the same key.
+func requestBody(req interface{}) ([]byte, error) {
+	msg, ok := req.(proto.Message)
+	if !ok || msg == nil || reflect.ValueOf(msg).IsNil() {
+		return nil, nil
+	}
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func storedResponse(res interface{}) (*idempotency.Response, error) {
+	msg, ok := res.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type %T", res)
+	}
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &idempotency.Response{MessageType: proto.MessageName(msg), Body: body}, nil
+}
+
+func storedMessage(response *idempotency.Response) (proto.Message, error) {
+	messageType := proto.MessageType(response.MessageType)
+	if messageType == nil {
+		return nil, fmt.Errorf("unknown stored message type %s", response.MessageType)
+	}
+	msg := reflect.New(messageType.Elem()).Interface().(proto.Message)
+	if err := proto.Unmarshal(response.Body, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/idempotency"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// testServerTransportStream records the headers set by handlers.
+type testServerTransportStream struct {
+	header metadata.MD
+}
+
+func (s *testServerTransportStream) Method() string {
+	return ""
+}
+
+func (s *testServerTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *testServerTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *testServerTransportStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	interceptor := IdempotencyInterceptor(idempotency.NewDeduplicator(idempotency.NewMemoryStore(10), time.Minute, "test"), ratelimit.ClientKey{Source: ratelimit.KeySourceIP})
+	info := &grpc.UnaryServerInfo{FullMethod: "/inference.GRPCInferenceService/ModelInfer"}
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &inference.ModelInferResponse{ModelName: "classifier", Id: "1"}, nil
+	}
+	call := func(key string) (interface{}, metadata.MD, error) {
+		stream := &testServerTransportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(payload.IdempotencyKeyHeader, key))
+		}
+		res, err := interceptor(ctx, &inference.ModelInferRequest{}, info, handler)
+		return res, stream.header, err
+	}
+
+	first, header, err := call("key-1")
+	g.Expect(err).To(BeNil())
+	g.Expect(header.Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+
+	retry, header, err := call("key-1")
+	g.Expect(err).To(BeNil())
+	g.Expect(header.Get(payload.IdempotentReplayedHeader)).To(Equal([]string{"true"}))
+	g.Expect(proto.Equal(retry.(proto.Message), first.(proto.Message))).To(BeTrue())
+	g.Expect(calls).To(Equal(1))
+
+	_, _, err = call("")
+	g.Expect(err).To(BeNil())
+	g.Expect(calls).To(Equal(2))
+}
+
+func TestIdempotencyInterceptorErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	interceptor := IdempotencyInterceptor(idempotency.NewDeduplicator(idempotency.NewMemoryStore(10), time.Minute, "test"), ratelimit.ClientKey{Source: ratelimit.KeySourceIP})
+	info := &grpc.UnaryServerInfo{FullMethod: "/seldon.protos.Seldon/Predict"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(payload.IdempotencyKeyHeader, "key-1"))
+	calls := 0
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, errors.New("failed")
+	}
+
+	// Failed calls are not stored so retries are handled again
+	_, err := interceptor(ctx, nil, info, failing)
+	g.Expect(err).ToNot(BeNil())
+	_, err = interceptor(ctx, nil, info, failing)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(calls).To(Equal(2))
+}
+
+func TestIdempotencyInterceptorScope(t *testing.T) {
+	g := NewGomegaWithT(t)
+	interceptor := IdempotencyInterceptor(idempotency.NewDeduplicator(idempotency.NewMemoryStore(10), time.Minute, "test"), ratelimit.ClientKey{Source: ratelimit.KeySourceHeader, Name: "x-client"})
+	info := &grpc.UnaryServerInfo{FullMethod: "/inference.GRPCInferenceService/ModelInfer"}
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &inference.ModelInferResponse{ModelName: "classifier", Id: req.(*inference.ModelInferRequest).Id}, nil
+	}
+	call := func(id string, kv ...string) metadata.MD {
+		stream := &testServerTransportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(append([]string{payload.IdempotencyKeyHeader, "key-1"}, kv...)...))
+		_, err := interceptor(ctx, &inference.ModelInferRequest{Id: id}, info, handler)
+		g.Expect(err).To(BeNil())
+		return stream.header
+	}
+
+	call("1", payload.SeldonJwtClaimsHeader, `{"sub":"alice"}`)
+	g.Expect(call("1", payload.SeldonJwtClaimsHeader, `{"sub":"alice"}`).Get(payload.IdempotentReplayedHeader)).To(Equal([]string{"true"}))
+	g.Expect(calls).To(Equal(1))
+
+	// Other clients reusing the key, identified by their JWT or the client key, are handled separately
+	g.Expect(call("1", payload.SeldonJwtClaimsHeader, `{"sub":"bob"}`).Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(call("1", "x-client", "carol").Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(calls).To(Equal(3))
+
+	// As are requests with a different body
+	g.Expect(call("2", payload.SeldonJwtClaimsHeader, `{"sub":"alice"}`).Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(calls).To(Equal(4))
+}
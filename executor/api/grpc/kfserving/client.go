@@ -2,7 +2,6 @@ package kfserving
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"math"
 
@@ -20,7 +19,7 @@ import (
 type KFServingGrpcClient struct {
 	Log            logr.Logger
 	callOptions    []grpc.CallOption
-	conns          map[string]*grpc.ClientConn
+	pool           *grpc2.ConnectionPool
 	Predictor      *v1.PredictorSpec
 	DeploymentName string
 	annotations    map[string]string
@@ -34,42 +33,43 @@ func (s *KFServingGrpcClient) ModelMetadata(ctx context.Context, modelName strin
 	panic("implement me")
 }
 
-func NewKFServingGrpcClient(predictor *v1.PredictorSpec, deploymentName string, annotations map[string]string) client.SeldonApiClient {
+func NewKFServingGrpcClient(predictor *v1.PredictorSpec, deploymentName string, annotations map[string]string) (client.SeldonApiClient, error) {
 	opts := []grpc.CallOption{
 		grpc.MaxCallSendMsgSize(math.MaxInt32),
 		grpc.MaxCallRecvMsgSize(math.MaxInt32),
 	}
+	log := logf.Log.WithName("SeldonGrpcClient")
+	options, err := grpc2.ClientOptionsFromAnnotations(annotations)
+	if err != nil {
+		return nil, err
+	}
 	smgc := KFServingGrpcClient{
-		Log:            logf.Log.WithName("SeldonGrpcClient"),
+		Log:            log,
 		callOptions:    opts,
-		conns:          make(map[string]*grpc.ClientConn),
+		pool:           grpc2.NewConnectionPool(options, predictor),
 		Predictor:      predictor,
 		DeploymentName: deploymentName,
 		annotations:    annotations,
 	}
-	return &smgc
+	return &smgc, nil
 }
 
-func (s *KFServingGrpcClient) getConnection(host string, port int32, modelName string) (*grpc.ClientConn, error) {
-	k := fmt.Sprintf("%s:%d", host, port)
-	if conn, ok := s.conns[k]; ok {
-		return conn, nil
-	} else {
-		opts := []grpc.DialOption{
-			grpc.WithInsecure(),
-		}
-		opts = append(opts, grpc2.AddClientInterceptors(s.Predictor, s.DeploymentName, modelName, s.annotations, s.Log))
-		conn, err := grpc.Dial(fmt.Sprintf("%s:%d", host, port), opts...)
+func (s *KFServingGrpcClient) getConnection(ctx context.Context, host string, port int32, modelName string) (*grpc.ClientConn, error) {
+	nodeName := client.NodeName(ctx, modelName)
+	return s.pool.Get(host, port, nodeName, func() ([]grpc.DialOption, error) {
+		creds, err := grpc2.TransportCredentials(s.Predictor, nodeName, s.Log)
 		if err != nil {
 			return nil, err
 		}
-		s.conns[k] = conn
-		return conn, nil
-	}
+		return []grpc.DialOption{
+			creds,
+			grpc2.AddClientInterceptors(s.Predictor, s.DeploymentName, modelName, s.annotations, s.Log),
+		}, nil
+	})
 }
 
 func (s *KFServingGrpcClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +146,7 @@ func (s *KFServingGrpcClient) Chain(ctx context.Context, modelName string, msg p
 }
 
 func (s *KFServingGrpcClient) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +167,7 @@ func (s *KFServingGrpcClient) Status(ctx context.Context, modelName string, host
 }
 
 func (s *KFServingGrpcClient) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return nil, err
 	}
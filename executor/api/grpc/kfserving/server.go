@@ -48,7 +48,7 @@ func (g GrpcKFServingServer) ModelReady(ctx context.Context, request *inference.
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetName())
 	reqPayload := payload.ProtoPayload{Msg: request}
-	resPayload, err := seldonPredictorProcess.Status(&g.predictor.Graph, request.Name, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Status(&predictor.ActiveSpec(g.predictor).Graph, request.Name, &reqPayload)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +66,7 @@ func (g GrpcKFServingServer) ModelMetadata(ctx context.Context, request *inferen
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetName())
 	reqPayload := payload.ProtoPayload{Msg: request}
-	resPayload, err := seldonPredictorProcess.Metadata(&g.predictor.Graph, request.Name, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Metadata(&predictor.ActiveSpec(g.predictor).Graph, request.Name, &reqPayload)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +80,7 @@ func (g GrpcKFServingServer) ModelInfer(ctx context.Context, request *inference.
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("infer"), g.ServerUrl, g.Namespace, md, request.GetModelName())
 	reqPayload := payload.ProtoPayload{Msg: request}
-	resPayload, err := seldonPredictorProcess.Predict(&g.predictor.Graph, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Predict(&predictor.ActiveSpec(g.predictor).Graph, &reqPayload)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/k8s"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	DefaultPoolSize         = 10
+	DefaultKeepaliveTimeout = 20 * time.Second
+
+	LoadBalancingPickFirst  = "pick_first"
+	LoadBalancingRoundRobin = "round_robin"
+)
+
+// ClientOptions configures the connections from the gRPC clients to the nodes of the graph.
+type ClientOptions struct {
+	// Number of connections to each node, which requests are spread over
+	PoolSize int
+	// Interval of keepalive pings on idle connections, which are disabled if zero
+	KeepaliveTime time.Duration
+	// How long to wait for a keepalive ping to be acknowledged before closing the connection
+	KeepaliveTimeout time.Duration
+	// Policy for balancing requests over the addresses of a node. With round_robin the host is resolved
+	// through DNS so a headless service spreads requests over all its pods.
+	LoadBalancing string
+}
+
+// ClientOptionsFromAnnotations reads the client options from the deployment's annotations.
+func ClientOptionsFromAnnotations(annotations map[string]string) (ClientOptions, error) {
+	options := ClientOptions{
+		PoolSize:         DefaultPoolSize,
+		KeepaliveTimeout: DefaultKeepaliveTimeout,
+		LoadBalancing:    LoadBalancingPickFirst,
+	}
+	if val := annotations[k8s.ANNOTATION_GRPC_POOL_SIZE]; val != "" {
+		size, err := strconv.Atoi(val)
+		if err != nil || size < 1 {
+			return options, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_GRPC_POOL_SIZE, val)
+		}
+		options.PoolSize = size
+	}
+	if val := annotations[k8s.ANNOTATION_GRPC_KEEPALIVE_TIME]; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d < 0 {
+			return options, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_GRPC_KEEPALIVE_TIME, val)
+		}
+		options.KeepaliveTime = d
+	}
+	if val := annotations[k8s.ANNOTATION_GRPC_KEEPALIVE_TIMEOUT]; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return options, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_GRPC_KEEPALIVE_TIMEOUT, val)
+		}
+		options.KeepaliveTimeout = d
+	}
+	if val := annotations[k8s.ANNOTATION_GRPC_LOAD_BALANCING]; val != "" {
+		if val != LoadBalancingPickFirst && val != LoadBalancingRoundRobin {
+			return options, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_GRPC_LOAD_BALANCING, val)
+		}
+		options.LoadBalancing = val
+	}
+	return options, nil
+}
+
+// Target returns the target to dial for a node.
+func (o ClientOptions) Target(host string, port int32) string {
+	if o.LoadBalancing == LoadBalancingRoundRobin {
+		return fmt.Sprintf("dns:///%s:%d", host, port)
+	}
+	return fmt.Sprintf("%s:%d", host, port)
+}
+
+// DialOptions returns the dial options for the keepalive and load balancing settings.
+func (o ClientOptions) DialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+	if o.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                o.KeepaliveTime,
+			Timeout:             o.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+	if o.LoadBalancing == LoadBalancingRoundRobin {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{"%s":{}}]}`, LoadBalancingRoundRobin)))
+	}
+	return opts
+}
+
+type poolEntry struct {
+	conns []*grpc.ClientConn
+	next  uint32
+}
+
+// ConnectionPool holds a fixed number of connections to each node of the graph, spreading requests over
+// them in turn.
+type ConnectionPool struct {
+	Options   ClientOptions
+	predictor *v1.PredictorSpec
+
+	mu      sync.RWMutex
+	entries map[string]*poolEntry
+}
+
+func NewConnectionPool(options ClientOptions, predictor *v1.PredictorSpec) *ConnectionPool {
+	return &ConnectionPool{
+		Options:   options,
+		predictor: predictor,
+		entries:   make(map[string]*poolEntry),
+	}
+}
+
+// target returns the target to dial for a node, which is its unix socket if it has one.
+func (p *ConnectionPool) target(host string, port int32, nodeName string) string {
+	if p.predictor != nil {
+		if pu := v1.GetPredictiveUnit(&p.predictor.Graph, nodeName); pu != nil {
+			if unixSocket := pu.Endpoint.GetUnixSocketPath(); unixSocket != "" {
+				return "unix://" + unixSocket
+			}
+		}
+	}
+	return p.Options.Target(host, port)
+}
+
+// Get returns the next connection to a node, creating the node's connections with the given dial options
+// on first use.
+func (p *ConnectionPool) Get(host string, port int32, nodeName string, dialOptions func() ([]grpc.DialOption, error)) (*grpc.ClientConn, error) {
+	target := p.target(host, port, nodeName)
+	p.mu.RLock()
+	entry, ok := p.entries[target]
+	p.mu.RUnlock()
+	if !ok {
+		var err error
+		if entry, err = p.create(target, dialOptions); err != nil {
+			return nil, err
+		}
+	}
+	i := atomic.AddUint32(&entry.next, 1)
+	return entry.conns[int(i)%len(entry.conns)], nil
+}
+
+func (p *ConnectionPool) create(target string, dialOptions func() ([]grpc.DialOption, error)) (*poolEntry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.entries[target]; ok {
+		return entry, nil
+	}
+	opts, err := dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, p.Options.DialOptions()...)
+	size := p.Options.PoolSize
+	if size < 1 {
+		size = 1
+	}
+	entry := &poolEntry{conns: make([]*grpc.ClientConn, size)}
+	for i := range entry.conns {
+		// Dialing doesn't block so connections are made in the background
+		conn, err := grpc.Dial(target, opts...)
+		if err != nil {
+			for _, c := range entry.conns[:i] {
+				c.Close()
+			}
+			return nil, err
+		}
+		entry.conns[i] = conn
+	}
+	p.entries[target] = entry
+	return entry, nil
+}
@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestClientOptionsFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	options, err := ClientOptionsFromAnnotations(nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(options).To(Equal(ClientOptions{PoolSize: DefaultPoolSize, KeepaliveTimeout: DefaultKeepaliveTimeout, LoadBalancing: LoadBalancingPickFirst}))
+	g.Expect(options.Target("model", 9000)).To(Equal("model:9000"))
+	g.Expect(options.DialOptions()).To(BeEmpty())
+
+	options, err = ClientOptionsFromAnnotations(map[string]string{
+		k8s.ANNOTATION_GRPC_POOL_SIZE:         "4",
+		k8s.ANNOTATION_GRPC_KEEPALIVE_TIME:    "30s",
+		k8s.ANNOTATION_GRPC_KEEPALIVE_TIMEOUT: "5s",
+		k8s.ANNOTATION_GRPC_LOAD_BALANCING:    LoadBalancingRoundRobin,
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(options).To(Equal(ClientOptions{PoolSize: 4, KeepaliveTime: 30 * time.Second, KeepaliveTimeout: 5 * time.Second, LoadBalancing: LoadBalancingRoundRobin}))
+	g.Expect(options.Target("model", 9000)).To(Equal("dns:///model:9000"))
+	g.Expect(options.DialOptions()).To(HaveLen(2))
+
+	for name, val := range map[string]string{
+		k8s.ANNOTATION_GRPC_POOL_SIZE:         "0",
+		k8s.ANNOTATION_GRPC_KEEPALIVE_TIME:    "often",
+		k8s.ANNOTATION_GRPC_KEEPALIVE_TIMEOUT: "-1s",
+		k8s.ANNOTATION_GRPC_LOAD_BALANCING:    "least_request",
+	} {
+		_, err = ClientOptionsFromAnnotations(map[string]string{name: val})
+		g.Expect(err).ToNot(BeNil(), name)
+		g.Expect(err.Error()).To(ContainSubstring(name))
+	}
+}
+
+func TestConnectionPool(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := grpc.NewServer()
+	RegisterHealthServer(server, func() error { return nil }, logf.Log)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).To(BeNil())
+	go server.Serve(lis)
+	defer server.Stop()
+	port := int32(lis.Addr().(*net.TCPAddr).Port)
+
+	pool := NewConnectionPool(ClientOptions{PoolSize: 3, KeepaliveTime: 10 * time.Second, KeepaliveTimeout: time.Second, LoadBalancing: LoadBalancingRoundRobin}, nil)
+	dials := 0
+	dialOptions := func() ([]grpc.DialOption, error) {
+		dials++
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	}
+
+	// Requests are spread over the pool's connections in turn
+	conns := map[*grpc.ClientConn]int{}
+	for i := 0; i < 6; i++ {
+		conn, err := pool.Get("localhost", port, "model", dialOptions)
+		g.Expect(err).To(BeNil())
+		conns[conn]++
+		res, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		g.Expect(err).To(BeNil())
+		g.Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+	}
+	g.Expect(dials).To(Equal(1))
+	g.Expect(conns).To(HaveLen(3))
+	for _, count := range conns {
+		g.Expect(count).To(Equal(2))
+	}
+
+	_, err = pool.Get("other", port, "other", func() ([]grpc.DialOption, error) {
+		return nil, errors.New("no credentials")
+	})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestConnectionPoolUnixSocket(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := grpc.NewServer()
+	RegisterHealthServer(server, func() error { return nil }, logf.Log)
+	socket := filepath.Join(t.TempDir(), "model.sock")
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).To(BeNil())
+	go server.Serve(lis)
+	defer server.Stop()
+
+	predictor := &v1.PredictorSpec{
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Endpoint: &v1.Endpoint{UnixSocket: socket},
+		},
+	}
+	pool := NewConnectionPool(ClientOptions{PoolSize: 1, LoadBalancing: LoadBalancingRoundRobin}, predictor)
+	g.Expect(pool.target("localhost", 9000, "model")).To(Equal("unix://" + socket))
+	g.Expect(pool.target("localhost", 9000, "other")).To(Equal("dns:///localhost:9000"))
+
+	// The host and port are ignored for nodes with a unix socket
+	conn, err := pool.Get("localhost", 1, "model", func() ([]grpc.DialOption, error) {
+		return []grpc.DialOption{grpc.WithInsecure()}, nil
+	})
+	g.Expect(err).To(BeNil())
+	res, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	g.Expect(err).To(BeNil())
+	g.Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+}
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const retryAfterMetadata = "retry-after"
+
+// RateLimitInterceptor rejects calls exceeding the rate limits with the ResourceExhausted code and a
+// retry-after header giving the number of seconds to wait.
+func RateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !healthMethods[info.FullMethod] {
+			if ok, retryAfter := limiter.Allow(rateLimitClientKey(ctx, limiter.Key)); !ok {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadata, ratelimit.RetryAfterSeconds(retryAfter)))
+				return nil, status.Error(codes.ResourceExhausted, (&ratelimit.LimitExceededError{RetryAfter: retryAfter}).Error())
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func rateLimitClientKey(ctx context.Context, key ratelimit.ClientKey) string {
+	switch key.Source {
+	case ratelimit.KeySourceHeader, ratelimit.KeySourceClaim:
+		md, _ := metadata.FromIncomingContext(ctx)
+		if key.Source == ratelimit.KeySourceHeader {
+			return firstValue(md, key.Name)
+		}
+		return ratelimit.ClaimValue(firstValue(md, payload.SeldonJwtClaimsHeader), key.Name)
+	default:
+		p, ok := peer.FromContext(ctx)
+		if !ok || p.Addr == nil {
+			return ""
+		}
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return p.Addr.String()
+		}
+		return host
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	limiter := ratelimit.NewLimiter(0, 0, 1, 1, ratelimit.ClientKey{Source: ratelimit.KeySourceIP}, "test")
+	interceptor := RateLimitInterceptor(limiter)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	call := func(method string, ip string) error {
+		ctx := peer.NewContext(context.TODO(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	g.Expect(call("/seldon.protos.Seldon/Predict", "10.0.0.1")).To(BeNil())
+	g.Expect(status.Code(call("/seldon.protos.Seldon/Predict", "10.0.0.1"))).To(Equal(codes.ResourceExhausted))
+	g.Expect(call("/seldon.protos.Seldon/Predict", "10.0.0.2")).To(BeNil())
+	g.Expect(call("/grpc.health.v1.Health/Check", "10.0.0.1")).To(BeNil())
+}
@@ -2,12 +2,9 @@ package seldon
 
 import (
 	"context"
-	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"github.com/seldonio/seldon-core/executor/api/client"
-	"math/rand"
-	"sync"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	grpc2 "github.com/seldonio/seldon-core/executor/api/grpc"
@@ -22,14 +19,10 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-// TODO: make this configurable
-var numConns = 10
-
 type SeldonMessageGrpcClient struct {
-	sync.RWMutex
 	Log            logr.Logger
 	callOptions    []grpc.CallOption
-	conns          map[string][]*grpc.ClientConn
+	pool           *grpc2.ConnectionPool
 	Predictor      *v1.PredictorSpec
 	DeploymentName string
 	annotations    map[string]string
@@ -39,76 +32,39 @@ func (s *SeldonMessageGrpcClient) IsGrpc() bool {
 	return true
 }
 
-func NewSeldonGrpcClient(spec *v1.PredictorSpec, deploymentName string, annotations map[string]string) client.SeldonApiClient {
+func NewSeldonGrpcClient(spec *v1.PredictorSpec, deploymentName string, annotations map[string]string) (client.SeldonApiClient, error) {
 	opts := []grpc.CallOption{
 		grpc.MaxCallSendMsgSize(math.MaxInt32),
 		grpc.MaxCallRecvMsgSize(math.MaxInt32),
 	}
+	log := logf.Log.WithName("SeldonGrpcClient")
+	options, err := grpc2.ClientOptionsFromAnnotations(annotations)
+	if err != nil {
+		return nil, err
+	}
 	smgc := SeldonMessageGrpcClient{
-		Log:            logf.Log.WithName("SeldonGrpcClient"),
+		Log:            log,
 		callOptions:    opts,
-		conns:          make(map[string][]*grpc.ClientConn),
+		pool:           grpc2.NewConnectionPool(options, spec),
 		Predictor:      spec,
 		DeploymentName: deploymentName,
 		annotations:    annotations,
 	}
-	return &smgc
+	return &smgc, nil
 }
 
-// TODO: Re-examine locks here, make sure concurrent reads/writes aren't overwriting each other
-// TODO: Verify this logic is necessary, or if we can just create numConns connections at once (simplifying logic)
-// TODO: Investigate a TLL for conns--may result in better load balancing if connections occasionally re-connect
-func (s *SeldonMessageGrpcClient) getConnection(host string, port int32, modelName string) (*grpc.ClientConn, error) {
-	s.RLock()
-	randNum := rand.Intn(numConns)
-	k := fmt.Sprintf("%s:%d", host, port)
-	if nodeConns, ok := s.conns[k]; ok {
-		if c := nodeConns[randNum]; c != nil {
-			defer s.RUnlock()
-			return c, nil
-		}
-		s.RUnlock()
-
-		c, err := s.createNewConn(modelName, host, port)
-		if err != nil {
-			return nil, err
-		}
-
-		s.Lock()
-		s.conns[k][randNum] = c
-		s.Unlock()
-
-		return s.conns[k][randNum], nil
-	} else {
-		s.RUnlock()
-		connList := make([]*grpc.ClientConn, numConns)
-
-		c, err := s.createNewConn(modelName, host, port)
+func (s *SeldonMessageGrpcClient) getConnection(ctx context.Context, host string, port int32, modelName string) (*grpc.ClientConn, error) {
+	nodeName := client.NodeName(ctx, modelName)
+	return s.pool.Get(host, port, nodeName, func() ([]grpc.DialOption, error) {
+		creds, err := grpc2.TransportCredentials(s.Predictor, nodeName, s.Log)
 		if err != nil {
 			return nil, err
 		}
-
-		connList[randNum] = c
-
-		s.Lock()
-		s.conns[k] = connList
-		s.Unlock()
-
-		return s.conns[k][randNum], nil
-	}
-}
-
-func (s *SeldonMessageGrpcClient) createNewConn(modelName, host string, port int32) (*grpc.ClientConn, error) {
-	opts := []grpc.DialOption{
-		grpc.WithInsecure(),
-	}
-
-	opts = append(opts, grpc2.AddClientInterceptors(s.Predictor, s.DeploymentName, modelName, s.annotations, s.Log))
-	conn, err := grpc.Dial(fmt.Sprintf("%s:%d", host, port), opts...)
-	if err != nil {
-		return nil, err
-	}
-	return conn, nil
+		return []grpc.DialOption{
+			creds,
+			grpc2.AddClientInterceptors(s.Predictor, s.DeploymentName, modelName, s.annotations, s.Log),
+		}, nil
+	})
 }
 
 func (s *SeldonMessageGrpcClient) Chain(ctx context.Context, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
@@ -116,7 +72,7 @@ func (s *SeldonMessageGrpcClient) Chain(ctx context.Context, modelName string, m
 }
 
 func (s *SeldonMessageGrpcClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
@@ -130,7 +86,7 @@ func (s *SeldonMessageGrpcClient) Predict(ctx context.Context, modelName string,
 }
 
 func (s *SeldonMessageGrpcClient) TransformInput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
@@ -144,7 +100,7 @@ func (s *SeldonMessageGrpcClient) TransformInput(ctx context.Context, modelName
 }
 
 func (s *SeldonMessageGrpcClient) Route(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (int, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return 0, err
 	}
@@ -159,7 +115,7 @@ func (s *SeldonMessageGrpcClient) Route(ctx context.Context, modelName string, h
 }
 
 func (s *SeldonMessageGrpcClient) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
@@ -178,7 +134,7 @@ func (s *SeldonMessageGrpcClient) Combine(ctx context.Context, modelName string,
 }
 
 func (s *SeldonMessageGrpcClient) TransformOutput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
@@ -192,7 +148,7 @@ func (s *SeldonMessageGrpcClient) TransformOutput(ctx context.Context, modelName
 }
 
 func (s *SeldonMessageGrpcClient) Feedback(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
@@ -225,7 +181,7 @@ func (s *SeldonMessageGrpcClient) Status(ctx context.Context, modelName string,
 
 // Return model's metadata as payload.SeldonPaylaod (to expose as received on corresponding executor endpoint)
 func (s *SeldonMessageGrpcClient) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
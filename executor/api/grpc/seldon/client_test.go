@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/golang/protobuf/jsonpb"
 	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/test"
@@ -13,6 +14,7 @@ import (
 	"github.com/seldonio/seldon-core/executor/k8s"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"net"
+	"path/filepath"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"testing"
 )
@@ -77,7 +79,8 @@ func TestClientPredict(t *testing.T) {
 	p, host, port, stopFunc := createTestGrpcServer(g, nil)
 	defer stopFunc()
 
-	client := NewSeldonGrpcClient(p, "", nil)
+	client, err := NewSeldonGrpcClient(p, "", nil)
+	g.Expect(err).To(BeNil())
 
 	req := createPredictPayload(g)
 	reqSm := req.GetPayload().(*proto.SeldonMessage)
@@ -87,6 +90,36 @@ func TestClientPredict(t *testing.T) {
 	g.Expect(respSm.GetData().GetNdarray().Values[0].GetNumberValue()).To(Equal(reqSm.GetData().GetNdarray().Values[0].GetNumberValue()))
 }
 
+func TestClientPredictUnixSocketModelName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	socket := filepath.Join(t.TempDir(), "model.sock")
+	model := v1.MODEL
+	p := &v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Type:     &model,
+			Endpoint: &v1.Endpoint{UnixSocket: socket},
+		},
+	}
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).To(BeNil())
+	grpcServer, err := grpc.CreateGrpcServer(p, "dep", nil, logf.Log)
+	g.Expect(err).To(BeNil())
+	proto.RegisterModelServer(grpcServer, test.NewSeldonTestServer(1, &testProtoModelMetadata))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	seldonClient, err := NewSeldonGrpcClient(p, "", nil)
+	g.Expect(err).To(BeNil())
+
+	// The socket is found by the node's name when the request names another model, without dialling the host
+	ctx := client.WithNodeName(context.TODO(), "model")
+	_, err = seldonClient.Predict(ctx, "mymodel", "", 9000, createPredictPayload(g), nil)
+	g.Expect(err).To(BeNil())
+}
+
 func TestClientPredictTimeout(t *testing.T) {
 	t.Logf("Started")
 	g := NewGomegaWithT(t)
@@ -95,10 +128,11 @@ func TestClientPredictTimeout(t *testing.T) {
 	defer stopFunc()
 
 	annotations := map[string]string{k8s.ANNOTATION_GRPC_TIMEOUT: "100"}
-	client := NewSeldonGrpcClient(p, "", annotations)
+	client, err := NewSeldonGrpcClient(p, "", annotations)
+	g.Expect(err).To(BeNil())
 
 	req := createPredictPayload(g)
-	_, err := client.Predict(context.TODO(), "m", host, port, req, nil)
+	_, err = client.Predict(context.TODO(), "m", host, port, req, nil)
 	g.Expect(err).NotTo(BeNil())
 	g.Expect(err.Error()).To(Equal("rpc error: code = DeadlineExceeded desc = context deadline exceeded"))
 }
@@ -111,10 +145,11 @@ func TestClientPredictMessageSize(t *testing.T) {
 	p, host, port, stopFunc := createTestGrpcServer(g, annotations)
 	defer stopFunc()
 
-	client := NewSeldonGrpcClient(p, "", annotations)
+	client, err := NewSeldonGrpcClient(p, "", annotations)
+	g.Expect(err).To(BeNil())
 
 	req := createPredictPayload(g)
-	_, err := client.Predict(context.TODO(), "m", host, port, req, nil)
+	_, err = client.Predict(context.TODO(), "m", host, port, req, nil)
 	g.Expect(err).NotTo(BeNil())
 	g.Expect(err.Error()).To(Equal("rpc error: code = ResourceExhausted desc = grpc: received message larger than max (26 vs. 1)"))
 }
@@ -126,7 +161,8 @@ func TestClientMetadata(t *testing.T) {
 	p, host, port, stopFunc := createTestGrpcServer(g, nil)
 	defer stopFunc()
 
-	client := NewSeldonGrpcClient(p, "", nil)
+	client, err := NewSeldonGrpcClient(p, "", nil)
+	g.Expect(err).To(BeNil())
 	resp, err := client.Metadata(context.TODO(), "m", host, port, nil, nil)
 
 	respSm := resp.GetPayload().(*proto.SeldonModelMetadata)
@@ -150,7 +186,8 @@ func TestClientModelMetadata(t *testing.T) {
 	p, host, port, stopFunc := createTestGrpcServer(g, nil)
 	defer stopFunc()
 
-	client := NewSeldonGrpcClient(p, "", nil)
+	client, err := NewSeldonGrpcClient(p, "", nil)
+	g.Expect(err).To(BeNil())
 	resp, err := client.ModelMetadata(context.TODO(), "m", host, port, nil, nil)
 	g.Expect(err).To(BeNil())
 
@@ -41,7 +41,7 @@ func (g GrpcSeldonServer) Predict(ctx context.Context, req *proto.SeldonMessage)
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, md.Get(payload.SeldonPUIDHeader)[0])
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, md, "")
 	reqPayload := payload.ProtoPayload{Msg: req}
-	resPayload, err := seldonPredictorProcess.Predict(&g.predictor.Graph, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Predict(&predictor.ActiveSpec(g.predictor).Graph, &reqPayload)
 	if err != nil {
 		g.Log.Error(err, "Failed to call predict")
 		return payloadToMessage(resPayload), err
@@ -55,7 +55,7 @@ func (g GrpcSeldonServer) SendFeedback(ctx context.Context, req *proto.Feedback)
 	protoGrpc.SetHeader(ctx, header)
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, md, "")
 	reqPayload := payload.ProtoPayload{Msg: req}
-	resPayload, err := seldonPredictorProcess.Feedback(&g.predictor.Graph, &reqPayload)
+	resPayload, err := seldonPredictorProcess.Feedback(&predictor.ActiveSpec(g.predictor).Graph, &reqPayload)
 	if err != nil {
 		g.Log.Error(err, "Failed to call feedback")
 		return payloadToMessage(resPayload), err
@@ -65,7 +65,7 @@ func (g GrpcSeldonServer) SendFeedback(ctx context.Context, req *proto.Feedback)
 
 func (g GrpcSeldonServer) ModelMetadata(ctx context.Context, req *proto.SeldonModelMetadataRequest) (*proto.SeldonModelMetadata, error) {
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), req.GetName())
-	resPayload, err := seldonPredictorProcess.Metadata(&g.predictor.Graph, req.GetName(), nil)
+	resPayload, err := seldonPredictorProcess.Metadata(&predictor.ActiveSpec(g.predictor).Graph, req.GetName(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +76,7 @@ func (g GrpcSeldonServer) GraphMetadata(ctx context.Context, req *empty.Empty) (
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, g.Client, logf.Log.WithName("SeldonMessageRestClient"), g.ServerUrl, g.Namespace, grpc.CollectMetadata(ctx), "")
 
-	graphMetadata, err := seldonPredictorProcess.GraphMetadata(g.predictor)
+	graphMetadata, err := seldonPredictorProcess.GraphMetadata(predictor.ActiveSpec(g.predictor))
 	if err != nil {
 		return nil, err
 	}
@@ -10,12 +10,16 @@ import (
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_opentracing "github.com/grpc-ecosystem/go-grpc-middleware/tracing/opentracing"
 	"github.com/opentracing/opentracing-go"
+	"github.com/seldonio/seldon-core/executor/api/cert"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/k8s"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 const (
@@ -36,7 +40,7 @@ func getMaxMsgSizeFromAnnotations(annotations map[string]string) (int, error) {
 	}
 }
 
-func CreateGrpcServer(spec *v1.PredictorSpec, deploymentName string, annotations map[string]string, logger logr.Logger) (*grpc.Server, error) {
+func CreateGrpcServer(spec *v1.PredictorSpec, deploymentName string, annotations map[string]string, logger logr.Logger, serverOptions ...grpc.ServerOption) (*grpc.Server, error) {
 	maxMsgSize := math.MaxInt32
 	// Update from annotations
 	if annotations != nil {
@@ -58,12 +62,37 @@ func CreateGrpcServer(spec *v1.PredictorSpec, deploymentName string, annotations
 	if opentracing.IsGlobalTracerRegistered() {
 		interceptors = append(interceptors, grpc_opentracing.UnaryServerInterceptor())
 	}
+	interceptors = append(interceptors, clientIdentityInterceptor)
 	opts = append(opts, grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(interceptors...)))
+	opts = append(opts, serverOptions...)
 
 	grpcServer := grpc.NewServer(opts...)
 	return grpcServer, nil
 }
 
+// clientIdentityInterceptor sets the identity of clients verified with mutual TLS in the incoming metadata,
+// removing any identity sent by the client itself.
+func clientIdentityInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	md.Delete(payload.SeldonClientIdentityHeader)
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if identity := cert.ClientIdentity(&tlsInfo.State); identity != "" {
+				md.Set(payload.SeldonClientIdentityHeader, identity)
+				if span := opentracing.SpanFromContext(ctx); span != nil {
+					span.SetTag(tracing.ClientIdentityTag, identity)
+				}
+			}
+		}
+	}
+	return handler(metadata.NewIncomingContext(ctx, md), req)
+}
+
 func CollectMetadata(ctx context.Context) metadata.MD {
 	if mdFromIncoming, ok := metadata.FromIncomingContext(ctx); ok {
 		val := mdFromIncoming.Get(payload.SeldonPUIDHeader)
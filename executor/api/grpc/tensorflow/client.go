@@ -2,7 +2,6 @@ package tensorflow
 
 import (
 	"context"
-	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
@@ -22,7 +21,7 @@ import (
 type TensorflowGrpcClient struct {
 	Log            logr.Logger
 	callOptions    []grpc.CallOption
-	conns          map[string]*grpc.ClientConn
+	pool           *grpc2.ConnectionPool
 	Predictor      *v1.PredictorSpec
 	DeploymentName string
 	annotations    map[string]string
@@ -32,38 +31,39 @@ func (s *TensorflowGrpcClient) IsGrpc() bool {
 	return true
 }
 
-func NewTensorflowGrpcClient(predictor *v1.PredictorSpec, deploymentName string, annotations map[string]string) client.SeldonApiClient {
+func NewTensorflowGrpcClient(predictor *v1.PredictorSpec, deploymentName string, annotations map[string]string) (client.SeldonApiClient, error) {
 	opts := []grpc.CallOption{
 		grpc.MaxCallSendMsgSize(math.MaxInt32),
 		grpc.MaxCallRecvMsgSize(math.MaxInt32),
 	}
+	log := logf.Log.WithName("TensorflowGrpcClient")
+	options, err := grpc2.ClientOptionsFromAnnotations(annotations)
+	if err != nil {
+		return nil, err
+	}
 	smgc := TensorflowGrpcClient{
-		Log:            logf.Log.WithName("TensorflowGrpcClient"),
+		Log:            log,
 		callOptions:    opts,
-		conns:          make(map[string]*grpc.ClientConn),
+		pool:           grpc2.NewConnectionPool(options, predictor),
 		Predictor:      predictor,
 		DeploymentName: deploymentName,
 		annotations:    annotations,
 	}
-	return &smgc
+	return &smgc, nil
 }
 
-func (s *TensorflowGrpcClient) getConnection(host string, port int32, modelName string) (*grpc.ClientConn, error) {
-	k := fmt.Sprintf("%s:%d", host, port)
-	if conn, ok := s.conns[k]; ok {
-		return conn, nil
-	} else {
-		opts := []grpc.DialOption{
-			grpc.WithInsecure(),
-		}
-		opts = append(opts, grpc2.AddClientInterceptors(s.Predictor, s.DeploymentName, modelName, s.annotations, s.Log))
-		conn, err := grpc.Dial(fmt.Sprintf("%s:%d", host, port), opts...)
+func (s *TensorflowGrpcClient) getConnection(ctx context.Context, host string, port int32, modelName string) (*grpc.ClientConn, error) {
+	nodeName := client.NodeName(ctx, modelName)
+	return s.pool.Get(host, port, nodeName, func() ([]grpc.DialOption, error) {
+		creds, err := grpc2.TransportCredentials(s.Predictor, nodeName, s.Log)
 		if err != nil {
 			return nil, err
 		}
-		s.conns[k] = conn
-		return conn, nil
-	}
+		return []grpc.DialOption{
+			creds,
+			grpc2.AddClientInterceptors(s.Predictor, s.DeploymentName, modelName, s.annotations, s.Log),
+		}, nil
+	})
 }
 
 // Allow PredictionResponses to be turned into PredictionRequests
@@ -87,7 +87,7 @@ func (s *TensorflowGrpcClient) Chain(ctx context.Context, modelName string, msg
 }
 
 func (s *TensorflowGrpcClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +128,7 @@ func (s *TensorflowGrpcClient) TransformOutput(ctx context.Context, modelName st
 }
 
 func (s *TensorflowGrpcClient) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
@@ -143,7 +143,7 @@ func (s *TensorflowGrpcClient) Status(ctx context.Context, modelName string, hos
 }
 
 func (s *TensorflowGrpcClient) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	conn, err := s.getConnection(host, port, modelName)
+	conn, err := s.getConnection(ctx, host, port, modelName)
 	if err != nil {
 		return s.CreateErrorPayload(err), err
 	}
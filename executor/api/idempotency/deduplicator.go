@@ -0,0 +1,138 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/k8s"
+)
+
+const (
+	DefaultMaxEntries = 10000
+
+	// The request was handled and its response stored
+	ResultMiss = "miss"
+	// A stored response was returned
+	ResultHit = "hit"
+	// The response of a duplicate request in flight was waited for and returned
+	ResultWaited = "waited"
+)
+
+// call is a request being handled whose duplicates wait for its response.
+type call struct {
+	done     chan struct{}
+	response *Response
+}
+
+// Deduplicator returns the stored response to requests repeating an idempotency key seen within its TTL, and
+// makes duplicates of a request in flight wait for its response rather than being handled again.
+type Deduplicator struct {
+	store Store
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*call
+
+	requests *prometheus.CounterVec
+}
+
+// NewDeduplicator creates a deduplicator keeping responses in store for ttl.
+func NewDeduplicator(store Store, ttl time.Duration, deploymentName string) *Deduplicator {
+	return &Deduplicator{
+		store:    store,
+		ttl:      ttl,
+		calls:    make(map[string]*call),
+		requests: newIdempotencyCounter().MustCurryWith(prometheus.Labels{metric.DeploymentNameMetric: deploymentName}),
+	}
+}
+
+func newIdempotencyCounter() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: metric.IdempotentRequestsMetricName,
+			Help: "Requests with an idempotency key handled, returned a stored response or waiting for a duplicate",
+		},
+		[]string{metric.DeploymentNameMetric, metric.IdempotencyResultMetric},
+	)
+	if err := prometheus.Register(counter); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			counter = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	return counter
+}
+
+// NewDeduplicatorFromAnnotations creates an in-memory deduplicator from the deployment's annotations,
+// returning nil if no TTL is configured.
+func NewDeduplicatorFromAnnotations(annotations map[string]string, deploymentName string) (*Deduplicator, error) {
+	val := annotations[k8s.ANNOTATION_IDEMPOTENCY_TTL]
+	if val == "" {
+		return nil, nil
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_IDEMPOTENCY_TTL, val)
+	}
+	maxEntries := DefaultMaxEntries
+	if val := annotations[k8s.ANNOTATION_IDEMPOTENCY_MAX_ENTRIES]; val != "" {
+		maxEntries, err = strconv.Atoi(val)
+		if err != nil || maxEntries <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_IDEMPOTENCY_MAX_ENTRIES, val)
+		}
+	}
+	return NewDeduplicator(NewMemoryStore(maxEntries), ttl, deploymentName), nil
+}
+
+// ScopedKey scopes an idempotency key to the endpoint, the client sending it and a hash of the request body, so
+// responses aren't returned to other clients, or to requests with a different body, reusing the key.
+func ScopedKey(endpoint string, client string, key string, body []byte) string {
+	return fmt.Sprintf("%q %q %q %x", endpoint, client, key, sha256.Sum256(body))
+}
+
+// Do returns the stored response for key, waits for a request with the same key in flight, or calls handle and
+// stores the response it returns. Responses are not stored if handle returns an error, and requests waiting
+// for it then handle themselves so they can succeed where it failed.
+func (d *Deduplicator) Do(ctx context.Context, key string, handle func() (*Response, error)) (*Response, string, error) {
+	for {
+		d.mu.Lock()
+		if response, ok := d.store.Get(key); ok {
+			d.mu.Unlock()
+			d.requests.WithLabelValues(ResultHit).Inc()
+			return response, ResultHit, nil
+		}
+		if c, ok := d.calls[key]; ok {
+			d.mu.Unlock()
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+				return nil, ResultWaited, ctx.Err()
+			}
+			if c.response != nil {
+				d.requests.WithLabelValues(ResultWaited).Inc()
+				return c.response, ResultWaited, nil
+			}
+			continue
+		}
+		c := &call{done: make(chan struct{})}
+		d.calls[key] = c
+		d.mu.Unlock()
+
+		response, err := handle()
+		d.mu.Lock()
+		if err == nil && response != nil {
+			d.store.Set(key, response, d.ttl)
+			c.response = response
+		}
+		delete(d.calls, key)
+		d.mu.Unlock()
+		close(c.done)
+		d.requests.WithLabelValues(ResultMiss).Inc()
+		return response, ResultMiss, err
+	}
+}
@@ -0,0 +1,156 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/seldonio/seldon-core/executor/k8s"
+)
+
+func TestDeduplicatorStoresResponse(t *testing.T) {
+	g := NewGomegaWithT(t)
+	d := NewDeduplicator(NewMemoryStore(10), time.Minute, "stores")
+	calls := 0
+	handle := func() (*Response, error) {
+		calls++
+		return &Response{Status: 200, Body: []byte("ok")}, nil
+	}
+
+	response, result, err := d.Do(context.Background(), "key", handle)
+	g.Expect(err).To(BeNil())
+	g.Expect(result).To(Equal(ResultMiss))
+	g.Expect(response.Body).To(Equal([]byte("ok")))
+
+	response, result, err = d.Do(context.Background(), "key", handle)
+	g.Expect(err).To(BeNil())
+	g.Expect(result).To(Equal(ResultHit))
+	g.Expect(response.Body).To(Equal([]byte("ok")))
+	g.Expect(calls).To(Equal(1))
+
+	_, result, _ = d.Do(context.Background(), "other", handle)
+	g.Expect(result).To(Equal(ResultMiss))
+	g.Expect(calls).To(Equal(2))
+	g.Expect(testutil.ToFloat64(d.requests.WithLabelValues(ResultHit))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(d.requests.WithLabelValues(ResultMiss))).To(Equal(2.0))
+}
+
+func TestDeduplicatorDoesNotStoreErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	d := NewDeduplicator(NewMemoryStore(10), time.Minute, "errors")
+	_, result, err := d.Do(context.Background(), "key", func() (*Response, error) {
+		return nil, errors.New("failed")
+	})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(result).To(Equal(ResultMiss))
+
+	_, result, err = d.Do(context.Background(), "key", func() (*Response, error) {
+		return &Response{Status: 200}, nil
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(result).To(Equal(ResultMiss))
+}
+
+func TestDeduplicatorWaitsForInFlight(t *testing.T) {
+	g := NewGomegaWithT(t)
+	d := NewDeduplicator(NewMemoryStore(10), time.Minute, "waits")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	handle := func() (*Response, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return &Response{Status: 200, Body: []byte("ok")}, nil
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, result, _ := d.Do(context.Background(), "key", handle)
+		g.Expect(result).To(Equal(ResultMiss))
+	}()
+	<-started
+	results := make([]string, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, result, err := d.Do(context.Background(), "key", handle)
+			g.Expect(err).To(BeNil())
+			g.Expect(response.Body).To(Equal([]byte("ok")))
+			results[i] = result
+		}(i)
+	}
+	// Give the duplicates time to start waiting, though any which don't will find the stored response
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	g.Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+	for _, result := range results {
+		g.Expect(result).To(Or(Equal(ResultWaited), Equal(ResultHit)))
+	}
+}
+
+func TestDeduplicatorWaitCancelled(t *testing.T) {
+	g := NewGomegaWithT(t)
+	d := NewDeduplicator(NewMemoryStore(10), time.Minute, "cancelled")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go d.Do(context.Background(), "key", func() (*Response, error) {
+		close(started)
+		<-release
+		return &Response{}, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := d.Do(ctx, "key", func() (*Response, error) {
+		return &Response{}, nil
+	})
+	g.Expect(err).To(Equal(context.DeadlineExceeded))
+}
+
+func TestNewDeduplicatorFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	d, err := NewDeduplicatorFromAnnotations(map[string]string{}, "annotations")
+	g.Expect(err).To(BeNil())
+	g.Expect(d).To(BeNil())
+
+	d, err = NewDeduplicatorFromAnnotations(map[string]string{
+		k8s.ANNOTATION_IDEMPOTENCY_TTL:         "5m",
+		k8s.ANNOTATION_IDEMPOTENCY_MAX_ENTRIES: "100",
+	}, "annotations")
+	g.Expect(err).To(BeNil())
+	g.Expect(d.ttl).To(Equal(5 * time.Minute))
+	g.Expect(d.store).To(BeAssignableToTypeOf(&MemoryStore{}))
+
+	_, err = NewDeduplicatorFromAnnotations(map[string]string{k8s.ANNOTATION_IDEMPOTENCY_TTL: "0s"}, "annotations")
+	g.Expect(err).ToNot(BeNil())
+	_, err = NewDeduplicatorFromAnnotations(map[string]string{
+		k8s.ANNOTATION_IDEMPOTENCY_TTL:         "5m",
+		k8s.ANNOTATION_IDEMPOTENCY_MAX_ENTRIES: "none",
+	}, "annotations")
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestScopedKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	key := ScopedKey("/predict", "alice", "key-1", []byte("a"))
+	g.Expect(ScopedKey("/predict", "alice", "key-1", []byte("a"))).To(Equal(key))
+	g.Expect(ScopedKey("/predict", "bob", "key-1", []byte("a"))).ToNot(Equal(key))
+	g.Expect(ScopedKey("/predict", "alice", "key-1", []byte("b"))).ToNot(Equal(key))
+	// Parts containing spaces can't be confused with each other
+	g.Expect(ScopedKey("/predict", "a b", "c", nil)).ToNot(Equal(ScopedKey("/predict", "a", "b c", nil)))
+}
@@ -0,0 +1,51 @@
+package idempotency
+
+import (
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/api/cache"
+)
+
+// Response is a response stored to be returned to requests repeating its idempotency key.
+type Response struct {
+	// HTTP status code, or zero for gRPC responses
+	Status      int
+	ContentType string
+	// Fully qualified name of a gRPC response message
+	MessageType string
+	Body        []byte
+}
+
+// Store holds responses by idempotency key until their TTL passes. Implementations must be safe for
+// concurrent use, so a store shared between replicas can be used in place of the in-memory one.
+type Store interface {
+	Get(key string) (*Response, bool)
+	Set(key string, response *Response, ttl time.Duration)
+}
+
+// MemoryStore keeps responses in memory, removing the least recently used when it holds maxEntries.
+type MemoryStore struct {
+	lru *cache.LRU
+}
+
+// NewMemoryStore creates an in-memory store holding up to maxEntries responses.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{lru: cache.NewLRU(maxEntries)}
+}
+
+func (s *MemoryStore) Get(key string) (*Response, bool) {
+	value, ok := s.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return value.(*Response), true
+}
+
+func (s *MemoryStore) Set(key string, response *Response, ttl time.Duration) {
+	s.lru.Set(key, response, ttl)
+}
+
+// Len returns the number of responses held, including any expired but not yet removed.
+func (s *MemoryStore) Len() int {
+	return s.lru.Len()
+}
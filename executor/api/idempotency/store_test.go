@@ -0,0 +1,26 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMemoryStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	s := NewMemoryStore(1)
+	_, ok := s.Get("a")
+	g.Expect(ok).To(BeFalse())
+
+	s.Set("a", &Response{Status: 200, Body: []byte("a")}, time.Minute)
+	response, ok := s.Get("a")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(response.Body).To(Equal([]byte("a")))
+
+	// The least recently used response is removed once the store is full
+	s.Set("b", &Response{Status: 200, Body: []byte("b")}, time.Minute)
+	g.Expect(s.Len()).To(Equal(1))
+	_, ok = s.Get("a")
+	g.Expect(ok).To(BeFalse())
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"reflect"
+	"sync"
 	"syscall"
 	"time"
 
@@ -92,9 +93,12 @@ func NewKafkaServer(
 		case api.TransportGrpc:
 			log.Info("Start grpc kafka graph")
 			if protocol == "seldon" {
-				apiClient = seldon.NewSeldonGrpcClient(predictor, deploymentName, annotations)
+				apiClient, err = seldon.NewSeldonGrpcClient(predictor, deploymentName, annotations)
 			} else {
-				apiClient = tensorflow.NewTensorflowGrpcClient(predictor, deploymentName, annotations)
+				apiClient, err = tensorflow.NewTensorflowGrpcClient(predictor, deploymentName, annotations)
+			}
+			if err != nil {
+				return nil, err
 			}
 		default:
 			return nil, fmt.Errorf("Unknown transport %s", transport)
@@ -189,16 +193,17 @@ func (ks *SeldonKafkaServer) Serve() error {
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
-	cancelChan := make(chan struct{})
 	jobChan := make(chan *KafkaJob, ks.Workers)
+	workers := sync.WaitGroup{}
 	for i := 0; i < ks.Workers; i++ {
-		go ks.worker(jobChan, cancelChan)
+		workers.Add(1)
+		go ks.worker(jobChan, &workers)
 	}
 
 	//wait for graph to be ready
 	ready := false
 	for ready == false {
-		err := predictor.Ready(ks.Protocol, &ks.Predictor.Graph, ks.FullHealthCheck)
+		err := predictor.Ready(ks.Protocol, &predictor.ActiveSpec(ks.Predictor).Graph, ks.FullHealthCheck)
 		ready = err == nil
 		if !ready {
 			ks.Log.Info("Waiting for graph to be ready")
@@ -285,7 +290,9 @@ func (ks *SeldonKafkaServer) Serve() error {
 
 	ks.Log.Info("Final Processed", "messages", cnt)
 	ks.Log.Info("Closing consumer")
-	close(cancelChan)
+	// Finish the jobs already taken from the topic before closing the consumer they are committed with
+	close(jobChan)
+	workers.Wait()
 	c.Close()
 	return nil
 }
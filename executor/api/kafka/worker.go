@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"context"
+	"sync"
 
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 	"github.com/opentracing/opentracing-go"
@@ -17,19 +18,17 @@ type KafkaJob struct {
 	reqPayload payload.SeldonPayload
 }
 
-func (ks *SeldonKafkaServer) worker(jobChan <-chan *KafkaJob, cancelChan <-chan struct{}) {
-	for {
-		select {
-		case <-cancelChan:
-			return
-
-		case job := <-jobChan:
-			ks.processKafkaRequest(job)
-		}
+// worker processes jobs until the job channel is closed, so jobs already queued are finished on shutdown.
+func (ks *SeldonKafkaServer) worker(jobChan <-chan *KafkaJob, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for job := range jobChan {
+		ks.processKafkaRequest(job)
 	}
 }
 
 func (ks *SeldonKafkaServer) processKafkaRequest(job *KafkaJob) {
+	done := predictor.TrackRequest("kafka "+ks.TopicIn, job.headers[payload.SeldonPUIDHeader][0])
+	defer done()
 	ctx := context.Background()
 	// Add Seldon Puid to Context
 	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, job.headers[payload.SeldonPUIDHeader][0])
@@ -44,7 +43,7 @@ func (ks *SeldonKafkaServer) processKafkaRequest(job *KafkaJob) {
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, ks.Client, logf.Log.WithName("KafkaClient"), ks.ServerUrl, ks.Namespace, job.headers, "")
 
-	resPayload, err := seldonPredictorProcess.Predict(&ks.Predictor.Graph, job.reqPayload)
+	resPayload, err := seldonPredictorProcess.Predict(&predictor.ActiveSpec(ks.Predictor).Graph, job.reqPayload)
 	if err != nil {
 		ks.Log.Error(err, "Failed prediction")
 		return
@@ -1,23 +1,50 @@
 package metric
 
 const (
-	CodeMetric             = "code"    // 2xx, 5xx etc
-	HTTPMethodMetric       = "method"  // Http Method (Post, Get etc)
-	ServiceMetric          = "service" // http or grpc service: prediction, feedback etc
-	DeploymentNameMetric   = "deployment_name"
-	PredictorNameMetric    = "predictor_name"
-	PredictorVersionMetric = "predictor_version"
-	ModelNameMetric        = "model_name"
-	ModelImageMetric       = "model_image"
-	ModelVersionMetric     = "model_version"
+	CodeMetric                  = "code"    // 2xx, 5xx etc
+	HTTPMethodMetric            = "method"  // Http Method (Post, Get etc)
+	ServiceMetric               = "service" // http or grpc service: prediction, feedback etc
+	DeploymentNameMetric        = "deployment_name"
+	PredictorNameMetric         = "predictor_name"
+	PredictorVersionMetric      = "predictor_version"
+	ModelNameMetric             = "model_name"
+	ModelImageMetric            = "model_image"
+	ModelVersionMetric          = "model_version"
+	CertificatePathMetric       = "certificate"
+	RateLimitResultMetric       = "result" // admitted or rejected
+	RateLimitMetric             = "limit"  // global or client limit rejecting a request
+	ConcurrencyShedReasonMetric = "reason"
+	ConnectionReusedMetric      = "reused"
+	GraphVersionMetric          = "graph_version"
+	GraphReloadResultMetric     = "result" // loaded or rejected
+	RecordResultMetric          = "result" // recorded or dropped
+	IdempotencyResultMetric     = "result" // miss, hit or waited
+	CacheMetric                 = "cache"  // graph or the name of a graph node
+	CacheResultMetric           = "result" // hit or miss
 
-	ServerRequestsMetricName = "seldon_api_executor_server_requests_seconds"
-	ClientRequestsMetricName = "seldon_api_executor_client_requests_seconds"
+	ServerRequestsMetricName        = "seldon_api_executor_server_requests_seconds"
+	ClientRequestsMetricName        = "seldon_api_executor_client_requests_seconds"
+	CertificateExpiryMetricName     = "seldon_api_executor_certificate_expiry_timestamp_seconds"
+	RateLimitRequestsMetricName     = "seldon_api_executor_rate_limit_requests_total"
+	ConcurrencyInFlightMetricName   = "seldon_api_executor_inflight_requests"
+	ConcurrencyLimitMetricName      = "seldon_api_executor_concurrency_limit"
+	ConcurrencyShedMetricName       = "seldon_api_executor_shed_requests_total"
+	BulkheadActiveMetricName        = "seldon_api_executor_node_active_requests"
+	BulkheadQueuedMetricName        = "seldon_api_executor_node_queued_requests"
+	ClientOpenConnectionsMetricName = "seldon_api_executor_client_open_connections"
+	ClientConnectionsMetricName     = "seldon_api_executor_client_connection_requests_total"
+	GraphVersionMetricName          = "seldon_api_executor_graph_version_info"
+	GraphReloadsMetricName          = "seldon_api_executor_graph_reloads_total"
+	RecordedRequestsMetricName      = "seldon_api_executor_recorded_requests_total"
+	IdempotentRequestsMetricName    = "seldon_api_executor_idempotent_requests_total"
+	CacheRequestsMetricName         = "seldon_api_executor_cache_requests_total"
 
-	PredictionHttpServiceName = "predictions"
-	StatusHttpServiceName     = "status"
-	MetadataHttpServiceName   = "metadata"
-	FeedbackHttpServiceName   = "feedback"
+	PredictionHttpServiceName          = "predictions"
+	PredictionBatchHttpServiceName     = "predictions-batch"
+	PredictionWebsocketHttpServiceName = "predictions-websocket"
+	StatusHttpServiceName              = "status"
+	MetadataHttpServiceName            = "metadata"
+	FeedbackHttpServiceName            = "feedback"
 )
 
 var (
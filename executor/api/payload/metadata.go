@@ -7,6 +7,16 @@ import (
 const (
 	SeldonPUIDHeader        = "Seldon-Puid"
 	SeldonSkipLoggingHeader = "Seldon-Skip-Logging"
+	// Identity of the client verified with mutual TLS
+	SeldonClientIdentityHeader = "Seldon-Client-Identity"
+	// Claims of the JWT bearer token validated by the executor, encoded as JSON
+	SeldonJwtClaimsHeader = "Seldon-Jwt-Claims"
+	// Requests a trace of each graph node's input and output in the response, if enabled for the deployment
+	SeldonDebugTraceHeader = "Seldon-Debug-Trace"
+	// Identifies a request so retries of it are given the stored response, if enabled for the deployment
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// Set on responses which are the stored response to an earlier request with the same idempotency key
+	IdempotentReplayedHeader = "Idempotent-Replayed"
 )
 
 type MetaData struct {
@@ -25,6 +35,16 @@ func NewFromMap(m map[string][]string) *MetaData {
 	return &meta
 }
 
+// GetAsString returns the first value of a key, matched case insensitively as gRPC metadata keys are lower case.
+func (m *MetaData) GetAsString(key string) string {
+	for k, values := range m.Meta {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
 func (m *MetaData) GetAsBoolean(key string, def bool) bool {
 	values, ok := m.Meta[key]
 	if !ok {
@@ -86,3 +86,11 @@ func TestGetAsBoolean(t *testing.T) {
 		g.Expect(asBool).To(Equal(test.expected))
 	}
 }
+
+func TestGetAsString(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	meta := NewFromMap(map[string][]string{"seldon-client-identity": {"client"}})
+	g.Expect(meta.GetAsString(SeldonClientIdentityHeader)).To(Equal("client"))
+	g.Expect(meta.GetAsString("foo")).To(Equal(""))
+}
@@ -0,0 +1,21 @@
+package api
+
+// IsSameProtocol checks whether two protocols share the same payload format.
+func IsSameProtocol(a string, b string) bool {
+	normalise := func(protocol string) string {
+		if protocol == ProtocolKFServing {
+			return ProtocolV2
+		}
+		return protocol
+	}
+	return normalise(a) == normalise(b)
+}
+
+// IsKnownProtocol checks whether the executor can send payloads of a protocol.
+func IsKnownProtocol(protocol string) bool {
+	switch protocol {
+	case ProtocolSeldon, ProtocolTensorflow, ProtocolV2, ProtocolKFServing:
+		return true
+	}
+	return false
+}
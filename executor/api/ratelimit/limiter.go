@@ -0,0 +1,251 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Sources of the key identifying clients for their own limits
+	KeySourceIP     = "ip"
+	KeySourceHeader = "header"
+	KeySourceClaim  = "claim"
+
+	LimitGlobal = "global"
+	LimitClient = "client"
+
+	ResultAdmitted = "admitted"
+	ResultRejected = "rejected"
+
+	// Limiters of clients which haven't made requests for this long are removed
+	clientIdleTimeout = 10 * time.Minute
+	cleanupInterval   = time.Minute
+)
+
+// ClientKey describes where the key identifying a client is taken from.
+type ClientKey struct {
+	Source string
+	// Header or claim name
+	Name string
+}
+
+// ParseClientKey parses ip, header:<name> or claim:<name>, where nested claims are selected with dots.
+func ParseClientKey(value string) (ClientKey, error) {
+	parts := strings.SplitN(value, ":", 2)
+	switch {
+	case value == "" || value == KeySourceIP:
+		return ClientKey{Source: KeySourceIP}, nil
+	case len(parts) == 2 && (parts[0] == KeySourceHeader || parts[0] == KeySourceClaim) && parts[1] != "":
+		return ClientKey{Source: parts[0], Name: parts[1]}, nil
+	default:
+		return ClientKey{}, fmt.Errorf("invalid client key %q, expected ip, header:<name> or claim:<name>", value)
+	}
+}
+
+// ClaimValue returns a claim from the JSON encoded claims of a validated JWT.
+func ClaimValue(encodedClaims string, name string) string {
+	if encodedClaims == "" {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(encodedClaims), &value); err != nil {
+		return ""
+	}
+	for _, part := range strings.Split(name, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = m[part]
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter applies token bucket limits to the rate of requests, both globally and for each client.
+type Limiter struct {
+	global      *rate.Limiter
+	clientRate  rate.Limit
+	clientBurst int
+	Key         ClientKey
+
+	mu          sync.Mutex
+	clients     map[string]*clientLimiter
+	lastCleanup time.Time
+
+	requests *prometheus.CounterVec
+}
+
+// NewLimiter creates a limiter allowing globalRate requests per second overall and clientRate requests per
+// second for each client, either of which can be zero for no limit. Bursts default to the rate rounded up.
+func NewLimiter(globalRate float64, globalBurst int, clientRate float64, clientBurst int, key ClientKey, deploymentName string) *Limiter {
+	l := &Limiter{
+		clientRate:  rate.Limit(clientRate),
+		clientBurst: defaultBurst(clientRate, clientBurst),
+		Key:         key,
+		clients:     make(map[string]*clientLimiter),
+		lastCleanup: time.Now(),
+		requests:    newRateLimitCounter().MustCurryWith(prometheus.Labels{metric.DeploymentNameMetric: deploymentName}),
+	}
+	if globalRate > 0 {
+		l.global = rate.NewLimiter(rate.Limit(globalRate), defaultBurst(globalRate, globalBurst))
+	}
+	return l
+}
+
+func defaultBurst(r float64, burst int) int {
+	if burst > 0 {
+		return burst
+	}
+	return int(math.Ceil(r))
+}
+
+func newRateLimitCounter() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: metric.RateLimitRequestsMetricName,
+			Help: "Requests admitted or rejected by the rate limits",
+		},
+		[]string{metric.DeploymentNameMetric, metric.RateLimitResultMetric, metric.RateLimitMetric},
+	)
+	if err := prometheus.Register(counter); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			counter = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	return counter
+}
+
+// NewLimiterFromAnnotations creates a limiter from the deployment's annotations, returning nil if no
+// limits are configured.
+func NewLimiterFromAnnotations(annotations map[string]string, deploymentName string) (*Limiter, error) {
+	globalRate, err := parseFloatAnnotation(annotations, k8s.ANNOTATION_RATE_LIMIT)
+	if err != nil {
+		return nil, err
+	}
+	globalBurst, err := parseIntAnnotation(annotations, k8s.ANNOTATION_RATE_LIMIT_BURST)
+	if err != nil {
+		return nil, err
+	}
+	clientRate, err := parseFloatAnnotation(annotations, k8s.ANNOTATION_RATE_LIMIT_PER_CLIENT)
+	if err != nil {
+		return nil, err
+	}
+	clientBurst, err := parseIntAnnotation(annotations, k8s.ANNOTATION_RATE_LIMIT_PER_CLIENT_BURST)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseClientKey(annotations[k8s.ANNOTATION_RATE_LIMIT_CLIENT_KEY])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", k8s.ANNOTATION_RATE_LIMIT_CLIENT_KEY)
+	}
+	if globalRate <= 0 && clientRate <= 0 {
+		return nil, nil
+	}
+	return NewLimiter(globalRate, globalBurst, clientRate, clientBurst, key, deploymentName), nil
+}
+
+func parseFloatAnnotation(annotations map[string]string, name string) (float64, error) {
+	val := annotations[name]
+	if val == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return f, nil
+}
+
+func parseIntAnnotation(annotations map[string]string, name string) (int, error) {
+	val := annotations[name]
+	if val == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return i, nil
+}
+
+// Allow checks whether a request from a client is within the limits, returning how long to wait before
+// retrying if not. Requests without a client key are only subject to the global limit.
+func (l *Limiter) Allow(clientKey string) (bool, time.Duration) {
+	now := time.Now()
+	var clientReservation *rate.Reservation
+	if l.clientRate > 0 && clientKey != "" {
+		clientReservation = l.clientLimiter(clientKey, now).ReserveN(now, 1)
+		if delay := clientReservation.DelayFrom(now); delay > 0 {
+			clientReservation.CancelAt(now)
+			l.requests.WithLabelValues(ResultRejected, LimitClient).Inc()
+			return false, delay
+		}
+	}
+	if l.global != nil {
+		reservation := l.global.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			// The request isn't made so it doesn't count towards the client's limit
+			if clientReservation != nil {
+				clientReservation.CancelAt(now)
+			}
+			l.requests.WithLabelValues(ResultRejected, LimitGlobal).Inc()
+			return false, delay
+		}
+	}
+	l.requests.WithLabelValues(ResultAdmitted, "").Inc()
+	return true, 0
+}
+
+func (l *Limiter) clientLimiter(clientKey string, now time.Time) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastCleanup) > cleanupInterval {
+		for key, c := range l.clients {
+			if now.Sub(c.lastSeen) > clientIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.lastCleanup = now
+	}
+	c, ok := l.clients[clientKey]
+	if !ok {
+		c = &clientLimiter{limiter: rate.NewLimiter(l.clientRate, l.clientBurst)}
+		l.clients[clientKey] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
+
+// RetryAfterSeconds rounds a delay up to whole seconds for the Retry-After header.
+func RetryAfterSeconds(delay time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(delay.Seconds())))
+}
+
+// LimitExceededError is returned for requests rejected by a rate limit.
+type LimitExceededError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Millisecond))
+}
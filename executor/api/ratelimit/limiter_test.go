@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/seldonio/seldon-core/executor/k8s"
+)
+
+func TestLimiterGlobal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(1, 2, 0, 0, ClientKey{Source: KeySourceIP}, "global-test")
+	ok, _ := l.Allow("a")
+	g.Expect(ok).To(BeTrue())
+	ok, _ = l.Allow("b")
+	g.Expect(ok).To(BeTrue())
+	ok, retryAfter := l.Allow("c")
+	g.Expect(ok).To(BeFalse())
+	g.Expect(retryAfter).To(BeNumerically(">", 0))
+	g.Expect(retryAfter).To(BeNumerically("<=", time.Second))
+	g.Expect(RetryAfterSeconds(retryAfter)).To(Equal("1"))
+
+	g.Expect(testutil.ToFloat64(l.requests.WithLabelValues(ResultAdmitted, ""))).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(l.requests.WithLabelValues(ResultRejected, LimitGlobal))).To(Equal(1.0))
+}
+
+func TestLimiterPerClient(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(0, 0, 1, 1, ClientKey{Source: KeySourceIP}, "client-test")
+	ok, _ := l.Allow("a")
+	g.Expect(ok).To(BeTrue())
+	ok, _ = l.Allow("a")
+	g.Expect(ok).To(BeFalse())
+	// Other clients have their own limit
+	ok, _ = l.Allow("b")
+	g.Expect(ok).To(BeTrue())
+	// Requests without a key are only subject to the global limit
+	ok, _ = l.Allow("")
+	g.Expect(ok).To(BeTrue())
+	ok, _ = l.Allow("")
+	g.Expect(ok).To(BeTrue())
+
+	g.Expect(testutil.ToFloat64(l.requests.WithLabelValues(ResultRejected, LimitClient))).To(Equal(1.0))
+}
+
+func TestLimiterGlobalRejectionDoesNotUseClientLimit(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l := NewLimiter(1, 1, 1, 1, ClientKey{Source: KeySourceIP}, "combined-test")
+	ok, _ := l.Allow("a")
+	g.Expect(ok).To(BeTrue())
+	ok, _ = l.Allow("b")
+	g.Expect(ok).To(BeFalse())
+	// b's rejected request didn't use its token
+	l.global = nil
+	ok, _ = l.Allow("b")
+	g.Expect(ok).To(BeTrue())
+}
+
+func TestNewLimiterFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l, err := NewLimiterFromAnnotations(map[string]string{}, "dep")
+	g.Expect(err).To(BeNil())
+	g.Expect(l).To(BeNil())
+
+	l, err = NewLimiterFromAnnotations(map[string]string{
+		k8s.ANNOTATION_RATE_LIMIT_PER_CLIENT: "2.5",
+		k8s.ANNOTATION_RATE_LIMIT_CLIENT_KEY: "claim:sub",
+	}, "dep")
+	g.Expect(err).To(BeNil())
+	g.Expect(l.global).To(BeNil())
+	g.Expect(l.clientBurst).To(Equal(3))
+	g.Expect(l.Key).To(Equal(ClientKey{Source: KeySourceClaim, Name: "sub"}))
+
+	_, err = NewLimiterFromAnnotations(map[string]string{k8s.ANNOTATION_RATE_LIMIT: "fast"}, "dep")
+	g.Expect(err).ToNot(BeNil())
+	_, err = NewLimiterFromAnnotations(map[string]string{k8s.ANNOTATION_RATE_LIMIT: "1", k8s.ANNOTATION_RATE_LIMIT_CLIENT_KEY: "cookie:id"}, "dep")
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestClaimValue(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	claims := `{"sub":"alice","tenant":{"id":"t1"}}`
+	g.Expect(ClaimValue(claims, "sub")).To(Equal("alice"))
+	g.Expect(ClaimValue(claims, "tenant.id")).To(Equal("t1"))
+	g.Expect(ClaimValue(claims, "missing")).To(Equal(""))
+	g.Expect(ClaimValue("", "sub")).To(Equal(""))
+}
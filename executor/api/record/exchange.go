@@ -0,0 +1,105 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+// Longest line read from a recording
+const maxLineSize = 64 * 1024 * 1024
+
+// Request headers which are never recorded as they hold credentials
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Exchange is a recorded request and the executor's response to it, written to recordings as a line of JSON.
+// Bodies holding JSON are recorded as JSON values in request and response, and other bodies are base64 encoded
+// in requestBase64 and responseBase64.
+type Exchange struct {
+	Time           time.Time           `json:"time"`
+	Puid           string              `json:"puid,omitempty"`
+	Method         string              `json:"method"`
+	Path           string              `json:"path"`
+	Headers        map[string][]string `json:"headers,omitempty"`
+	Request        json.RawMessage     `json:"request,omitempty"`
+	RequestBase64  []byte              `json:"requestBase64,omitempty"`
+	Status         int                 `json:"status"`
+	Response       json.RawMessage     `json:"response,omitempty"`
+	ResponseBase64 []byte              `json:"responseBase64,omitempty"`
+	DurationMs     float64             `json:"durationMs"`
+}
+
+// NewExchange records a request handled by the executor.
+func NewExchange(req *http.Request, requestBody []byte, status int, responseBody []byte, duration time.Duration) *Exchange {
+	headers := req.Header.Clone()
+	for _, name := range redactedHeaders {
+		headers.Del(name)
+	}
+	e := &Exchange{
+		Time:       time.Now().UTC(),
+		Puid:       req.Header.Get(payload.SeldonPUIDHeader),
+		Method:     req.Method,
+		Path:       req.URL.RequestURI(),
+		Headers:    headers,
+		Status:     status,
+		DurationMs: float64(duration) / float64(time.Millisecond),
+	}
+	e.Request, e.RequestBase64 = encodeBody(requestBody)
+	e.Response, e.ResponseBase64 = encodeBody(responseBody)
+	return e
+}
+
+func encodeBody(body []byte) (json.RawMessage, []byte) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+	if json.Valid(body) {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, body); err == nil {
+			return compacted.Bytes(), nil
+		}
+	}
+	return nil, body
+}
+
+// RequestBody returns the body of the recorded request.
+func (e *Exchange) RequestBody() []byte {
+	if e.Request != nil {
+		return e.Request
+	}
+	return e.RequestBase64
+}
+
+// ResponseBody returns the body of the recorded response.
+func (e *Exchange) ResponseBody() []byte {
+	if e.Response != nil {
+		return e.Response
+	}
+	return e.ResponseBase64
+}
+
+// ReadExchanges reads a recording.
+func ReadExchanges(r io.Reader) ([]*Exchange, error) {
+	var exchanges []*Exchange
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		e := &Exchange{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return nil, errors.Wrapf(err, "invalid exchange on line %d", line)
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, scanner.Err()
+}
@@ -0,0 +1,208 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/k8s"
+)
+
+const (
+	DefaultMaxMegabytes = 100
+	DefaultMaxFiles     = 5
+
+	ResultRecorded = "recorded"
+	ResultDropped  = "dropped"
+
+	// Exchanges waiting to be written before new ones are dropped
+	queueLength = 1000
+)
+
+// Recorder samples requests and responses and writes them to a file, which is rotated when it grows too large.
+// Exchanges are written in the background and dropped if the file can't keep up, so recording never slows
+// down requests.
+type Recorder struct {
+	path       string
+	sampleRate float64
+	maxBytes   int64
+	maxFiles   int
+	log        logr.Logger
+
+	queue chan *Exchange
+	done  chan struct{}
+	once  sync.Once
+
+	file *os.File
+	size int64
+
+	exchanges *prometheus.CounterVec
+}
+
+// NewRecorder records a fraction sampleRate of requests to path, keeping up to maxFiles files of at most maxBytes.
+func NewRecorder(path string, sampleRate float64, maxBytes int64, maxFiles int, deploymentName string, log logr.Logger) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		path:       path,
+		sampleRate: sampleRate,
+		maxBytes:   maxBytes,
+		maxFiles:   maxFiles,
+		log:        log.WithName("Recorder"),
+		queue:      make(chan *Exchange, queueLength),
+		done:       make(chan struct{}),
+		exchanges:  newRecordedCounter().MustCurryWith(prometheus.Labels{metric.DeploymentNameMetric: deploymentName}),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.write()
+	return r, nil
+}
+
+func newRecordedCounter() *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: metric.RecordedRequestsMetricName,
+			Help: "Sampled requests recorded or dropped because the recording couldn't keep up",
+		},
+		[]string{metric.DeploymentNameMetric, metric.RecordResultMetric},
+	)
+	if err := prometheus.Register(counter); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			counter = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	return counter
+}
+
+// NewRecorderFromAnnotations creates a recorder from the deployment's annotations, returning nil if no
+// recording file is configured.
+func NewRecorderFromAnnotations(annotations map[string]string, deploymentName string, log logr.Logger) (*Recorder, error) {
+	path := annotations[k8s.ANNOTATION_RECORD_FILE]
+	if path == "" {
+		return nil, nil
+	}
+	sampleRate := 1.0
+	if val := annotations[k8s.ANNOTATION_RECORD_SAMPLE_RATE]; val != "" {
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil || f < 0 || f > 1 {
+			return nil, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_RECORD_SAMPLE_RATE, val)
+		}
+		sampleRate = f
+	}
+	maxMegabytes, err := parsePositiveInt(annotations, k8s.ANNOTATION_RECORD_MAX_MEGABYTES, DefaultMaxMegabytes)
+	if err != nil {
+		return nil, err
+	}
+	maxFiles, err := parsePositiveInt(annotations, k8s.ANNOTATION_RECORD_MAX_FILES, DefaultMaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorder(path, sampleRate, int64(maxMegabytes)*1024*1024, maxFiles, deploymentName, log)
+}
+
+func parsePositiveInt(annotations map[string]string, name string, defaultValue int) (int, error) {
+	val := annotations[name]
+	if val == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil || i <= 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, val)
+	}
+	return i, nil
+}
+
+// Sample returns whether a request should be recorded.
+func (r *Recorder) Sample() bool {
+	return r.sampleRate >= 1 || rand.Float64() < r.sampleRate
+}
+
+// Record queues an exchange to be written, dropping it if the queue is full.
+func (r *Recorder) Record(e *Exchange) {
+	select {
+	case r.queue <- e:
+	default:
+		r.exchanges.WithLabelValues(ResultDropped).Inc()
+	}
+}
+
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate renames the recording to path.1, shifting older files up and removing the oldest.
+func (r *Recorder) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	for i := r.maxFiles - 1; i > 0; i-- {
+		from := r.path
+		if i > 1 {
+			from = fmt.Sprintf("%s.%d", r.path, i-1)
+		}
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", r.path, i)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if r.maxFiles <= 1 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return r.open()
+}
+
+func (r *Recorder) write() {
+	defer close(r.done)
+	for e := range r.queue {
+		line, err := json.Marshal(e)
+		if err != nil {
+			r.log.Error(err, "Failed to encode exchange", "puid", e.Puid)
+			continue
+		}
+		line = append(line, '\n')
+		if r.size > 0 && r.size+int64(len(line)) > r.maxBytes {
+			if err := r.rotate(); err != nil {
+				r.log.Error(err, "Failed to rotate recording, stopping recording", "path", r.path)
+				return
+			}
+		}
+		n, err := r.file.Write(line)
+		r.size += int64(n)
+		if err != nil {
+			r.log.Error(err, "Failed to write exchange", "puid", e.Puid)
+			continue
+		}
+		r.exchanges.WithLabelValues(ResultRecorded).Inc()
+	}
+	r.file.Close()
+}
+
+// Close writes the queued exchanges and closes the recording.
+func (r *Recorder) Close() {
+	r.once.Do(func() {
+		close(r.queue)
+	})
+	<-r.done
+}
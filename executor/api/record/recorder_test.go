@@ -0,0 +1,113 @@
+package record
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func readRecording(g *GomegaWithT, path string) []*Exchange {
+	file, err := os.Open(path)
+	g.Expect(err).To(BeNil())
+	defer file.Close()
+	exchanges, err := ReadExchanges(file)
+	g.Expect(err).To(BeNil())
+	return exchanges
+}
+
+func TestNewExchange(t *testing.T) {
+	g := NewGomegaWithT(t)
+	req := httptest.NewRequest("POST", "/api/v1.0/predictions?x=1", nil)
+	req.Header.Set("Seldon-Puid", "puid")
+	req.Header.Set("Cookie", "session")
+	e := NewExchange(req, []byte("{ \"a\": 1 }"), 200, []byte{0xff, 0x00}, 5*time.Millisecond)
+	g.Expect(e.Puid).To(Equal("puid"))
+	g.Expect(e.Path).To(Equal("/api/v1.0/predictions?x=1"))
+	g.Expect(e.Headers).ToNot(HaveKey("Cookie"))
+	g.Expect(string(e.Request)).To(Equal(`{"a":1}`))
+	g.Expect(e.RequestBase64).To(BeNil())
+	g.Expect(e.Response).To(BeNil())
+	g.Expect(e.ResponseBody()).To(Equal([]byte{0xff, 0x00}))
+	g.Expect(e.DurationMs).To(Equal(5.0))
+}
+
+func TestRecorderRotates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	r, err := NewRecorder(path, 1, 600, 3, "rotate", logf.Log)
+	g.Expect(err).To(BeNil())
+
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest("POST", "/api/v1.0/predictions", nil)
+		req.Header.Set("Seldon-Puid", fmt.Sprintf("puid-%d", i))
+		r.Record(NewExchange(req, []byte(`{"data":{"ndarray":[1]}}`), 200, []byte(`{"data":{"ndarray":[2]}}`), time.Millisecond))
+	}
+	r.Close()
+
+	g.Expect(path + ".1").To(BeAnExistingFile())
+	g.Expect(path + ".2").To(BeAnExistingFile())
+	g.Expect(path + ".3").ToNot(BeAnExistingFile())
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		g.Expect(err).To(BeNil())
+		g.Expect(info.Size()).To(BeNumerically("<=", 600))
+	}
+	// The newest exchange is in the current file
+	exchanges := readRecording(g, path)
+	g.Expect(exchanges[len(exchanges)-1].Puid).To(Equal("puid-9"))
+	g.Expect(testutil.ToFloat64(r.exchanges.WithLabelValues(ResultRecorded))).To(Equal(10.0))
+}
+
+func TestNewRecorderFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r, err := NewRecorderFromAnnotations(map[string]string{}, "test", logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(r).To(BeNil())
+
+	path := filepath.Join(t.TempDir(), "recordings", "requests.jsonl")
+	r, err = NewRecorderFromAnnotations(map[string]string{
+		k8s.ANNOTATION_RECORD_FILE:          path,
+		k8s.ANNOTATION_RECORD_SAMPLE_RATE:   "0.25",
+		k8s.ANNOTATION_RECORD_MAX_FILES:     "2",
+		k8s.ANNOTATION_RECORD_MAX_MEGABYTES: "1",
+	}, "test", logf.Log)
+	g.Expect(err).To(BeNil())
+	defer r.Close()
+	g.Expect(r.sampleRate).To(Equal(0.25))
+	g.Expect(r.maxFiles).To(Equal(2))
+	g.Expect(r.maxBytes).To(Equal(int64(1024 * 1024)))
+	g.Expect(path).To(BeAnExistingFile())
+
+	for _, annotations := range []map[string]string{
+		{k8s.ANNOTATION_RECORD_FILE: path, k8s.ANNOTATION_RECORD_SAMPLE_RATE: "2"},
+		{k8s.ANNOTATION_RECORD_FILE: path, k8s.ANNOTATION_RECORD_MAX_FILES: "0"},
+		{k8s.ANNOTATION_RECORD_FILE: path, k8s.ANNOTATION_RECORD_MAX_MEGABYTES: "lots"},
+	} {
+		_, err := NewRecorderFromAnnotations(annotations, "test", logf.Log)
+		g.Expect(err).ToNot(BeNil())
+	}
+}
+
+func TestReadExchanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+	exchanges, err := ReadExchanges(strings.NewReader(`{"method":"POST","path":"/a","request":{"x":1},"status":200}
+
+{"method":"POST","path":"/b","requestBase64":"/wA=","status":500}
+`))
+	g.Expect(err).To(BeNil())
+	g.Expect(exchanges).To(HaveLen(2))
+	g.Expect(string(exchanges[0].RequestBody())).To(Equal(`{"x":1}`))
+	g.Expect(exchanges[1].RequestBody()).To(Equal([]byte{0xff, 0x00}))
+
+	_, err = ReadExchanges(strings.NewReader("not json\n"))
+	g.Expect(err).ToNot(BeNil())
+}
@@ -0,0 +1,268 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Differences listed for each response
+const maxDifferences = 10
+
+// Headers describing the recorded connection rather than the request
+var skippedHeaders = []string{"Content-Length", "Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade"}
+
+// Replayer sends recorded requests to an executor and compares its responses to the recorded ones.
+type Replayer struct {
+	Target *url.URL
+	// Requests sent per second, or 0 for no limit
+	Rate        float64
+	Concurrency int
+	// Top level fields of JSON responses which aren't compared, such as meta
+	IgnoreFields []string
+	Client       *http.Client
+}
+
+// Diff describes how a replayed response differed from the recorded one.
+type Diff struct {
+	Puid        string
+	Path        string
+	Differences []string
+}
+
+// Report summarises a replay.
+type Report struct {
+	Requests int
+	// Requests which failed to get a response
+	Errors    int
+	Latencies []time.Duration
+	Diffs     []Diff
+}
+
+type result struct {
+	latency time.Duration
+	err     error
+	diff    *Diff
+}
+
+// Replay sends the exchanges' requests in order, Concurrency at a time.
+func (r *Replayer) Replay(ctx context.Context, exchanges []*Exchange) *Report {
+	var limiter *rate.Limiter
+	if r.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(r.Rate), 1)
+	}
+	concurrency := r.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan *Exchange)
+	results := make(chan result)
+	wg := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				results <- r.send(ctx, e)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, e := range exchanges {
+			if limiter != nil {
+				if err := limiter.Wait(ctx); err != nil {
+					return
+				}
+			}
+			select {
+			case jobs <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := &Report{}
+	for res := range results {
+		report.Requests++
+		if res.err != nil {
+			report.Errors++
+			continue
+		}
+		report.Latencies = append(report.Latencies, res.latency)
+		if res.diff != nil {
+			report.Diffs = append(report.Diffs, *res.diff)
+		}
+	}
+	sort.Slice(report.Latencies, func(i, j int) bool { return report.Latencies[i] < report.Latencies[j] })
+	return report
+}
+
+func (r *Replayer) send(ctx context.Context, e *Exchange) result {
+	u, err := r.Target.Parse(e.Path)
+	if err != nil {
+		return result{err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, e.Method, u.String(), bytes.NewReader(e.RequestBody()))
+	if err != nil {
+		return result{err: err}
+	}
+	for name, values := range e.Headers {
+		req.Header[name] = values
+	}
+	for _, name := range skippedHeaders {
+		req.Header.Del(name)
+	}
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return result{err: err}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	latency := time.Since(start)
+	if err != nil {
+		return result{err: err}
+	}
+
+	var differences []string
+	if res.StatusCode != e.Status {
+		differences = append(differences, fmt.Sprintf("status: %d != %d", res.StatusCode, e.Status))
+	}
+	differences = append(differences, r.compareBodies(e.ResponseBody(), body)...)
+	if len(differences) == 0 {
+		return result{latency: latency}
+	}
+	return result{latency: latency, diff: &Diff{Puid: e.Puid, Path: e.Path, Differences: differences}}
+}
+
+// compareBodies compares JSON bodies field by field, and other bodies byte for byte.
+func (r *Replayer) compareBodies(recorded []byte, replayed []byte) []string {
+	var recordedValue, replayedValue interface{}
+	if json.Unmarshal(recorded, &recordedValue) != nil || json.Unmarshal(replayed, &replayedValue) != nil {
+		if bytes.Equal(recorded, replayed) {
+			return nil
+		}
+		return []string{"body differs"}
+	}
+	if recordedMap, ok := recordedValue.(map[string]interface{}); ok {
+		if replayedMap, ok := replayedValue.(map[string]interface{}); ok {
+			for _, field := range r.IgnoreFields {
+				delete(recordedMap, field)
+				delete(replayedMap, field)
+			}
+		}
+	}
+	var differences []string
+	diffValues("", recordedValue, replayedValue, &differences)
+	return differences
+}
+
+// diffValues lists the paths at which two decoded JSON values differ.
+func diffValues(path string, recorded interface{}, replayed interface{}, differences *[]string) {
+	if len(*differences) >= maxDifferences {
+		return
+	}
+	switch rec := recorded.(type) {
+	case map[string]interface{}:
+		rep, ok := replayed.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(rec)+len(rep))
+		for k := range rec {
+			keys = append(keys, k)
+		}
+		for k := range rep {
+			if _, ok := rec[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			diffValues(path+"."+k, rec[k], rep[k], differences)
+		}
+		return
+	case []interface{}:
+		rep, ok := replayed.([]interface{})
+		if !ok || len(rep) != len(rec) {
+			break
+		}
+		for i := range rec {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), rec[i], rep[i], differences)
+		}
+		return
+	}
+	if !reflect.DeepEqual(recorded, replayed) {
+		if path == "" {
+			path = "."
+		}
+		*differences = append(*differences, fmt.Sprintf("%s: %s != %s", path, summarise(replayed), summarise(recorded)))
+	}
+}
+
+func summarise(v interface{}) string {
+	if v == nil {
+		return "missing"
+	}
+	b, _ := json.Marshal(v)
+	s := string(b)
+	if len(s) > 40 {
+		s = s[:37] + "..."
+	}
+	return s
+}
+
+// Percentile returns the latency below which the fraction p of successful requests completed.
+func (r *Report) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(r.Latencies))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(r.Latencies) {
+		i = len(r.Latencies) - 1
+	}
+	return r.Latencies[i]
+}
+
+// Write prints the report, listing up to maxDiffs responses which differed from the recording.
+func (r *Report) Write(w io.Writer, maxDiffs int) {
+	fmt.Fprintf(w, "Requests: %d\n", r.Requests)
+	fmt.Fprintf(w, "Errors: %d\n", r.Errors)
+	fmt.Fprintf(w, "Latency: p50 %s p90 %s p99 %s max %s\n", r.Percentile(0.5), r.Percentile(0.9), r.Percentile(0.99), r.Percentile(1))
+	fmt.Fprintf(w, "Responses differing from the recording (replayed != recorded): %d\n", len(r.Diffs))
+	for i, diff := range r.Diffs {
+		if i >= maxDiffs {
+			fmt.Fprintf(w, "... %d more\n", len(r.Diffs)-maxDiffs)
+			break
+		}
+		fmt.Fprintf(w, "  %s %s\n", diff.Puid, diff.Path)
+		fmt.Fprintf(w, "    %s\n", strings.Join(diff.Differences, "\n    "))
+	}
+}
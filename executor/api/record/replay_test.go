@@ -0,0 +1,93 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReplay(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, _ := ioutil.ReadAll(req.Body)
+		g.Expect(req.Header.Get("Seldon-Puid")).ToNot(BeEmpty())
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/same":
+			w.Write(body)
+		case "/different":
+			w.Write([]byte(`{"data":{"ndarray":[1,3]},"meta":{"puid":"new"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	exchanges := []*Exchange{
+		{Puid: "1", Method: "POST", Path: "/same", Headers: map[string][]string{"Seldon-Puid": {"1"}, "Content-Length": {"100"}}, Request: []byte(`{"a":1}`), Status: 200, Response: []byte(`{"a":1}`)},
+		{Puid: "2", Method: "POST", Path: "/different", Headers: map[string][]string{"Seldon-Puid": {"2"}}, Request: []byte(`{}`), Status: 200, Response: []byte(`{"data":{"ndarray":[1,2]},"meta":{"puid":"old"}}`)},
+		{Puid: "3", Method: "POST", Path: "/missing", Headers: map[string][]string{"Seldon-Puid": {"3"}}, Status: 200},
+	}
+	replayer := &Replayer{Target: target, Concurrency: 2, IgnoreFields: []string{"meta"}}
+	report := replayer.Replay(context.Background(), exchanges)
+	g.Expect(atomic.LoadInt32(&requests)).To(Equal(int32(3)))
+	g.Expect(report.Requests).To(Equal(3))
+	g.Expect(report.Errors).To(Equal(0))
+	g.Expect(report.Latencies).To(HaveLen(3))
+	g.Expect(report.Diffs).To(HaveLen(2))
+	for _, diff := range report.Diffs {
+		switch diff.Puid {
+		case "2":
+			g.Expect(diff.Differences).To(Equal([]string{".data.ndarray[1]: 3 != 2"}))
+		case "3":
+			g.Expect(diff.Differences).To(ContainElement("status: 404 != 200"))
+		default:
+			t.Errorf("unexpected diff %v", diff)
+		}
+	}
+
+	var out bytes.Buffer
+	report.Write(&out, 1)
+	g.Expect(out.String()).To(ContainSubstring("Requests: 3"))
+	g.Expect(out.String()).To(ContainSubstring("... 1 more"))
+}
+
+func TestReplayRate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+
+	exchanges := make([]*Exchange, 5)
+	for i := range exchanges {
+		exchanges[i] = &Exchange{Method: "GET", Path: "/", Status: 200}
+	}
+	start := time.Now()
+	report := (&Replayer{Target: target, Rate: 20, Concurrency: 5}).Replay(context.Background(), exchanges)
+	g.Expect(report.Requests).To(Equal(5))
+	// The first request is sent straight away and the rest 50ms apart
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+}
+
+func TestPercentile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	report := &Report{}
+	g.Expect(report.Percentile(0.5)).To(Equal(time.Duration(0)))
+	for i := 1; i <= 100; i++ {
+		report.Latencies = append(report.Latencies, time.Duration(i)*time.Millisecond)
+	}
+	g.Expect(report.Percentile(0.5)).To(Equal(50 * time.Millisecond))
+	g.Expect(report.Percentile(0.99)).To(Equal(99 * time.Millisecond))
+	g.Expect(report.Percentile(1)).To(Equal(100 * time.Millisecond))
+}
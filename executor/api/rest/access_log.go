@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+// WithAccessLog logs a line for each request handled.
+func WithAccessLog(enabled bool) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		r.accessLog = enabled
+	}
+}
+
+type accessLogKey struct{}
+
+// accessLogEntry holds the fields of an access log line only known inside the handler.
+type accessLogEntry struct {
+	traceID string
+}
+
+func accessLogEntryFrom(ctx context.Context) *accessLogEntry {
+	entry, _ := ctx.Value(accessLogKey{}).(*accessLogEntry)
+	return entry
+}
+
+// countingReader counts the bytes read from a request body.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// accessLogResponseWriter keeps the status and size of the response written.
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (w *accessLogResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// Hijack allows websocket connections to be upgraded through the writer.
+func (w *accessLogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Flush allows streamed responses, such as batch predictions, to be flushed through the writer.
+func (w *accessLogResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// logAccess logs the method, path, status, latency and sizes of requests with their PUID and trace id.
+func (r *SeldonRestApi) logAccess(service string, next http.HandlerFunc) http.HandlerFunc {
+	if !r.accessLog {
+		return next
+	}
+	log := r.Log.WithName("AccessLog")
+	return func(w http.ResponseWriter, req *http.Request) {
+		entry := &accessLogEntry{}
+		req = req.WithContext(context.WithValue(req.Context(), accessLogKey{}, entry))
+		body := &countingReader{ReadCloser: req.Body}
+		req.Body = body
+		rw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next(rw, req)
+		log.Info("Request",
+			"service", service,
+			"method", req.Method,
+			"path", req.URL.Path,
+			"status", rw.status,
+			"latencyMs", float64(time.Since(start))/float64(time.Millisecond),
+			"requestBytes", body.n,
+			"responseBytes", rw.n,
+			"model", mux.Vars(req)[ModelHttpPathVariable],
+			"puid", req.Header.Get(payload.SeldonPUIDHeader),
+			"traceId", entry.traceID,
+		)
+	}
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestAccessLog(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	var mutex sync.Mutex
+	var lines []string
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true, WithAccessLog(true))
+	r.Log = funcr.New(func(prefix, args string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{})
+	r.Initialise()
+
+	body := `{"data":{"ndarray":[1.1,2.0]}}`
+	req, _ := http.NewRequest("POST", "/api/v1.0/predictions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payload.SeldonPUIDHeader, "puid-1")
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusOK))
+
+	req, _ = http.NewRequest("GET", "/api/v1.0/metadata/model", nil)
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+
+	g.Expect(lines).To(HaveLen(2))
+	g.Expect(lines[0]).To(HavePrefix("AccessLog "))
+	g.Expect(lines[0]).To(ContainSubstring(`"method"="POST"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"path"="/api/v1.0/predictions"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"status"=200`))
+	g.Expect(lines[0]).To(ContainSubstring(`"requestBytes"=30`))
+	g.Expect(lines[0]).To(ContainSubstring(`"puid"="puid-1"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"latencyMs"=`))
+	g.Expect(lines[1]).To(ContainSubstring(`"method"="GET"`))
+	g.Expect(lines[1]).To(ContainSubstring(`"status"=`))
+	g.Expect(lines[1]).To(ContainSubstring(`"model"="model"`))
+	// A PUID is generated for requests without one
+	g.Expect(lines[1]).NotTo(ContainSubstring(`"puid"=""`))
+}
+
+func TestAccessLogDisabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	p := v1.PredictorSpec{Name: "p"}
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true)
+	var lines []string
+	r.Log = funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{})
+	r.Initialise()
+
+	req, _ := http.NewRequest("GET", "/api/v1.0/metadata/model", nil)
+	r.Router.ServeHTTP(httptest.NewRecorder(), req)
+	for _, line := range lines {
+		g.Expect(line).NotTo(HavePrefix("AccessLog"))
+	}
+}
+
+func TestAccessLogResponseWriterFlush(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	res := httptest.NewRecorder()
+	var w http.ResponseWriter = &accessLogResponseWriter{ResponseWriter: res, status: http.StatusOK}
+	flusher, ok := w.(http.Flusher)
+	g.Expect(ok).To(BeTrue())
+	flusher.Flush()
+	g.Expect(res.Flushed).To(BeTrue())
+}
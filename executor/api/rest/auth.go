@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+// Endpoints checked against the authorization rules for each service. Status and health checks
+// are not authenticated.
+var authEndpoints = map[string]string{
+	metric.PredictionHttpServiceName:          auth.EndpointPredict,
+	metric.PredictionBatchHttpServiceName:     auth.EndpointPredict,
+	metric.PredictionWebsocketHttpServiceName: auth.EndpointPredict,
+	metric.FeedbackHttpServiceName:            auth.EndpointFeedback,
+	metric.MetadataHttpServiceName:            auth.EndpointMetadata,
+}
+
+// WithAuthenticator requires requests to the prediction, feedback and metadata endpoints to have
+// a valid JWT bearer token.
+func WithAuthenticator(authenticator *auth.Authenticator) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		r.authenticator = authenticator
+	}
+}
+
+// authenticate checks the bearer token of requests to a service and passes its claims on in the
+// claims header, removing any claims header sent by the client itself.
+func (r *SeldonRestApi) authenticate(service string, next http.HandlerFunc) http.HandlerFunc {
+	endpoint, protected := authEndpoints[service]
+	return func(w http.ResponseWriter, req *http.Request) {
+		req.Header.Del(payload.SeldonJwtClaimsHeader)
+		if r.authenticator != nil && protected && req.Method != http.MethodOptions {
+			claims, err := r.authenticator.Check(req.Header.Get("Authorization"), endpoint)
+			if err != nil {
+				r.Log.V(1).Info("Request rejected", "service", service, "reason", err.Error())
+				var aerr *auth.AuthenticationError
+				if errors.As(err, &aerr) {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				r.respondWithError(w, nil, err)
+				return
+			}
+			encoded, err := auth.EncodeClaims(claims)
+			if err != nil {
+				r.respondWithError(w, nil, err)
+				return
+			}
+			req.Header.Set(payload.SeldonJwtClaimsHeader, encoded)
+		}
+		next(w, req)
+	}
+}
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func createTestAuthenticator(t *testing.T, rules string) (*auth.Authenticator, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := fmt.Sprintf(`{"keys":[{"kid":"test","kty":"RSA","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := ioutil.WriteFile(path, []byte(jwks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.NewKeySet(path, auth.DefaultJWKSRefreshInterval, logf.Log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedRules, err := auth.ParseRules(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth.NewAuthenticator(keys, "", nil, parsedRules), key
+}
+
+func createTestToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func TestJwtAuthentication(t *testing.T) {
+	authenticator, key := createTestAuthenticator(t, "predict:groups:ml")
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true, WithAuthenticator(authenticator))
+	r.Initialise()
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		code          int
+	}{
+		{"no token", "POST", "/api/v1.0/predictions", "", http.StatusUnauthorized},
+		{"invalid token", "POST", "/api/v1.0/predictions", "Bearer invalid", http.StatusUnauthorized},
+		{"missing group", "POST", "/api/v1.0/predictions", createTestToken(t, key, jwt.MapClaims{"groups": []string{"other"}}), http.StatusForbidden},
+		{"allowed", "POST", "/api/v1.0/predictions", createTestToken(t, key, jwt.MapClaims{"groups": []string{"ml"}}), http.StatusOK},
+		{"endpoint without rule", "POST", "/api/v1.0/feedback", createTestToken(t, key, jwt.MapClaims{"groups": []string{"ml"}}), http.StatusForbidden},
+		{"status", "GET", "/api/v1.0/status/model", "", http.StatusOK},
+		{"preflight", "OPTIONS", "/api/v1.0/predictions", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(`{"data":{"ndarray":[1.1,2.0]}}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			res := httptest.NewRecorder()
+			r.Router.ServeHTTP(res, req)
+			g.Expect(res.Code).To(Equal(tt.code))
+			if tt.code == http.StatusUnauthorized {
+				g.Expect(res.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+			}
+		})
+	}
+}
+
+func TestJwtClaimsHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	authenticator, key := createTestAuthenticator(t, "")
+
+	var claims string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		claims = req.Header.Get(payload.SeldonJwtClaimsHeader)
+	}
+	r := &SeldonRestApi{Log: logf.Log, Client: &test.SeldonMessageTestClient{}}
+
+	// Claims sent by clients are not trusted
+	req := httptest.NewRequest("POST", "http://example.com/api/v1.0/predictions", nil)
+	req.Header.Set(payload.SeldonJwtClaimsHeader, `{"sub":"admin"}`)
+	r.authenticate(metric.PredictionHttpServiceName, handler)(httptest.NewRecorder(), req)
+	g.Expect(claims).To(Equal(""))
+
+	r.authenticator = authenticator
+	req = httptest.NewRequest("POST", "http://example.com/api/v1.0/predictions", nil)
+	req.Header.Set("Authorization", createTestToken(t, key, jwt.MapClaims{"sub": "alice"}))
+	r.authenticate(metric.PredictionHttpServiceName, handler)(httptest.NewRecorder(), req)
+	g.Expect(claims).To(Equal(`{"sub":"alice"}`))
+}
@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	guuid "github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/predictor"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+
+	DefaultBatchConcurrency = 4
+	// Maximum size of the body of a batch request, which is read into memory before it is processed
+	DefaultBatchMaxBytes = 256 * 1024 * 1024
+	// Maximum size of a single line in a batch request
+	batchMaxLineBytes = 32 * 1024 * 1024
+)
+
+// BatchTooLargeError is returned for batch requests whose body is larger than the batch size limit.
+type BatchTooLargeError struct {
+	MaxBytes int64
+}
+
+func (e *BatchTooLargeError) Error() string {
+	return fmt.Sprintf("batch request body is larger than %d bytes", e.MaxBytes)
+}
+
+// WithBatchConcurrency sets how many lines of a batch request are sent through the graph at once.
+func WithBatchConcurrency(concurrency int) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		if concurrency > 0 {
+			r.batchConcurrency = concurrency
+		}
+	}
+}
+
+// WithBatchMaxBytes sets the maximum size of the body of a batch request.
+func WithBatchMaxBytes(maxBytes int64) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		if maxBytes > 0 {
+			r.batchMaxBytes = maxBytes
+		}
+	}
+}
+
+// limitedBody fails with a BatchTooLargeError once more than maxBytes have been read.
+type limitedBody struct {
+	body      io.Reader
+	maxBytes  int64
+	remaining int64
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &BatchTooLargeError{MaxBytes: l.maxBytes}
+	}
+	n, err := l.body.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, &BatchTooLargeError{MaxBytes: l.maxBytes}
+	}
+	return n, err
+}
+
+// readBatchLines splits a newline-delimited JSON body of at most maxBytes into its non-empty lines.
+// The whole body is read before any response is written as HTTP/1.x does not
+// allow further reads of the request body once the response has started.
+func readBatchLines(req *http.Request, maxBytes int64) ([][]byte, error) {
+	scanner := bufio.NewScanner(&limitedBody{body: req.Body, maxBytes: maxBytes, remaining: maxBytes})
+	scanner.Buffer(make([]byte, 64*1024), batchMaxLineBytes)
+	var lines [][]byte
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		lines = append(lines, append([]byte(nil), line...))
+	}
+	return lines, scanner.Err()
+}
+
+// predictWithNewPuid runs a single request through the graph under a fresh PUID so that
+// requests sharing one HTTP call can be told apart in the logs and downstream.
+func (r *SeldonRestApi) predictWithNewPuid(ctx context.Context, header http.Header, modelName string, body []byte) (string, payload.SeldonPayload, error) {
+	puid := guuid.New().String()
+	reqHeader := header.Clone()
+	reqHeader.Set(payload.SeldonPUIDHeader, puid)
+	ctx = context.WithValue(ctx, payload.SeldonPUIDHeader, puid)
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, reqHeader, modelName)
+
+	reqPayload, err := seldonPredictorProcess.Client.Unmarshall(body, ContentTypeJSON)
+	if err != nil {
+		return puid, nil, err
+	}
+	resPayload, err := seldonPredictorProcess.Predict(&predictor.ActiveSpec(r.predictor).Graph, reqPayload)
+	return puid, resPayload, err
+}
+
+// predictBatchLine runs a single line of a batch through the graph and returns the compacted
+// JSON response. Failures are turned into the protocol's error payload.
+func (r *SeldonRestApi) predictBatchLine(ctx context.Context, header http.Header, modelName string, line []byte) []byte {
+	puid, resPayload, err := r.predictWithNewPuid(ctx, header, modelName, line)
+	if err != nil {
+		r.Log.Error(err, "Batch line failed", "puid", puid)
+		if resPayload == nil || resPayload.GetPayload() == nil {
+			resPayload = r.Client.CreateErrorPayload(err)
+		}
+	}
+
+	out, err := compactJSONPayload(resPayload)
+	if err != nil {
+		out, _ = compactJSONPayload(r.Client.CreateErrorPayload(err))
+	}
+	return out
+}
+
+func compactJSONPayload(msg payload.SeldonPayload) ([]byte, error) {
+	data, err := payload.DecompressSeldonPayload(msg)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *SeldonRestApi) predictionsBatch(w http.ResponseWriter, req *http.Request) {
+	r.Log.V(1).Info("Batch predictions called")
+
+	ctx := req.Context()
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		var serverSpan opentracing.Span
+		ctx, serverSpan = setupTracing(ctx, req, TracingPredictionsBatchName)
+		defer serverSpan.Finish()
+	}
+
+	lines, err := readBatchLines(req, r.batchMaxBytes)
+	if err != nil {
+		r.respondWithError(w, nil, err)
+		return
+	}
+
+	vars := mux.Vars(req)
+	modelName := vars[ModelHttpPathVariable]
+
+	// Each line gets a slot which is filled in when its prediction finishes so
+	// responses can be streamed back in input order.
+	results := make([]chan []byte, len(lines))
+	for i := range results {
+		results[i] = make(chan []byte, 1)
+	}
+
+	go func() {
+		sem := make(chan struct{}, r.batchConcurrency)
+		wg := sync.WaitGroup{}
+		for i, line := range lines {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(i int, line []byte) {
+				defer wg.Done()
+				results[i] <- r.predictBatchLine(ctx, req.Header, modelName, line)
+				<-sem
+			}(i, line)
+		}
+		wg.Wait()
+	}()
+
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	for _, result := range results {
+		if _, err := w.Write(append(<-result, '\n')); err != nil {
+			r.Log.Error(err, "Failed to write batch response")
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
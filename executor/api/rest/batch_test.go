@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func createBatchTestServer(g *WithT, protocol string, options ...ServerRestApiOption) (*SeldonRestApi, func()) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).To(BeNil())
+		if strings.Contains(string(body), "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"status":{"code":400,"info":"bad line","status":"FAILURE"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		fmt.Fprintf(w, `{"meta":{"puid":"%s"},"data":%s}`, r.Header.Get(payload.SeldonPUIDHeader), body)
+	})
+	server := httptest.NewServer(handler)
+	serverUrl, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	urlParts := strings.Split(serverUrl.Host, ":")
+	port, err := strconv.Atoi(urlParts[1])
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: urlParts[0],
+				ServicePort: int32(port),
+				Type:        v1.REST,
+				HttpPort:    int32(port),
+			},
+		},
+	}
+	client, err := NewJSONRestClient(protocol, "dep", &p, nil)
+	g.Expect(err).Should(BeNil())
+	r := NewServerRestApi(&p, client, false, serverUrl, "default", protocol, "test", "/metrics", true, append([]ServerRestApiOption{WithBatchConcurrency(2)}, options...)...)
+	r.Initialise()
+	return r, server.Close
+}
+
+func TestPredictionsBatch(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	r, closer := createBatchTestServer(g, api.ProtocolSeldon)
+	defer closer()
+
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"data":{"ndarray":[%d]}}`, i))
+	}
+	lines[3] = `{"data":{"strData":"fail"}}`
+	data := strings.Join(lines, "\n") + "\n\n"
+
+	req, _ := http.NewRequest("POST", "/api/v1.0/predictions:batch", strings.NewReader(data))
+	req.Header = map[string][]string{"Content-Type": []string{"application/x-ndjson"}}
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+	g.Expect(res.Header().Get("Content-Type")).To(Equal(ContentTypeNDJSON))
+
+	puids := map[string]bool{}
+	scanner := bufio.NewScanner(res.Body)
+	i := 0
+	for ; scanner.Scan(); i++ {
+		resp := map[string]interface{}{}
+		err := json.Unmarshal(scanner.Bytes(), &resp)
+		g.Expect(err).To(BeNil())
+		if i == 3 {
+			g.Expect(resp["status"].(map[string]interface{})["info"]).To(Equal("bad line"))
+			continue
+		}
+		puid := resp["meta"].(map[string]interface{})["puid"].(string)
+		g.Expect(puid).ToNot(BeEmpty())
+		g.Expect(puids).ToNot(HaveKey(puid))
+		puids[puid] = true
+		ndarray := resp["data"].(map[string]interface{})["data"].(map[string]interface{})["ndarray"].([]interface{})
+		g.Expect(ndarray[0]).To(Equal(float64(i)))
+	}
+	g.Expect(i).To(Equal(10))
+}
+
+func TestPredictionsBatchV2(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	r, closer := createBatchTestServer(g, api.ProtocolV2)
+	defer closer()
+
+	data := `{"inputs":[{"name":"a","shape":[1],"datatype":"INT32","data":[1]}]}
+{"inputs":[{"name":"a","shape":[1],"datatype":"INT32","data":[2]}]}`
+
+	req, _ := http.NewRequest("POST", "/v2/models/model/infer:batch", strings.NewReader(data))
+	req.Header = map[string][]string{"Content-Type": []string{"application/x-ndjson"}}
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+	lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+	g.Expect(lines).To(HaveLen(2))
+	g.Expect(lines[0]).To(ContainSubstring(`"data":[1]`))
+	g.Expect(lines[1]).To(ContainSubstring(`"data":[2]`))
+}
+
+func TestPredictionsBatchTooLarge(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	line := `{"data":{"ndarray":[1]}}` + "\n"
+	r, closer := createBatchTestServer(g, api.ProtocolSeldon, WithBatchMaxBytes(int64(len(line)*2)))
+	defer closer()
+
+	req, _ := http.NewRequest("POST", "/api/v1.0/predictions:batch", strings.NewReader(strings.Repeat(line, 2)))
+	req.Header = map[string][]string{"Content-Type": []string{"application/x-ndjson"}}
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+
+	req, _ = http.NewRequest("POST", "/api/v1.0/predictions:batch", strings.NewReader(strings.Repeat(line, 3)))
+	req.Header = map[string][]string{"Content-Type": []string{"application/x-ndjson"}}
+	res = httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(http.StatusRequestEntityTooLarge))
+}
@@ -21,12 +21,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/cert"
 	"github.com/seldonio/seldon-core/executor/api/client"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/util"
 	"github.com/seldonio/seldon-core/executor/k8s"
+	"github.com/seldonio/seldon-core/executor/predictor"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -44,6 +46,14 @@ type JSONRestClient struct {
 	DeploymentName string
 	predictor      *v1.PredictorSpec
 	metrics        *metric.ClientMetrics
+	transport      http.RoundTripper
+	// Transports for graph nodes whose endpoints use TLS or unix sockets
+	nodeTransports map[string]http.RoundTripper
+	tlsNodes       map[string]bool
+	// Transport for graph nodes called with h2c, if any are
+	h2cTransport     http.RoundTripper
+	transportOptions TransportOptions
+	transportMetrics *transportMetrics
 }
 
 func (smc *JSONRestClient) IsGrpc() bool {
@@ -53,7 +63,7 @@ func (smc *JSONRestClient) IsGrpc() bool {
 func (smc *JSONRestClient) CreateErrorPayload(err error) payload.SeldonPayload {
 	respFailed := proto.SeldonMessage{
 		Status: &proto.Status{
-			Code:   http.StatusInternalServerError,
+			Code:   int32(errorStatusCode(err)),
 			Info:   err.Error(),
 			Status: proto.Status_FAILURE,
 		},
@@ -124,13 +134,49 @@ func NewJSONRestClient(protocol string, deploymentName string, predictor *v1.Pre
 		}
 	}
 
+	transportOptions, err := TransportOptionsFromAnnotations(annotations)
+	if err != nil {
+		return nil, err
+	}
+	transportMetrics := newTransportMetrics(deploymentName)
+
 	client := JSONRestClient{
-		httpClient,
-		logf.Log.WithName("JSONRestClient"),
-		protocol,
-		deploymentName,
-		predictor,
-		metric.NewClientMetrics(predictor, deploymentName, ""),
+		httpClient:       httpClient,
+		Log:              logf.Log.WithName("JSONRestClient"),
+		Protocol:         protocol,
+		DeploymentName:   deploymentName,
+		predictor:        predictor,
+		metrics:          metric.NewClientMetrics(predictor, deploymentName, ""),
+		transport:        transportOptions.newTransport(nil, "", transportMetrics),
+		nodeTransports:   map[string]http.RoundTripper{},
+		tlsNodes:         map[string]bool{},
+		transportOptions: transportOptions,
+		transportMetrics: transportMetrics,
+	}
+	if len(transportOptions.H2CNodes) > 0 {
+		client.h2cTransport = transportOptions.newH2CTransport("", transportMetrics)
+	}
+	if predictor != nil {
+		for _, pu := range v1.GetPredictiveUnitList(&predictor.Graph) {
+			unixSocket := pu.Endpoint.GetUnixSocketPath()
+			if pu.Endpoint == nil || (pu.Endpoint.TLS == nil && unixSocket == "") {
+				continue
+			}
+			if pu.Endpoint.TLS == nil {
+				if transportOptions.UseH2C(pu.Name) {
+					client.nodeTransports[pu.Name] = transportOptions.newH2CTransport(unixSocket, transportMetrics)
+				} else {
+					client.nodeTransports[pu.Name] = transportOptions.newTransport(nil, unixSocket, transportMetrics)
+				}
+				continue
+			}
+			tlsConfig, err := cert.NewClientTLSConfig(pu.Endpoint.TLS, client.Log)
+			if err != nil {
+				return nil, err
+			}
+			client.nodeTransports[pu.Name] = transportOptions.newTransport(tlsConfig, unixSocket, transportMetrics)
+			client.tlsNodes[pu.Name] = true
+		}
 	}
 	for i := range options {
 		options[i](&client)
@@ -139,7 +185,22 @@ func NewJSONRestClient(protocol string, deploymentName string, predictor *v1.Pre
 	return &client, nil
 }
 
-func (smc *JSONRestClient) getMetricsRoundTripper(modelName string, service string) http.RoundTripper {
+// getTransport returns the transport for calls to a graph node, which is set up for the node's TLS settings or
+// unix socket if it has them, or uses h2c if the node is called with it.
+func (smc *JSONRestClient) getTransport(nodeName string) http.RoundTripper {
+	if transport, ok := smc.nodeTransports[nodeName]; ok {
+		return transport
+	}
+	if smc.h2cTransport != nil && smc.transportOptions.UseH2C(nodeName) {
+		return smc.h2cTransport
+	}
+	if smc.transport != nil {
+		return smc.transport
+	}
+	return http.DefaultTransport
+}
+
+func (smc *JSONRestClient) getMetricsRoundTripper(modelName string, nodeName string, service string) http.RoundTripper {
 	container := v1.GetContainerForPredictiveUnit(smc.predictor, modelName)
 	imageName := ""
 	imageVersion := ""
@@ -158,7 +219,7 @@ func (smc *JSONRestClient) getMetricsRoundTripper(modelName string, service stri
 		metric.ModelNameMetric:        modelName,
 		metric.ModelImageMetric:       imageName,
 		metric.ModelVersionMetric:     imageVersion,
-	}), http.DefaultTransport)
+	}), smc.getTransport(nodeName))
 
 	return promhttp.InstrumentRoundTripperDuration(smc.metrics.ClientHandledSummary.MustCurryWith(prometheus.Labels{
 		metric.DeploymentNameMetric:   smc.DeploymentName,
@@ -204,6 +265,9 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 
 	// Add metadata passed in
 	smc.addHeaders(req, meta)
+	if smc.transportMetrics != nil {
+		req = smc.transportMetrics.trace(req, modelName)
+	}
 
 	if opentracing.IsGlobalTracerRegistered() {
 		tracer := opentracing.GlobalTracer()
@@ -220,8 +284,10 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 		tracer.Inject(clientSpan.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
 	}
 
-	client := smc.httpClient
-	client.Transport = smc.getMetricsRoundTripper(modelName, method)
+	// Copy the client so the per-call metrics transport isn't shared between concurrent requests
+	transport := smc.getMetricsRoundTripper(modelName, client.NodeName(ctx, modelName), method)
+	client := *smc.httpClient
+	client.Transport = transport
 
 	response, err := client.Do(req)
 	if err != nil {
@@ -246,8 +312,19 @@ func (smc *JSONRestClient) doHttp(ctx context.Context, modelName string, method
 	return b, contentTypeResponse, contentEncodingResponse, err
 }
 
-func (smc *JSONRestClient) modifyMethod(method string, modelName string) string {
-	switch smc.Protocol {
+// getNodeProtocol returns the protocol spoken by the graph node a call is made to. Nodes without their own
+// protocol, or which can't be found, use the protocol of the deployment.
+func (smc *JSONRestClient) getNodeProtocol(ctx context.Context, modelName string) string {
+	if smc.predictor != nil {
+		if pu := v1.GetPredictiveUnit(&predictor.ActiveSpec(smc.predictor).Graph, client.NodeName(ctx, modelName)); pu != nil && pu.Protocol != "" {
+			return string(pu.Protocol)
+		}
+	}
+	return smc.Protocol
+}
+
+func (smc *JSONRestClient) modifyMethod(ctx context.Context, method string, modelName string) string {
+	switch smc.getNodeProtocol(ctx, modelName) {
 	case api.ProtocolTensorflow:
 		switch method {
 		case client.SeldonPredictPath, client.SeldonTransformInputPath, client.SeldonTransformOutputPath:
@@ -285,8 +362,12 @@ func (smc *JSONRestClient) modifyMethod(method string, modelName string) string
 }
 
 func (smc *JSONRestClient) call(ctx context.Context, modelName string, method string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	scheme := "http"
+	if smc.tlsNodes[client.NodeName(ctx, modelName)] {
+		scheme = "https"
+	}
 	url := url.URL{
-		Scheme: "http",
+		Scheme: scheme,
 		Host:   net.JoinHostPort(host, strconv.Itoa(int(port))),
 		Path:   method,
 	}
@@ -313,12 +394,12 @@ func (smc *JSONRestClient) call(ctx context.Context, modelName string, method st
 }
 
 func (smc *JSONRestClient) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonStatusPath, modelName), host, port, msg, meta)
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonStatusPath, modelName), host, port, msg, meta)
 }
 
 // Return model's metadata as payload.SeldonPaylaod (to expose as received on corresponding executor endpoint)
 func (smc *JSONRestClient) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonMetadataPath, modelName), host, port, msg, meta)
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonMetadataPath, modelName), host, port, msg, meta)
 }
 
 // Return model's metadata decoded to payload.ModelMetadata (to build GraphMetadata)
@@ -340,6 +421,24 @@ func (smc *JSONRestClient) ModelMetadata(ctx context.Context, modelName string,
 	return modelMetadata, nil
 }
 
+// callConverted calls a node which may speak a different protocol to the deployment, converting the
+// request to the node's protocol and the response back to the given protocol.
+func (smc *JSONRestClient) callConverted(ctx context.Context, modelName string, method string, host string, port int32, req payload.SeldonPayload, meta map[string][]string, responseProtocol string) (payload.SeldonPayload, error) {
+	nodeProtocol := smc.getNodeProtocol(ctx, modelName)
+	if api.IsSameProtocol(nodeProtocol, smc.Protocol) && api.IsSameProtocol(nodeProtocol, responseProtocol) {
+		return smc.call(ctx, modelName, smc.modifyMethod(ctx, method, modelName), host, port, req, meta)
+	}
+	req, err := ConvertPayload(req, smc.Protocol, nodeProtocol, false)
+	if err != nil {
+		return nil, err
+	}
+	res, err := smc.call(ctx, modelName, smc.modifyMethod(ctx, method, modelName), host, port, req, meta)
+	if err != nil {
+		return res, err
+	}
+	return ConvertPayload(res, nodeProtocol, responseProtocol, true)
+}
+
 func (smc *JSONRestClient) Chain(ctx context.Context, modelName string, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
 	switch smc.Protocol {
 	case api.ProtocolSeldon: // Seldon Messages can always be chained together
@@ -353,16 +452,21 @@ func (smc *JSONRestClient) Chain(ctx context.Context, modelName string, msg payl
 }
 
 func (smc *JSONRestClient) Predict(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonPredictPath, modelName), host, port, req, meta)
+	return smc.callConverted(ctx, modelName, client.SeldonPredictPath, host, port, req, meta, smc.Protocol)
 }
 
 func (smc *JSONRestClient) TransformInput(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonTransformInputPath, modelName), host, port, req, meta)
+	return smc.callConverted(ctx, modelName, client.SeldonTransformInputPath, host, port, req, meta, smc.Protocol)
 }
 
 // Try to extract from SeldonMessage otherwise fall back to extract from Json Array
 func (smc *JSONRestClient) Route(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (int, error) {
-	sp, err := smc.call(ctx, modelName, smc.modifyMethod(client.SeldonRoutePath, modelName), host, port, req, meta)
+	// Responses from routers speaking a different protocol to the deployment are converted to a SeldonMessage
+	responseProtocol := smc.Protocol
+	if !api.IsSameProtocol(smc.getNodeProtocol(ctx, modelName), smc.Protocol) {
+		responseProtocol = api.ProtocolSeldon
+	}
+	sp, err := smc.callConverted(ctx, modelName, client.SeldonRoutePath, host, port, req, meta, responseProtocol)
 	if err != nil {
 		return 0, err
 	} else {
@@ -371,21 +475,36 @@ func (smc *JSONRestClient) Route(ctx context.Context, modelName string, host str
 }
 
 func (smc *JSONRestClient) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	nodeProtocol := smc.getNodeProtocol(ctx, modelName)
+	if !api.IsSameProtocol(nodeProtocol, smc.Protocol) {
+		converted := make([]payload.SeldonPayload, len(msgs))
+		for i, msg := range msgs {
+			var err error
+			if converted[i], err = ConvertPayload(msg, smc.Protocol, nodeProtocol, true); err != nil {
+				return nil, err
+			}
+		}
+		msgs = converted
+	}
 	req, err := CombineSeldonMessagesToJson(msgs)
 	if err != nil {
 		return nil, err
 	}
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonCombinePath, modelName), host, port, req, meta)
+	res, err := smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonCombinePath, modelName), host, port, req, meta)
+	if err != nil {
+		return res, err
+	}
+	return ConvertPayload(res, nodeProtocol, smc.Protocol, true)
 }
 
 func (smc *JSONRestClient) TransformOutput(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonTransformOutputPath, modelName), host, port, req, meta)
+	return smc.callConverted(ctx, modelName, client.SeldonTransformOutputPath, host, port, req, meta, smc.Protocol)
 }
 
 func (smc *JSONRestClient) Feedback(ctx context.Context, modelName string, host string, port int32, req payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
 	// Currently feedback is enabled across all protocols but client only works on seldon protocol
-	if smc.Protocol != api.ProtocolSeldon {
+	if smc.getNodeProtocol(ctx, modelName) != api.ProtocolSeldon {
 		return req, nil
 	}
-	return smc.call(ctx, modelName, smc.modifyMethod(client.SeldonFeedbackPath, modelName), host, port, req, meta)
+	return smc.call(ctx, modelName, smc.modifyMethod(ctx, client.SeldonFeedbackPath, modelName), host, port, req, meta)
 }
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -381,3 +382,113 @@ func TestMarshall(t *testing.T) {
 		g.Expect(w.String()).To(Equal(test.expected))
 	}
 }
+
+func TestCrossProtocolPredict(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/v2/models/model/infer"))
+		body := map[string]interface{}{}
+		g.Expect(json.NewDecoder(r.Body).Decode(&body)).To(BeNil())
+		g.Expect(body["inputs"]).To(HaveLen(1))
+		w.Write([]byte(`{"outputs":[{"name":"output-0","datatype":"FP32","shape":[1,2],"data":[0.9,0.1]}]}`))
+	})
+	host, port, httpClient, teardown := testingHTTPClient(g, h)
+	defer teardown()
+	model := v1.MODEL
+	predictor := v1.PredictorSpec{
+		Name:        "test",
+		Annotations: map[string]string{},
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Type:     &model,
+			Protocol: v1.ProtocolV2,
+		},
+	}
+	seldonRestClient, err := NewJSONRestClient(api.ProtocolSeldon, "test", &predictor, nil, SetHTTPClient(httpClient))
+	g.Expect(err).To(BeNil())
+
+	resPayload, err := seldonRestClient.Predict(createTestContext(), "model", host, int32(port), createPayload(g), map[string][]string{})
+	g.Expect(err).Should(BeNil())
+
+	var smRes proto.SeldonMessage
+	err = jsonpb.UnmarshalString(string(resPayload.GetPayload().([]byte)), &smRes)
+	g.Expect(err).Should(BeNil())
+	g.Expect(smRes.GetData().GetNdarray().Values[0].GetListValue().Values[0].GetNumberValue()).Should(Equal(0.9))
+
+	// Payloads which can't be converted are rejected before calling the model
+	_, err = seldonRestClient.Predict(createTestContext(), "model", host, int32(port), &payload.BytesPayload{Msg: []byte(`{"binData":"AAA="}`)}, map[string][]string{})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(errorStatusCode(err)).To(Equal(http.StatusBadRequest))
+}
+
+func TestEndpointTLS(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(okPredictResponse))
+	}))
+	defer server.Close()
+	serverUrl, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	port, err := strconv.Atoi(serverUrl.Port())
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	predictor := v1.PredictorSpec{
+		Name:        "test",
+		Annotations: map[string]string{},
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				TLS: &v1.EndpointTLS{InsecureSkipVerify: true},
+			},
+		},
+	}
+	seldonRestClient, err := NewJSONRestClient(api.ProtocolSeldon, "test", &predictor, nil)
+	g.Expect(err).To(BeNil())
+
+	resPayload, err := seldonRestClient.Predict(createTestContext(), "model", serverUrl.Hostname(), int32(port), createPayload(g), map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(string(resPayload.GetPayload().([]byte))).To(Equal(okPredictResponse))
+
+	// Nodes without TLS use plain http
+	_, err = seldonRestClient.Predict(createTestContext(), "other", serverUrl.Hostname(), int32(port), createPayload(g), map[string][]string{})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestEndpointUnixSocket(t *testing.T) {
+	g := NewGomegaWithT(t)
+	socket := filepath.Join(t.TempDir(), "model.sock")
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).Should(BeNil())
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(okPredictResponse))
+	})}
+	go server.Serve(lis)
+	defer server.Close()
+
+	model := v1.MODEL
+	predictor := v1.PredictorSpec{
+		Name:        "test",
+		Annotations: map[string]string{},
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				UnixSocket: socket,
+			},
+		},
+	}
+	seldonRestClient, err := NewJSONRestClient(api.ProtocolSeldon, "test", &predictor, nil)
+	g.Expect(err).To(BeNil())
+
+	// The host and port are ignored for nodes with a unix socket
+	resPayload, err := seldonRestClient.Predict(createTestContext(), "model", "localhost", 1, createPayload(g), map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(string(resPayload.GetPayload().([]byte))).To(Equal(okPredictResponse))
+
+	_, err = seldonRestClient.Predict(createTestContext(), "other", "localhost", 1, createPayload(g), map[string][]string{})
+	g.Expect(err).ToNot(BeNil())
+}
@@ -1,9 +1,10 @@
 package rest
 
 const (
-	TracingPredictionsName = "predictions"
-	TracingStatusName      = "status"
-	TracingMetadataName    = "metadata"
+	TracingPredictionsName      = "predictions"
+	TracingPredictionsBatchName = "predictions-batch"
+	TracingStatusName           = "status"
+	TracingMetadataName         = "metadata"
 
 	LoggingRestClientName = "RestClient"
 )
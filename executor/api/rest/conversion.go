@@ -0,0 +1,526 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+const (
+	conversionJsonDataName = "jsonData"
+	conversionStrDataName  = "strData"
+
+	datatypeBool  = "BOOL"
+	datatypeInt64 = "INT64"
+	datatypeFP64  = "FP64"
+	datatypeBytes = "BYTES"
+)
+
+// ConversionError is returned when a payload can not be converted between the protocols
+// spoken by two nodes of the inference graph.
+type ConversionError struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("can not convert payload from %s to %s protocol: %s", e.From, e.To, e.Reason)
+}
+
+// conversionTensor is the protocol independent representation of a tensor. Data is flattened
+// in row-major order.
+type conversionTensor struct {
+	name     string
+	datatype string
+	shape    []int
+	data     []interface{}
+}
+
+type conversionMessage struct {
+	id      string
+	tensors []conversionTensor
+}
+
+// ConvertPayload converts a JSON payload between the seldon, tensorflow and v2 protocols. Requests
+// and responses are rendered differently for tensorflow and v2, e.g. v2 "inputs" and "outputs".
+// Payloads which are already in the target protocol are returned unchanged.
+func ConvertPayload(msg payload.SeldonPayload, from string, to string, isResponse bool) (payload.SeldonPayload, error) {
+	if api.IsSameProtocol(from, to) {
+		return msg, nil
+	}
+	data, err := payload.DecompressSeldonPayload(msg)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return nil, &ConversionError{From: from, To: to, Reason: "payload is not a JSON object"}
+	}
+
+	defaultName := "input-0"
+	if isResponse {
+		defaultName = "output-0"
+	}
+
+	var cm *conversionMessage
+	switch from {
+	case api.ProtocolSeldon:
+		cm, err = parseSeldonMessage(body, defaultName)
+	case api.ProtocolTensorflow:
+		cm, err = parseTensorflowMessage(body, defaultName)
+	case api.ProtocolV2, api.ProtocolKFServing:
+		cm, err = parseV2Message(body)
+	default:
+		err = fmt.Errorf("unknown protocol %s", from)
+	}
+	if err != nil {
+		return nil, &ConversionError{From: from, To: to, Reason: err.Error()}
+	}
+
+	var out map[string]interface{}
+	switch to {
+	case api.ProtocolSeldon:
+		out, err = renderSeldonMessage(cm)
+	case api.ProtocolTensorflow:
+		out, err = renderTensorflowMessage(cm, isResponse)
+	case api.ProtocolV2, api.ProtocolKFServing:
+		out, err = renderV2Message(cm, isResponse)
+	default:
+		err = fmt.Errorf("unknown protocol %s", to)
+	}
+	if err != nil {
+		return nil, &ConversionError{From: from, To: to, Reason: err.Error()}
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.BytesPayload{Msg: b, ContentType: ContentTypeJSON}, nil
+}
+
+// Seldon messages hold a single ndarray or tensor. When the names match the columns of a 2-d
+// array each column becomes a tensor of shape [N, 1], otherwise the whole array is one tensor.
+// jsonData and strData become a single BYTES tensor named after the field.
+func parseSeldonMessage(body map[string]interface{}, defaultName string) (*conversionMessage, error) {
+	cm := &conversionMessage{}
+	if meta, ok := body["meta"].(map[string]interface{}); ok {
+		if puid, ok := meta["puid"].(string); ok {
+			cm.id = puid
+		}
+	}
+
+	if jsonData, ok := body["jsonData"]; ok {
+		b, err := json.Marshal(jsonData)
+		if err != nil {
+			return nil, err
+		}
+		cm.tensors = []conversionTensor{{name: conversionJsonDataName, datatype: datatypeBytes, shape: []int{1}, data: []interface{}{string(b)}}}
+		return cm, nil
+	}
+	if strData, ok := body["strData"].(string); ok {
+		cm.tensors = []conversionTensor{{name: conversionStrDataName, datatype: datatypeBytes, shape: []int{1}, data: []interface{}{strData}}}
+		return cm, nil
+	}
+	if _, ok := body["binData"]; ok {
+		return nil, fmt.Errorf("binData has no equivalent in other protocols")
+	}
+
+	data, ok := body["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected one of data, jsonData or strData")
+	}
+	var names []string
+	if rawNames, ok := data["names"].([]interface{}); ok {
+		for _, n := range rawNames {
+			name, ok := n.(string)
+			if !ok {
+				return nil, fmt.Errorf("names must be strings")
+			}
+			names = append(names, name)
+		}
+	}
+
+	var shape []int
+	var values []interface{}
+	var err error
+	datatype := ""
+	if ndarray, ok := data["ndarray"]; ok {
+		shape, values, err = flattenArray(ndarray)
+		if err != nil {
+			return nil, err
+		}
+	} else if tensor, ok := data["tensor"].(map[string]interface{}); ok {
+		_, shape, err = parseShape(tensor["shape"])
+		if err != nil {
+			return nil, err
+		}
+		_, values, err = flattenArray(tensor["values"])
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != shapeSize(shape) {
+			return nil, fmt.Errorf("tensor has %d values but shape %v", len(values), shape)
+		}
+		datatype = datatypeFP64
+	} else {
+		return nil, fmt.Errorf("data must contain an ndarray or tensor")
+	}
+
+	if len(shape) == 2 && len(names) > 0 && len(names) == shape[1] {
+		for col, name := range names {
+			t := conversionTensor{name: name, datatype: datatype, shape: []int{shape[0], 1}}
+			for row := 0; row < shape[0]; row++ {
+				t.data = append(t.data, values[row*shape[1]+col])
+			}
+			if t.datatype == "" {
+				if t.datatype, err = inferDatatype(t.data); err != nil {
+					return nil, fmt.Errorf("column %s: %v", name, err)
+				}
+			}
+			cm.tensors = append(cm.tensors, t)
+		}
+		return cm, nil
+	}
+
+	t := conversionTensor{name: defaultName, datatype: datatype, shape: shape, data: values}
+	if t.datatype == "" {
+		if t.datatype, err = inferDatatype(values); err != nil {
+			return nil, err
+		}
+	}
+	cm.tensors = []conversionTensor{t}
+	return cm, nil
+}
+
+func renderSeldonMessage(cm *conversionMessage) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	if cm.id != "" {
+		out["meta"] = map[string]interface{}{"puid": cm.id}
+	}
+	if len(cm.tensors) == 0 {
+		return nil, fmt.Errorf("no tensors to convert")
+	}
+
+	if len(cm.tensors) == 1 {
+		t := cm.tensors[0]
+		if t.datatype == datatypeBytes && len(t.data) == 1 {
+			switch t.name {
+			case conversionJsonDataName:
+				s, _ := t.data[0].(string)
+				decoder := json.NewDecoder(strings.NewReader(s))
+				decoder.UseNumber()
+				var jsonData interface{}
+				if err := decoder.Decode(&jsonData); err != nil {
+					return nil, fmt.Errorf("jsonData tensor is not valid JSON")
+				}
+				out["jsonData"] = jsonData
+				return out, nil
+			case conversionStrDataName:
+				out["strData"] = t.data[0]
+				return out, nil
+			}
+		}
+		// Unnamed tensors or tensors which are not columns keep their shape
+		if strings.HasPrefix(t.name, "input-") || strings.HasPrefix(t.name, "output-") || !isColumn(t) {
+			out["data"] = map[string]interface{}{"ndarray": nestArray(t.data, t.shape)}
+			return out, nil
+		}
+	}
+
+	// Merge columns of equal length into a 2-d array with names
+	for _, t := range cm.tensors {
+		if !isColumn(t) {
+			return nil, fmt.Errorf("tensor %s with shape %v is not a column to be merged into an ndarray", t.name, t.shape)
+		}
+	}
+	rows := cm.tensors[0].shape[0]
+	names := make([]string, len(cm.tensors))
+	for i, t := range cm.tensors {
+		if t.shape[0] != rows {
+			return nil, fmt.Errorf("tensors must all have shape [%d] or [%d, 1] to be merged into an ndarray", rows, rows)
+		}
+		names[i] = t.name
+	}
+	values := make([]interface{}, 0, rows*len(cm.tensors))
+	for row := 0; row < rows; row++ {
+		for _, t := range cm.tensors {
+			values = append(values, t.data[row])
+		}
+	}
+	out["data"] = map[string]interface{}{
+		"names":   names,
+		"ndarray": nestArray(values, []int{rows, len(cm.tensors)}),
+	}
+	return out, nil
+}
+
+// Tensorflow instances and predictions hold a single tensor; inputs and outputs either hold a single
+// tensor or map tensor names to tensors.
+func parseTensorflowMessage(body map[string]interface{}, defaultName string) (*conversionMessage, error) {
+	for _, key := range []string{"instances", "predictions", "inputs", "outputs"} {
+		v, ok := body[key]
+		if !ok {
+			continue
+		}
+		if named, ok := v.(map[string]interface{}); ok {
+			if key == "instances" || key == "predictions" {
+				break
+			}
+			names := make([]string, 0, len(named))
+			for name := range named {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			cm := &conversionMessage{}
+			for _, name := range names {
+				t, err := tensorFromArray(name, named[name])
+				if err != nil {
+					return nil, err
+				}
+				cm.tensors = append(cm.tensors, t)
+			}
+			return cm, nil
+		}
+		t, err := tensorFromArray(defaultName, v)
+		if err != nil {
+			return nil, err
+		}
+		return &conversionMessage{tensors: []conversionTensor{t}}, nil
+	}
+	return nil, fmt.Errorf("expected one of instances, predictions, inputs or outputs")
+}
+
+func renderTensorflowMessage(cm *conversionMessage, isResponse bool) (map[string]interface{}, error) {
+	single, named := "instances", "inputs"
+	if isResponse {
+		single, named = "predictions", "outputs"
+	}
+	for _, t := range cm.tensors {
+		if t.name == conversionJsonDataName {
+			return nil, fmt.Errorf("jsonData has no equivalent in the tensorflow protocol")
+		}
+	}
+	if len(cm.tensors) == 1 {
+		t := cm.tensors[0]
+		return map[string]interface{}{single: nestArray(t.data, t.shape)}, nil
+	}
+	tensors := map[string]interface{}{}
+	for _, t := range cm.tensors {
+		tensors[t.name] = nestArray(t.data, t.shape)
+	}
+	return map[string]interface{}{named: tensors}, nil
+}
+
+// V2 messages hold a list of named tensors whose data length must match their shape.
+func parseV2Message(body map[string]interface{}) (*conversionMessage, error) {
+	cm := &conversionMessage{}
+	if id, ok := body["id"].(string); ok {
+		cm.id = id
+	}
+	var tensors []interface{}
+	var ok bool
+	if tensors, ok = body["inputs"].([]interface{}); !ok {
+		if tensors, ok = body["outputs"].([]interface{}); !ok {
+			return nil, fmt.Errorf("expected a list of inputs or outputs")
+		}
+	}
+	for _, v := range tensors {
+		tensor, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("tensors must be objects")
+		}
+		name, _ := tensor["name"].(string)
+		datatype, _ := tensor["datatype"].(string)
+		if name == "" || datatype == "" {
+			return nil, fmt.Errorf("tensors must have a name and datatype")
+		}
+		known, shape, err := parseShape(tensor["shape"])
+		if err != nil {
+			return nil, fmt.Errorf("tensor %s: %v", name, err)
+		}
+		if !known {
+			return nil, fmt.Errorf("tensor %s has no shape", name)
+		}
+		_, data, err := flattenArray(tensor["data"])
+		if err != nil {
+			return nil, fmt.Errorf("tensor %s: %v", name, err)
+		}
+		if len(data) != shapeSize(shape) {
+			return nil, fmt.Errorf("tensor %s has %d elements but shape %v", name, len(data), shape)
+		}
+		cm.tensors = append(cm.tensors, conversionTensor{name: name, datatype: datatype, shape: shape, data: data})
+	}
+	return cm, nil
+}
+
+func renderV2Message(cm *conversionMessage, isResponse bool) (map[string]interface{}, error) {
+	key := "inputs"
+	if isResponse {
+		key = "outputs"
+	}
+	tensors := make([]interface{}, len(cm.tensors))
+	for i, t := range cm.tensors {
+		tensors[i] = map[string]interface{}{
+			"name":     t.name,
+			"datatype": t.datatype,
+			"shape":    t.shape,
+			"data":     t.data,
+		}
+	}
+	out := map[string]interface{}{key: tensors}
+	if cm.id != "" {
+		out["id"] = cm.id
+	}
+	return out, nil
+}
+
+func tensorFromArray(name string, v interface{}) (conversionTensor, error) {
+	shape, data, err := flattenArray(v)
+	if err != nil {
+		return conversionTensor{}, fmt.Errorf("tensor %s: %v", name, err)
+	}
+	datatype, err := inferDatatype(data)
+	if err != nil {
+		return conversionTensor{}, fmt.Errorf("tensor %s: %v", name, err)
+	}
+	return conversionTensor{name: name, datatype: datatype, shape: shape, data: data}, nil
+}
+
+// flattenArray returns the shape and row-major elements of a nested JSON array. Scalars have an
+// empty shape. Ragged arrays are rejected.
+func flattenArray(v interface{}) ([]int, []interface{}, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		if v == nil {
+			return nil, nil, fmt.Errorf("missing data")
+		}
+		return []int{}, []interface{}{v}, nil
+	}
+	if len(arr) == 0 {
+		return []int{0}, []interface{}{}, nil
+	}
+	var innerShape []int
+	var data []interface{}
+	for i, elt := range arr {
+		shape, eltData, err := flattenArray(elt)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			innerShape = shape
+		} else if !equalShapes(innerShape, shape) {
+			return nil, nil, fmt.Errorf("ragged arrays are not supported")
+		}
+		data = append(data, eltData...)
+	}
+	return append([]int{len(arr)}, innerShape...), data, nil
+}
+
+// nestArray is the inverse of flattenArray.
+func nestArray(data []interface{}, shape []int) interface{} {
+	if len(shape) == 0 {
+		if len(data) == 0 {
+			return nil
+		}
+		return data[0]
+	}
+	if len(shape) == 1 {
+		return data
+	}
+	stride := shapeSize(shape[1:])
+	out := make([]interface{}, shape[0])
+	for i := range out {
+		out[i] = nestArray(data[i*stride:(i+1)*stride], shape[1:])
+	}
+	return out
+}
+
+func parseShape(v interface{}) (bool, []int, error) {
+	if v == nil {
+		return false, nil, nil
+	}
+	dims, ok := v.([]interface{})
+	if !ok {
+		return false, nil, fmt.Errorf("shape must be a list")
+	}
+	shape := make([]int, len(dims))
+	for i, d := range dims {
+		n, ok := d.(json.Number)
+		if !ok {
+			return false, nil, fmt.Errorf("shape must contain integers")
+		}
+		dim, err := n.Int64()
+		if err != nil || dim < 0 {
+			return false, nil, fmt.Errorf("shape must contain non-negative integers")
+		}
+		shape[i] = int(dim)
+	}
+	return true, shape, nil
+}
+
+func shapeSize(shape []int) int {
+	size := 1
+	for _, d := range shape {
+		size *= d
+	}
+	return size
+}
+
+func equalShapes(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isColumn(t conversionTensor) bool {
+	return len(t.shape) == 1 || (len(t.shape) == 2 && t.shape[1] == 1)
+}
+
+// inferDatatype returns the v2 datatype for JSON elements: INT64 for whole numbers, FP64 if any
+// number has a fractional part or exponent, BOOL or BYTES for strings.
+func inferDatatype(data []interface{}) (string, error) {
+	datatype := ""
+	for _, elt := range data {
+		eltType := ""
+		switch v := elt.(type) {
+		case json.Number:
+			eltType = datatypeInt64
+			if strings.ContainsAny(v.String(), ".eE") {
+				eltType = datatypeFP64
+			}
+		case bool:
+			eltType = datatypeBool
+		case string:
+			eltType = datatypeBytes
+		default:
+			return "", fmt.Errorf("unsupported element %v", elt)
+		}
+		switch {
+		case datatype == "" || datatype == eltType:
+			datatype = eltType
+		case (datatype == datatypeInt64 && eltType == datatypeFP64) || (datatype == datatypeFP64 && eltType == datatypeInt64):
+			datatype = datatypeFP64
+		default:
+			return "", fmt.Errorf("mixed element types %s and %s", datatype, eltType)
+		}
+	}
+	if datatype == "" {
+		datatype = datatypeFP64
+	}
+	return datatype, nil
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+)
+
+func convertJSON(g *GomegaWithT, msg string, from string, to string, isResponse bool) map[string]interface{} {
+	res, err := ConvertPayload(&payload.BytesPayload{Msg: []byte(msg), ContentType: ContentTypeJSON}, from, to, isResponse)
+	g.Expect(err).To(BeNil())
+	out := map[string]interface{}{}
+	g.Expect(json.Unmarshal(res.GetPayload().([]byte), &out)).To(BeNil())
+	return out
+}
+
+func expectJSON(g *GomegaWithT, actual map[string]interface{}, expected string) {
+	e := map[string]interface{}{}
+	g.Expect(json.Unmarshal([]byte(expected), &e)).To(BeNil())
+	g.Expect(actual).To(Equal(e))
+}
+
+func TestConvertSeldonToV2(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Names matching the columns give one tensor per column
+	out := convertJSON(g, `{"meta":{"puid":"abc"},"data":{"names":["a","b"],"ndarray":[[1,"x"],[2.5,"y"]]}}`, api.ProtocolSeldon, api.ProtocolV2, false)
+	expectJSON(g, out, `{"id":"abc","inputs":[
+		{"name":"a","datatype":"FP64","shape":[2,1],"data":[1,2.5]},
+		{"name":"b","datatype":"BYTES","shape":[2,1],"data":["x","y"]}]}`)
+
+	// Otherwise the array is a single tensor
+	out = convertJSON(g, `{"data":{"ndarray":[[1,2,3],[4,5,6]]}}`, api.ProtocolSeldon, api.ProtocolV2, false)
+	expectJSON(g, out, `{"inputs":[{"name":"input-0","datatype":"INT64","shape":[2,3],"data":[1,2,3,4,5,6]}]}`)
+
+	out = convertJSON(g, `{"data":{"tensor":{"shape":[1,2],"values":[1,2]}}}`, api.ProtocolSeldon, api.ProtocolV2, true)
+	expectJSON(g, out, `{"outputs":[{"name":"output-0","datatype":"FP64","shape":[1,2],"data":[1,2]}]}`)
+
+	out = convertJSON(g, `{"jsonData":{"foo":[1,2]}}`, api.ProtocolSeldon, api.ProtocolV2, false)
+	expectJSON(g, out, `{"inputs":[{"name":"jsonData","datatype":"BYTES","shape":[1],"data":["{\"foo\":[1,2]}"]}]}`)
+}
+
+func TestConvertV2ToSeldon(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := convertJSON(g, `{"id":"abc","outputs":[
+		{"name":"a","datatype":"FP32","shape":[2],"data":[1,2]},
+		{"name":"b","datatype":"BYTES","shape":[2,1],"data":["x","y"]}]}`, api.ProtocolV2, api.ProtocolSeldon, true)
+	expectJSON(g, out, `{"meta":{"puid":"abc"},"data":{"names":["a","b"],"ndarray":[[1,"x"],[2,"y"]]}}`)
+
+	out = convertJSON(g, `{"outputs":[{"name":"output-0","datatype":"INT64","shape":[2,2],"data":[1,2,3,4]}]}`, api.ProtocolV2, api.ProtocolSeldon, true)
+	expectJSON(g, out, `{"data":{"ndarray":[[1,2],[3,4]]}}`)
+
+	out = convertJSON(g, `{"outputs":[{"name":"jsonData","datatype":"BYTES","shape":[1],"data":["{\"foo\":1}"]}]}`, api.ProtocolV2, api.ProtocolSeldon, true)
+	expectJSON(g, out, `{"jsonData":{"foo":1}}`)
+
+	out = convertJSON(g, `{"outputs":[{"name":"strData","datatype":"BYTES","shape":[1],"data":["hello"]}]}`, api.ProtocolKFServing, api.ProtocolSeldon, true)
+	expectJSON(g, out, `{"strData":"hello"}`)
+}
+
+func TestConvertTensorflow(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := convertJSON(g, `{"instances":[[1,2],[3,4]]}`, api.ProtocolTensorflow, api.ProtocolV2, false)
+	expectJSON(g, out, `{"inputs":[{"name":"input-0","datatype":"INT64","shape":[2,2],"data":[1,2,3,4]}]}`)
+
+	out = convertJSON(g, `{"inputs":{"b":[true],"a":[1.5]}}`, api.ProtocolTensorflow, api.ProtocolV2, false)
+	expectJSON(g, out, `{"inputs":[
+		{"name":"a","datatype":"FP64","shape":[1],"data":[1.5]},
+		{"name":"b","datatype":"BOOL","shape":[1],"data":[true]}]}`)
+
+	out = convertJSON(g, `{"data":{"ndarray":[[1,2]]}}`, api.ProtocolSeldon, api.ProtocolTensorflow, false)
+	expectJSON(g, out, `{"instances":[[1,2]]}`)
+
+	out = convertJSON(g, `{"outputs":[{"name":"a","datatype":"FP32","shape":[1],"data":[1]},{"name":"b","datatype":"FP32","shape":[1],"data":[2]}]}`, api.ProtocolV2, api.ProtocolTensorflow, true)
+	expectJSON(g, out, `{"outputs":{"a":[1],"b":[2]}}`)
+
+	out = convertJSON(g, `{"predictions":[0.1,0.9]}`, api.ProtocolTensorflow, api.ProtocolSeldon, true)
+	expectJSON(g, out, `{"data":{"ndarray":[0.1,0.9]}}`)
+}
+
+func TestConvertErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		msg  string
+		from string
+		to   string
+	}{
+		{msg: `{"data":{"ndarray":[[1,2],[3]]}}`, from: api.ProtocolSeldon, to: api.ProtocolV2},
+		{msg: `{"data":{"ndarray":[1,"a"]}}`, from: api.ProtocolSeldon, to: api.ProtocolV2},
+		{msg: `{"binData":"AAA="}`, from: api.ProtocolSeldon, to: api.ProtocolV2},
+		{msg: `{"jsonData":{"a":1}}`, from: api.ProtocolSeldon, to: api.ProtocolTensorflow},
+		{msg: `{"inputs":[{"name":"a","datatype":"FP32","shape":[3],"data":[1,2]}]}`, from: api.ProtocolV2, to: api.ProtocolSeldon},
+		{msg: `{"inputs":[{"name":"a","datatype":"FP32","shape":[2],"data":[1,2]},{"name":"b","datatype":"FP32","shape":[3],"data":[1,2,3]}]}`, from: api.ProtocolV2, to: api.ProtocolSeldon},
+		{msg: `[1,2]`, from: api.ProtocolTensorflow, to: api.ProtocolSeldon},
+		{msg: `{"outputs":[{"name":"a","datatype":"FP32","shape":[],"data":[1]},{"name":"b","datatype":"FP32","shape":[1],"data":[2]}]}`, from: api.ProtocolV2, to: api.ProtocolSeldon},
+	}
+	for _, test := range tests {
+		_, err := ConvertPayload(&payload.BytesPayload{Msg: []byte(test.msg)}, test.from, test.to, false)
+		g.Expect(err).ToNot(BeNil(), test.msg)
+		_, ok := err.(*ConversionError)
+		g.Expect(ok).To(BeTrue(), test.msg)
+		g.Expect(errorStatusCode(err)).To(Equal(400))
+	}
+
+	// Same protocol is passed through untouched
+	msg := &payload.BytesPayload{Msg: []byte(`not json`)}
+	res, err := ConvertPayload(msg, api.ProtocolKFServing, api.ProtocolV2, false)
+	g.Expect(err).To(BeNil())
+	g.Expect(res).To(BeIdenticalTo(msg))
+}
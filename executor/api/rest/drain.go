@@ -0,0 +1,22 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/predictor"
+)
+
+// trackInFlight counts requests as in flight so shutdown waits for them. Status and health checks are not counted,
+// and websocket connections count each message as it is processed instead.
+func (r *SeldonRestApi) trackInFlight(service string, next http.HandlerFunc) http.HandlerFunc {
+	if service == metric.StatusHttpServiceName || service == metric.PredictionWebsocketHttpServiceName {
+		return next
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		done := predictor.TrackRequest(service+" "+req.URL.Path, req.Header.Get(payload.SeldonPUIDHeader))
+		defer done()
+		next(w, req)
+	}
+}
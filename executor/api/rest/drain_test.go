@@ -0,0 +1,34 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/predictor"
+)
+
+func TestTrackInFlight(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &SeldonRestApi{}
+
+	var inFlight []predictor.InFlightRequest
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		inFlight = predictor.InFlight()
+	}
+	req, _ := http.NewRequest("POST", "/api/v1.0/predictions", nil)
+	req.Header.Set(payload.SeldonPUIDHeader, "puid-1")
+	r.trackInFlight(metric.PredictionHttpServiceName, handler)(httptest.NewRecorder(), req)
+	g.Expect(inFlight).To(HaveLen(1))
+	g.Expect(inFlight[0].Name).To(Equal(metric.PredictionHttpServiceName + " /api/v1.0/predictions"))
+	g.Expect(inFlight[0].Puid).To(Equal("puid-1"))
+	g.Expect(predictor.InFlight()).To(BeEmpty())
+
+	// Status and health checks are not counted
+	req, _ = http.NewRequest("GET", "/api/v1.0/health/status", nil)
+	r.trackInFlight(metric.StatusHttpServiceName, handler)(httptest.NewRecorder(), req)
+	g.Expect(inFlight).To(BeEmpty())
+}
@@ -1,8 +1,14 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/concurrency"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
 )
 
 type httpStatusError struct {
@@ -17,3 +23,39 @@ func (e *httpStatusError) Error() string {
 func invalidPayload(msg string) error {
 	return fmt.Errorf("invalid payload: %s", msg)
 }
+
+// errorStatusCode returns the HTTP status code to respond with for an error.
+func errorStatusCode(err error) int {
+	var serr *httpStatusError
+	if errors.As(err, &serr) {
+		return serr.StatusCode
+	}
+	var cerr *ConversionError
+	if errors.As(err, &cerr) {
+		return http.StatusBadRequest
+	}
+	var authnErr *auth.AuthenticationError
+	if errors.As(err, &authnErr) {
+		return http.StatusUnauthorized
+	}
+	var authzErr *auth.AuthorizationError
+	if errors.As(err, &authzErr) {
+		return http.StatusForbidden
+	}
+	var batchErr *BatchTooLargeError
+	if errors.As(err, &batchErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	var limitErr *ratelimit.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return http.StatusTooManyRequests
+	}
+	var overloadedErr *concurrency.OverloadedError
+	if errors.As(err, &overloadedErr) {
+		return http.StatusServiceUnavailable
+	}
+	if concurrency.IsBulkheadFull(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/seldonio/seldon-core/executor/api/idempotency"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+)
+
+// WithDeduplicator returns the stored response to prediction requests repeating an idempotency key.
+func WithDeduplicator(deduplicator *idempotency.Deduplicator) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		r.deduplicator = deduplicator
+	}
+}
+
+// deduplicate handles prediction requests with an idempotency key once, returning the stored response to
+// retries within the TTL. Keys are scoped to the request path, the client and the request body. Server errors
+// aren't stored so they can be retried, and duplicates waiting for a request which fails are handled themselves.
+func (r *SeldonRestApi) deduplicate(service string, next http.HandlerFunc) http.HandlerFunc {
+	if r.deduplicator == nil || (service != metric.PredictionHttpServiceName && service != metric.PredictionBatchHttpServiceName) {
+		return next
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get(payload.IdempotencyKeyHeader)
+		if key == "" || req.Method != http.MethodPost {
+			next(w, req)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			r.respondWithError(w, nil, err)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		key = idempotency.ScopedKey(req.URL.Path, r.clientIdentity(req), key, body)
+		response, result, err := r.deduplicator.Do(req.Context(), key, func() (*idempotency.Response, error) {
+			rw := &recordingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next(rw, req)
+			if rw.status >= http.StatusInternalServerError {
+				return nil, fmt.Errorf("request failed with status %d", rw.status)
+			}
+			return &idempotency.Response{Status: rw.status, ContentType: rw.Header().Get("Content-Type"), Body: rw.body.Bytes()}, nil
+		})
+		switch {
+		case result == idempotency.ResultMiss:
+			// The response has already been written
+		case err != nil:
+			r.respondWithError(w, nil, err)
+		default:
+			if response.ContentType != "" {
+				w.Header().Set("Content-Type", response.ContentType)
+			}
+			w.Header().Set(payload.IdempotentReplayedHeader, "true")
+			w.WriteHeader(response.Status)
+			if _, err := w.Write(response.Body); err != nil {
+				r.Log.Error(err, "Failed to write stored response")
+			}
+		}
+	}
+}
+
+// clientIdentity returns the subject of the client's validated JWT, or else the key identifying it for rate limits.
+func (r *SeldonRestApi) clientIdentity(req *http.Request) string {
+	if sub := ratelimit.ClaimValue(req.Header.Get(payload.SeldonJwtClaimsHeader), "sub"); sub != "" {
+		return sub
+	}
+	key := ratelimit.ClientKey{Source: ratelimit.KeySourceIP}
+	if r.rateLimiter != nil {
+		key = r.rateLimiter.Key
+	}
+	return rateLimitClientKey(key, req)
+}
@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/idempotency"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestDeduplicate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	store := idempotency.NewMemoryStore(10)
+	deduplicator := idempotency.NewDeduplicator(store, time.Minute, "test")
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true, WithDeduplicator(deduplicator))
+	r.Initialise()
+
+	call := func(path string, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(`{"data":{"ndarray":[1.1,2.0]}}`))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(payload.IdempotencyKeyHeader, key)
+		}
+		res := httptest.NewRecorder()
+		r.Router.ServeHTTP(res, req)
+		return res
+	}
+
+	first := call("/api/v1.0/predictions", "key-1")
+	g.Expect(first.Code).To(Equal(http.StatusOK))
+	g.Expect(first.Header().Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(store.Len()).To(Equal(1))
+
+	retry := call("/api/v1.0/predictions", "key-1")
+	g.Expect(retry.Code).To(Equal(http.StatusOK))
+	g.Expect(retry.Header().Get(payload.IdempotentReplayedHeader)).To(Equal("true"))
+	g.Expect(retry.Header().Get("Content-Type")).To(Equal(first.Header().Get("Content-Type")))
+	g.Expect(retry.Body.String()).To(Equal(first.Body.String()))
+
+	// Keys are scoped to the path and requests without one are not stored
+	g.Expect(call("/api/v0.1/predictions", "key-1").Header().Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(call("/api/v1.0/predictions", "").Header().Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(store.Len()).To(Equal(2))
+}
+
+func TestDeduplicateScope(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	store := idempotency.NewMemoryStore(10)
+	deduplicator := idempotency.NewDeduplicator(store, time.Minute, "test")
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true, WithDeduplicator(deduplicator))
+	r.Initialise()
+
+	call := func(remoteAddr string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1.0/predictions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(payload.IdempotencyKeyHeader, "key-1")
+		req.RemoteAddr = remoteAddr
+		res := httptest.NewRecorder()
+		r.Router.ServeHTTP(res, req)
+		return res
+	}
+
+	body := `{"data":{"ndarray":[1.1,2.0]}}`
+	g.Expect(call("10.0.0.1:1234", body).Header().Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(call("10.0.0.1:5678", body).Header().Get(payload.IdempotentReplayedHeader)).To(Equal("true"))
+
+	// Other clients reusing the key, and requests with a different body, are handled separately
+	g.Expect(call("10.0.0.2:1234", body).Header().Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(call("10.0.0.1:1234", `{"data":{"ndarray":[3.0,4.0]}}`).Header().Get(payload.IdempotentReplayedHeader)).To(BeEmpty())
+	g.Expect(store.Len()).To(Equal(3))
+}
+
+func TestDeduplicateWaiterAfterFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &SeldonRestApi{deduplicator: idempotency.NewDeduplicator(idempotency.NewMemoryStore(10), time.Minute, "test")}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	handler := r.deduplicate(metric.PredictionHttpServiceName, func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	call := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1.0/predictions", strings.NewReader(`{"data":{"ndarray":[1]}}`))
+		req.Header.Set(payload.IdempotencyKeyHeader, "key-1")
+		res := httptest.NewRecorder()
+		handler(res, req)
+		return res
+	}
+
+	wg := sync.WaitGroup{}
+	var first, waiter *httptest.ResponseRecorder
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		first = call()
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		waiter = call()
+	}()
+	// Give the duplicate time to start waiting, though it is handled itself either way
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// The duplicate is handled again rather than given the failure of the request it waited for
+	g.Expect(first.Code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(waiter.Code).To(Equal(http.StatusOK))
+	g.Expect(waiter.Body.String()).To(Equal("ok"))
+	g.Expect(atomic.LoadInt32(&calls)).To(Equal(int32(2)))
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	guuid "github.com/google/uuid"
+	"github.com/seldonio/seldon-core/executor/api/cert"
 	"github.com/seldonio/seldon-core/executor/api/payload"
 	"github.com/seldonio/seldon-core/executor/api/util"
 )
@@ -65,6 +66,19 @@ func handleCORSRequests(next http.Handler) http.Handler {
 	})
 }
 
+// clientIdentityHeader sets the identity of clients verified with mutual TLS, removing any identity
+// header sent by the client itself.
+func clientIdentityHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(payload.SeldonClientIdentityHeader)
+		if identity := cert.ClientIdentity(r.TLS); identity != "" {
+			r.Header.Set(payload.SeldonClientIdentityHeader, identity)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func puidHeader(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		puid := r.Header.Get(payload.SeldonPUIDHeader)
@@ -1,12 +1,16 @@
 package rest
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api/payload"
 )
 
 func TestEnvVars(t *testing.T) {
@@ -93,3 +97,24 @@ func TestXSSMiddleware(t *testing.T) {
 	headerVal := res.Header.Get(contentTypeOptsHeader)
 	g.Expect(headerVal).To(Equal(contentTypeOptsValue))
 }
+
+func TestClientIdentityHeader(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var identity string
+	m := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = r.Header.Get(payload.SeldonClientIdentityHeader)
+	})
+	wrapped := clientIdentityHeader(m)
+
+	// Identities sent by clients are not trusted
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set(payload.SeldonClientIdentityHeader, "admin")
+	wrapped.ServeHTTP(httptest.NewRecorder(), req)
+	g.Expect(identity).To(Equal(""))
+
+	req = httptest.NewRequest("GET", "https://example.com/foo", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "client"}}}}}
+	wrapped.ServeHTTP(httptest.NewRecorder(), req)
+	g.Expect(identity).To(Equal("client"))
+}
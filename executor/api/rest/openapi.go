@@ -0,0 +1,421 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/predictor"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	OpenAPIVersion  = "3.0.0"
+	OpenAPIFileName = "seldon.json"
+
+	openapiModelNameParameter = "model_name"
+)
+
+type openapiObject = map[string]interface{}
+
+// openapiSpecCache holds the OpenAPI spec generated for a version of the graph, so the models' metadata isn't
+// requested each time the spec is.
+type openapiSpecCache struct {
+	mu      sync.Mutex
+	version string
+	spec    []byte
+}
+
+func (c *openapiSpecCache) get(version string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.spec == nil || c.version != version {
+		return nil, false
+	}
+	return c.spec, true
+}
+
+func (c *openapiSpecCache) set(version string, spec []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version = version
+	c.spec = spec
+}
+
+// openapiTensor is a tensor description extracted from model metadata. Seldon metadata
+// describes a single message with several column names while v2 metadata has one
+// entry per named tensor so both are normalised into this struct.
+type openapiTensor struct {
+	Names    []string
+	DataType string
+	Shape    []int
+}
+
+// metadataTensor is the union of the tensor formats found in model metadata.
+type metadataTensor struct {
+	Name        string `json:"name"`
+	DataType    string `json:"datatype"`
+	Shape       []int  `json:"shape"`
+	MessageType string `json:"messagetype"`
+	Schema      *struct {
+		Names []string `json:"names"`
+		Shape []int    `json:"shape"`
+	} `json:"schema"`
+}
+
+// parseMetadataTensors converts the inputs or outputs of a model's metadata into tensor descriptions.
+// Unknown formats result in no tensors, in which case a generic schema is generated.
+func parseMetadataTensors(v interface{}) []openapiTensor {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var mts []metadataTensor
+	if err := json.Unmarshal(b, &mts); err != nil {
+		// Seldon metadata may describe a single message without a list
+		mt := metadataTensor{}
+		if err := json.Unmarshal(b, &mt); err != nil {
+			return nil
+		}
+		mts = []metadataTensor{mt}
+	}
+
+	var tensors []openapiTensor
+	for _, mt := range mts {
+		tensor := openapiTensor{DataType: mt.DataType, Shape: mt.Shape}
+		if tensor.DataType == "" {
+			tensor.DataType = mt.MessageType
+		}
+		if mt.Name != "" {
+			tensor.Names = []string{mt.Name}
+		}
+		if mt.Schema != nil {
+			if len(mt.Schema.Names) > 0 {
+				tensor.Names = mt.Schema.Names
+			}
+			if len(mt.Schema.Shape) > 0 {
+				tensor.Shape = mt.Schema.Shape
+			}
+		}
+		tensors = append(tensors, tensor)
+	}
+	return tensors
+}
+
+// openapiScalarSchema maps a v2 datatype (or seldon message type) to the schema of a single element.
+func openapiScalarSchema(dataType string) openapiObject {
+	dt := strings.ToUpper(dataType)
+	switch {
+	case strings.HasPrefix(dt, "FP"), dt == "TENSOR", dt == "ARRAY", dt == "NDARRAY":
+		return openapiObject{"type": "number"}
+	case strings.HasPrefix(dt, "INT"), strings.HasPrefix(dt, "UINT"):
+		return openapiObject{"type": "integer"}
+	case dt == "BOOL":
+		return openapiObject{"type": "boolean"}
+	case dt == "BYTES", dt == "STRDATA":
+		return openapiObject{"type": "string"}
+	}
+	return openapiObject{}
+}
+
+// openapiArraySchema builds nested array schemas for the given shape. Dimensions of -1 or 0 are variable.
+func openapiArraySchema(shape []int, dataType string) openapiObject {
+	schema := openapiScalarSchema(dataType)
+	for i := len(shape) - 1; i >= 0; i-- {
+		arr := openapiObject{"type": "array", "items": schema}
+		if shape[i] > 0 {
+			arr["minItems"] = shape[i]
+			arr["maxItems"] = shape[i]
+		}
+		schema = arr
+	}
+	return schema
+}
+
+func seldonMessageSchema(tensors []openapiTensor) openapiObject {
+	data := openapiObject{
+		"type": "object",
+		"properties": openapiObject{
+			"names":   openapiObject{"type": "array", "items": openapiObject{"type": "string"}},
+			"ndarray": openapiObject{"type": "array", "items": openapiObject{}},
+		},
+	}
+	properties := openapiObject{
+		"meta":     openapiObject{"type": "object"},
+		"data":     data,
+		"strData":  openapiObject{"type": "string"},
+		"jsonData": openapiObject{},
+	}
+	if len(tensors) == 1 {
+		tensor := tensors[0]
+		switch strings.ToLower(tensor.DataType) {
+		case "jsondata":
+			delete(properties, "data")
+			delete(properties, "strData")
+			return openapiObject{"type": "object", "required": []string{"jsonData"}, "properties": properties}
+		case "strdata":
+			delete(properties, "data")
+			delete(properties, "jsonData")
+			return openapiObject{"type": "object", "required": []string{"strData"}, "properties": properties}
+		}
+		dataProperties := data["properties"].(openapiObject)
+		if len(tensor.Names) > 0 {
+			dataProperties["names"].(openapiObject)["example"] = tensor.Names
+		}
+		// Seldon shapes exclude the batch dimension
+		dataProperties["ndarray"] = openapiObject{"type": "array", "items": openapiArraySchema(tensor.Shape, tensor.DataType)}
+	}
+	return openapiObject{"type": "object", "properties": properties}
+}
+
+func v2TensorSchema(tensor *openapiTensor) openapiObject {
+	properties := openapiObject{
+		"name":       openapiObject{"type": "string"},
+		"datatype":   openapiObject{"type": "string"},
+		"shape":      openapiObject{"type": "array", "items": openapiObject{"type": "integer"}},
+		"parameters": openapiObject{"type": "object"},
+		"data":       openapiObject{"type": "array", "items": openapiObject{}},
+	}
+	if tensor != nil {
+		if len(tensor.Names) > 0 {
+			properties["name"] = openapiObject{"type": "string", "enum": tensor.Names}
+		}
+		if tensor.DataType != "" {
+			properties["datatype"] = openapiObject{"type": "string", "enum": []string{tensor.DataType}}
+			properties["data"] = openapiObject{"type": "array", "items": openapiScalarSchema(tensor.DataType)}
+		}
+		if len(tensor.Shape) > 0 {
+			properties["shape"].(openapiObject)["example"] = tensor.Shape
+		}
+	}
+	return openapiObject{"type": "object", "required": []string{"name", "datatype", "shape", "data"}, "properties": properties}
+}
+
+func v2TensorsSchema(tensors []openapiTensor) openapiObject {
+	if len(tensors) == 0 {
+		return openapiObject{"type": "array", "items": v2TensorSchema(nil)}
+	}
+	var items []openapiObject
+	for i := range tensors {
+		items = append(items, v2TensorSchema(&tensors[i]))
+	}
+	schema := openapiObject{"type": "array", "minItems": len(items), "maxItems": len(items)}
+	if len(items) == 1 {
+		schema["items"] = items[0]
+	} else {
+		schema["items"] = openapiObject{"oneOf": items}
+	}
+	return schema
+}
+
+func v2RequestSchema(tensors []openapiTensor) openapiObject {
+	return openapiObject{
+		"type":     "object",
+		"required": []string{"inputs"},
+		"properties": openapiObject{
+			"id":         openapiObject{"type": "string"},
+			"parameters": openapiObject{"type": "object"},
+			"inputs":     v2TensorsSchema(tensors),
+			"outputs":    openapiObject{"type": "array", "items": openapiObject{"type": "object"}},
+		},
+	}
+}
+
+func v2ResponseSchema(tensors []openapiTensor) openapiObject {
+	return openapiObject{
+		"type":     "object",
+		"required": []string{"model_name", "outputs"},
+		"properties": openapiObject{
+			"model_name":    openapiObject{"type": "string"},
+			"model_version": openapiObject{"type": "string"},
+			"id":            openapiObject{"type": "string"},
+			"parameters":    openapiObject{"type": "object"},
+			"outputs":       v2TensorsSchema(tensors),
+		},
+	}
+}
+
+func tensorflowRequestSchema(tensors []openapiTensor) openapiObject {
+	items := openapiObject{}
+	if len(tensors) == 1 {
+		items = openapiArraySchema(tensors[0].Shape, tensors[0].DataType)
+	}
+	return openapiObject{
+		"type": "object",
+		"properties": openapiObject{
+			"signature_name": openapiObject{"type": "string"},
+			"instances":      openapiObject{"type": "array", "items": items},
+			"inputs":         openapiObject{},
+		},
+	}
+}
+
+func tensorflowResponseSchema(tensors []openapiTensor) openapiObject {
+	items := openapiObject{}
+	if len(tensors) == 1 {
+		items = openapiArraySchema(tensors[0].Shape, tensors[0].DataType)
+	}
+	return openapiObject{
+		"type": "object",
+		"properties": openapiObject{
+			"predictions": openapiObject{"type": "array", "items": items},
+			"outputs":     openapiObject{},
+		},
+	}
+}
+
+func openapiJsonContent(ref string) openapiObject {
+	return openapiObject{
+		"application/json": openapiObject{
+			"schema": openapiObject{"$ref": "#/components/schemas/" + ref},
+		},
+	}
+}
+
+func openapiOperation(operationId string, request string, response string, parameters []openapiObject) openapiObject {
+	op := openapiObject{
+		"operationId": operationId,
+		"responses": openapiObject{
+			"200": openapiObject{"description": "A successful response."},
+		},
+	}
+	if response != "" {
+		op["responses"].(openapiObject)["200"].(openapiObject)["content"] = openapiJsonContent(response)
+	}
+	if request != "" {
+		op["requestBody"] = openapiObject{"required": true, "content": openapiJsonContent(request)}
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+func openapiModelNameParameters(graphMetadata *predictor.GraphMetadata) []openapiObject {
+	schema := openapiObject{"type": "string"}
+	if graphMetadata != nil && len(graphMetadata.Models) > 0 {
+		var names []string
+		for name := range graphMetadata.Models {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		schema["enum"] = names
+	}
+	return []openapiObject{{
+		"name":     openapiModelNameParameter,
+		"in":       "path",
+		"required": true,
+		"schema":   schema,
+	}}
+}
+
+// NewOpenAPISpec generates an OpenAPI 3 document for the protocol served by this executor.
+// Request and response schemas are derived from the graph inputs and outputs so they contain
+// the tensor names, datatypes and shapes declared by the models. A nil graphMetadata, or models
+// without metadata, produce a generic schema for the protocol.
+func NewOpenAPISpec(protocol string, deploymentName string, namespace string, graphMetadata *predictor.GraphMetadata) map[string]interface{} {
+	var inputs, outputs []openapiTensor
+	if graphMetadata != nil {
+		inputs = parseMetadataTensors(graphMetadata.GraphInputs)
+		outputs = parseMetadataTensors(graphMetadata.GraphOutputs)
+	}
+
+	paths := openapiObject{}
+	schemas := openapiObject{}
+	modelName := fmt.Sprintf("{%s}", openapiModelNameParameter)
+	switch protocol {
+	case api.ProtocolSeldon:
+		schemas["PredictionRequest"] = seldonMessageSchema(inputs)
+		schemas["PredictionResponse"] = seldonMessageSchema(outputs)
+		schemas["Feedback"] = openapiObject{
+			"type": "object",
+			"properties": openapiObject{
+				"request":  openapiObject{"$ref": "#/components/schemas/PredictionRequest"},
+				"response": openapiObject{"$ref": "#/components/schemas/PredictionResponse"},
+				"reward":   openapiObject{"type": "number"},
+				"truth":    openapiObject{"$ref": "#/components/schemas/PredictionResponse"},
+			},
+		}
+		paths["/api/v1.0/predictions"] = openapiObject{"post": openapiOperation("Predict", "PredictionRequest", "PredictionResponse", nil)}
+		paths["/api/v1.0/feedback"] = openapiObject{"post": openapiOperation("SendFeedback", "Feedback", "PredictionResponse", nil)}
+		paths["/api/v1.0/metadata"] = openapiObject{"get": openapiOperation("GraphMetadata", "", "", nil)}
+	case api.ProtocolTensorflow:
+		schemas["PredictionRequest"] = tensorflowRequestSchema(inputs)
+		schemas["PredictionResponse"] = tensorflowResponseSchema(outputs)
+		params := openapiModelNameParameters(graphMetadata)
+		paths["/v1/models/"+modelName+":predict"] = openapiObject{"post": openapiOperation("Predict", "PredictionRequest", "PredictionResponse", params)}
+		paths["/v1/models/"+modelName+"/metadata"] = openapiObject{"get": openapiOperation("ModelMetadata", "", "", params)}
+	case api.ProtocolV2, api.ProtocolKFServing:
+		schemas["InferenceRequest"] = v2RequestSchema(inputs)
+		schemas["InferenceResponse"] = v2ResponseSchema(outputs)
+		params := openapiModelNameParameters(graphMetadata)
+		paths["/v2/models/"+modelName+"/infer"] = openapiObject{"post": openapiOperation("ModelInfer", "InferenceRequest", "InferenceResponse", params)}
+		paths["/v2/models/"+modelName] = openapiObject{"get": openapiOperation("ModelMetadata", "", "", params)}
+		paths["/v2/models/"+modelName+"/ready"] = openapiObject{"get": openapiOperation("ModelReady", "", "", params)}
+		paths["/v2/health/ready"] = openapiObject{"get": openapiOperation("ServerReady", "", "", nil)}
+	}
+
+	return openapiObject{
+		"openapi": OpenAPIVersion,
+		"info": openapiObject{
+			"title":       fmt.Sprintf("Seldon Deployment %s", deploymentName),
+			"description": fmt.Sprintf("Inference API of SeldonDeployment %s in namespace %s using the %s protocol", deploymentName, namespace, protocol),
+			"version":     "1.0",
+		},
+		// Requests through the ingress are prefixed with the namespace and deployment name
+		"servers": []openapiObject{
+			{"url": fmt.Sprintf("/seldon/%s/%s", namespace, deploymentName)},
+			{"url": "/"},
+		},
+		"paths":      paths,
+		"components": openapiObject{"schemas": schemas},
+	}
+}
+
+func (r *SeldonRestApi) openapi(w http.ResponseWriter, req *http.Request) {
+	r.Log.V(1).Info("OpenAPI spec called.")
+
+	ctx := req.Context()
+
+	// Apply tracing if active
+	if opentracing.IsGlobalTracerRegistered() {
+		var serverSpan opentracing.Span
+		ctx, serverSpan = setupTracing(ctx, req, TracingMetadataName)
+		defer serverSpan.Finish()
+	}
+
+	spec := predictor.ActiveSpec(r.predictor)
+	version := predictor.GraphVersion(spec)
+	if msg, ok := r.openapiSpec.get(version); ok {
+		r.respondWithSuccess(w, http.StatusOK, &payload.BytesPayload{Msg: msg, ContentType: ContentTypeJSON})
+		return
+	}
+
+	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, "")
+	graphMetadata, err := seldonPredictorProcess.GraphMetadata(spec)
+	if err != nil {
+		// Models may not expose metadata so fall back to the generic schema
+		r.Log.Info("Failed to get graph metadata, generating generic OpenAPI spec", "error", err.Error())
+		graphMetadata = nil
+	}
+
+	msg, err := json.Marshal(NewOpenAPISpec(r.Protocol, r.DeploymentName, r.Namespace, graphMetadata))
+	if err != nil {
+		r.respondWithError(w, nil, err)
+		return
+	}
+	// Generic specs aren't kept so the models' metadata is requested again once they serve it
+	if graphMetadata != nil {
+		r.openapiSpec.set(version, msg)
+	}
+	r.respondWithSuccess(w, http.StatusOK, &payload.BytesPayload{Msg: msg, ContentType: ContentTypeJSON})
+}
@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	"github.com/seldonio/seldon-core/executor/predictor"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func getOpenAPISpec(g *WithT, r *SeldonRestApi, path string) map[string]interface{} {
+	req, _ := http.NewRequest("GET", path, nil)
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200))
+	spec := map[string]interface{}{}
+	g.Expect(json.Unmarshal(res.Body.Bytes(), &spec)).To(BeNil())
+	g.Expect(spec["openapi"]).To(Equal(OpenAPIVersion))
+	return spec
+}
+
+func getOpenAPISchema(spec map[string]interface{}, name string) map[string]interface{} {
+	return spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+}
+
+func TestParseMetadataTensors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tensors := parseMetadataTensors([]map[string]interface{}{
+		{"name": "a", "datatype": "FP32", "shape": []int{-1, 4}},
+		{"name": "b", "datatype": "BYTES", "shape": []int{-1}},
+	})
+	g.Expect(tensors).To(Equal([]openapiTensor{
+		{Names: []string{"a"}, DataType: "FP32", Shape: []int{-1, 4}},
+		{Names: []string{"b"}, DataType: "BYTES", Shape: []int{-1}},
+	}))
+
+	tensors = parseMetadataTensors([]map[string]interface{}{
+		{"messagetype": "tensor", "schema": map[string]interface{}{"names": []string{"a", "b"}, "shape": []int{2}}},
+	})
+	g.Expect(tensors).To(Equal([]openapiTensor{
+		{Names: []string{"a", "b"}, DataType: "tensor", Shape: []int{2}},
+	}))
+
+	tensors = parseMetadataTensors(map[string]interface{}{"datatype": "jsonData"})
+	g.Expect(tensors).To(Equal([]openapiTensor{{DataType: "jsonData"}}))
+
+	g.Expect(parseMetadataTensors(nil)).To(BeNil())
+	g.Expect(parseMetadataTensors("unknown")).To(BeNil())
+}
+
+func TestOpenAPISpecV2(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "mymodel",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	metadataMap := map[string]payload.ModelMetadata{
+		"mymodel": {
+			Name: "mymodel",
+			Inputs: []map[string]interface{}{
+				{"name": "input-0", "datatype": "FP32", "shape": []int{-1, 3}},
+			},
+			Outputs: []map[string]interface{}{
+				{"name": "output-0", "datatype": "INT64", "shape": []int{-1}},
+			},
+		},
+	}
+
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{ModelMetadataMap: metadataMap}, false, url, "default", api.ProtocolV2, "test", "/metrics", true)
+	r.Initialise()
+
+	for _, path := range []string{"/seldon.json", "/v2/docs/seldon.json"} {
+		spec := getOpenAPISpec(g, r, path)
+		g.Expect(spec["paths"]).To(HaveKey("/v2/models/{model_name}/infer"))
+
+		inputs := getOpenAPISchema(spec, "InferenceRequest")["properties"].(map[string]interface{})["inputs"].(map[string]interface{})
+		input := inputs["items"].(map[string]interface{})["properties"].(map[string]interface{})
+		g.Expect(input["name"].(map[string]interface{})["enum"]).To(Equal([]interface{}{"input-0"}))
+		g.Expect(input["datatype"].(map[string]interface{})["enum"]).To(Equal([]interface{}{"FP32"}))
+		g.Expect(input["shape"].(map[string]interface{})["example"]).To(Equal([]interface{}{float64(-1), float64(3)}))
+		g.Expect(input["data"].(map[string]interface{})["items"]).To(Equal(map[string]interface{}{"type": "number"}))
+
+		outputs := getOpenAPISchema(spec, "InferenceResponse")["properties"].(map[string]interface{})["outputs"].(map[string]interface{})
+		output := outputs["items"].(map[string]interface{})["properties"].(map[string]interface{})
+		g.Expect(output["name"].(map[string]interface{})["enum"]).To(Equal([]interface{}{"output-0"}))
+		g.Expect(output["data"].(map[string]interface{})["items"]).To(Equal(map[string]interface{}{"type": "integer"}))
+	}
+}
+
+func TestOpenAPISpecSeldon(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "mymodel",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	metadataMap := map[string]payload.ModelMetadata{
+		"mymodel": {
+			Name: "mymodel",
+			Inputs: []map[string]interface{}{
+				{"messagetype": "tensor", "schema": map[string]interface{}{"names": []string{"a", "b"}, "shape": []int{2}}},
+			},
+			Outputs: []map[string]interface{}{
+				{"messagetype": "jsonData"},
+			},
+		},
+	}
+
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{ModelMetadataMap: metadataMap}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true)
+	r.Initialise()
+
+	for _, path := range []string{"/seldon.json", "/api/v1.0/doc/seldon.json", "/api/v0.1/doc/seldon.json"} {
+		spec := getOpenAPISpec(g, r, path)
+		g.Expect(spec["paths"]).To(HaveKey("/api/v1.0/predictions"))
+
+		data := getOpenAPISchema(spec, "PredictionRequest")["properties"].(map[string]interface{})["data"].(map[string]interface{})["properties"].(map[string]interface{})
+		g.Expect(data["names"].(map[string]interface{})["example"]).To(Equal([]interface{}{"a", "b"}))
+		row := data["ndarray"].(map[string]interface{})["items"].(map[string]interface{})
+		g.Expect(row["maxItems"]).To(Equal(float64(2)))
+
+		g.Expect(getOpenAPISchema(spec, "PredictionResponse")["required"]).To(Equal([]interface{}{"jsonData"}))
+	}
+}
+
+func TestOpenAPISpecGeneric(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// No metadata available from the models
+	spec := NewOpenAPISpec(api.ProtocolV2, "dep", "ns", nil)
+	g.Expect(spec["servers"]).To(ContainElement(map[string]interface{}{"url": "/seldon/ns/dep"}))
+	inputs := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})["InferenceRequest"].(map[string]interface{})["properties"].(map[string]interface{})["inputs"].(map[string]interface{})
+	g.Expect(inputs["items"]).To(Equal(v2TensorSchema(nil)))
+
+	spec = NewOpenAPISpec(api.ProtocolTensorflow, "dep", "ns", &predictor.GraphMetadata{})
+	g.Expect(spec["paths"]).To(HaveKey("/v1/models/{model_name}:predict"))
+}
+
+func TestOpenAPISpecCachedPerGraphVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name:     "mymodel",
+			Type:     &model,
+			Endpoint: &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST},
+		},
+	}
+	metadataMap := map[string]payload.ModelMetadata{
+		"mymodel": {Name: "mymodel", Inputs: []map[string]interface{}{{"name": "input-0", "datatype": "FP32", "shape": []int{-1, 3}}}},
+	}
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{ModelMetadataMap: metadataMap}, false, url, "default", api.ProtocolV2, "test", "/metrics", true)
+	r.Initialise()
+
+	inputName := func() interface{} {
+		spec := getOpenAPISpec(g, r, "/seldon.json")
+		inputs := getOpenAPISchema(spec, "InferenceRequest")["properties"].(map[string]interface{})["inputs"].(map[string]interface{})
+		return inputs["items"].(map[string]interface{})["properties"].(map[string]interface{})["name"].(map[string]interface{})["enum"]
+	}
+	g.Expect(inputName()).To(Equal([]interface{}{"input-0"}))
+
+	// The models' metadata isn't requested again for the same graph
+	metadataMap["mymodel"] = payload.ModelMetadata{Name: "mymodel", Inputs: []map[string]interface{}{{"name": "input-1", "datatype": "FP32", "shape": []int{-1, 3}}}}
+	g.Expect(inputName()).To(Equal([]interface{}{"input-0"}))
+
+	p.Graph.Endpoint.ServicePort = 9001
+	g.Expect(inputName()).To(Equal([]interface{}{"input-1"}))
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+)
+
+// WithRateLimiter limits the rate of requests to all endpoints apart from status and health checks.
+func WithRateLimiter(limiter *ratelimit.Limiter) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		r.rateLimiter = limiter
+	}
+}
+
+// rateLimit rejects requests exceeding the rate limits with a 429 status code.
+func (r *SeldonRestApi) rateLimit(service string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if r.rateLimiter != nil && service != metric.StatusHttpServiceName && req.Method != http.MethodOptions {
+			if ok, retryAfter := r.rateLimiter.Allow(rateLimitClientKey(r.rateLimiter.Key, req)); !ok {
+				w.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(retryAfter))
+				r.respondWithError(w, nil, &ratelimit.LimitExceededError{RetryAfter: retryAfter})
+				return
+			}
+		}
+		next(w, req)
+	}
+}
+
+func rateLimitClientKey(key ratelimit.ClientKey, req *http.Request) string {
+	switch key.Source {
+	case ratelimit.KeySourceHeader:
+		return req.Header.Get(key.Name)
+	case ratelimit.KeySourceClaim:
+		return ratelimit.ClaimValue(req.Header.Get(payload.SeldonJwtClaimsHeader), key.Name)
+	default:
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			return req.RemoteAddr
+		}
+		return host
+	}
+}
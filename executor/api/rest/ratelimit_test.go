@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestRateLimit(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	limiter := ratelimit.NewLimiter(0, 0, 1, 1, ratelimit.ClientKey{Source: ratelimit.KeySourceHeader, Name: "X-Api-Key"}, "test")
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true, WithRateLimiter(limiter))
+	r.Initialise()
+
+	call := func(method string, path string, apiKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(`{"data":{"ndarray":[1.1,2.0]}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Api-Key", apiKey)
+		res := httptest.NewRecorder()
+		r.Router.ServeHTTP(res, req)
+		return res
+	}
+
+	g.Expect(call("POST", "/api/v1.0/predictions", "a").Code).To(Equal(http.StatusOK))
+	res := call("POST", "/api/v1.0/predictions", "a")
+	g.Expect(res.Code).To(Equal(http.StatusTooManyRequests))
+	g.Expect(res.Header().Get("Retry-After")).To(Equal("1"))
+	g.Expect(call("POST", "/api/v1.0/predictions", "b").Code).To(Equal(http.StatusOK))
+	// Status checks are not limited
+	g.Expect(call("GET", "/api/v1.0/status/model", "a").Code).To(Equal(http.StatusOK))
+}
+
+func TestRateLimitClientKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	req := httptest.NewRequest("POST", "http://example.com/api/v1.0/predictions", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Api-Key", "key")
+	req.Header.Set("Seldon-Jwt-Claims", `{"sub":"alice"}`)
+
+	g.Expect(rateLimitClientKey(ratelimit.ClientKey{Source: ratelimit.KeySourceIP}, req)).To(Equal("10.0.0.1"))
+	g.Expect(rateLimitClientKey(ratelimit.ClientKey{Source: ratelimit.KeySourceHeader, Name: "X-Api-Key"}, req)).To(Equal("key"))
+	g.Expect(rateLimitClientKey(ratelimit.ClientKey{Source: ratelimit.KeySourceClaim, Name: "sub"}, req)).To(Equal("alice"))
+}
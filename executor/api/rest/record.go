@@ -0,0 +1,62 @@
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/record"
+)
+
+// WithRecorder records a sample of prediction requests and their responses.
+func WithRecorder(recorder *record.Recorder) ServerRestApiOption {
+	return func(r *SeldonRestApi) {
+		r.recorder = recorder
+	}
+}
+
+// recordingResponseWriter keeps a copy of the response written.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush allows streamed responses, such as batch predictions, to be flushed through the writer.
+func (w *recordingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// record passes a sample of prediction requests and their responses to the recorder.
+func (r *SeldonRestApi) record(service string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if r.recorder == nil || service != metric.PredictionHttpServiceName || req.Method != http.MethodPost || !r.recorder.Sample() {
+			next(w, req)
+			return
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			r.respondWithError(w, nil, err)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		rw := &recordingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next(rw, req)
+		r.recorder.Record(record.NewExchange(req, body, rw.status, rw.body.Bytes(), time.Since(start)))
+	}
+}
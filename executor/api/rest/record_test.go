@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/record"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestRecord(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: "foo",
+				ServicePort: 9000,
+				Type:        v1.REST,
+			},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	recorder, err := record.NewRecorder(path, 1, 1024*1024, 1, "test", logf.Log)
+	g.Expect(err).To(BeNil())
+	url, _ := url.Parse("http://localhost")
+	r := NewServerRestApi(&p, &test.SeldonMessageTestClient{}, false, url, "default", api.ProtocolSeldon, "test", "/metrics", true, WithRecorder(recorder))
+	r.Initialise()
+
+	call := func(method string, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(`{"data":{"ndarray":[1.1,2.0]}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set(payload.SeldonPUIDHeader, "puid-1")
+		res := httptest.NewRecorder()
+		r.Router.ServeHTTP(res, req)
+		return res
+	}
+	g.Expect(call("POST", "/api/v1.0/predictions").Code).To(Equal(http.StatusOK))
+	// Only predictions are recorded
+	g.Expect(call("GET", "/api/v1.0/status/model").Code).To(Equal(http.StatusOK))
+	recorder.Close()
+
+	file, err := os.Open(path)
+	g.Expect(err).To(BeNil())
+	defer file.Close()
+	exchanges, err := record.ReadExchanges(file)
+	g.Expect(err).To(BeNil())
+	g.Expect(exchanges).To(HaveLen(1))
+	e := exchanges[0]
+	g.Expect(e.Puid).To(Equal("puid-1"))
+	g.Expect(e.Path).To(Equal("/api/v1.0/predictions"))
+	g.Expect(e.Status).To(Equal(http.StatusOK))
+	g.Expect(string(e.Request)).To(Equal(`{"data":{"ndarray":[1.1,2.0]}}`))
+	g.Expect(e.Response).ToNot(BeEmpty())
+	g.Expect(e.Headers).To(HaveKey("Content-Type"))
+	g.Expect(e.Headers).ToNot(HaveKey("Authorization"))
+}
+
+func TestRecordingResponseWriterFlush(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	res := httptest.NewRecorder()
+	var w http.ResponseWriter = &recordingResponseWriter{ResponseWriter: res, status: http.StatusOK}
+	_, err := w.Write([]byte("{}\n"))
+	g.Expect(err).To(BeNil())
+	flusher, ok := w.(http.Flusher)
+	g.Expect(ok).To(BeTrue())
+	flusher.Flush()
+	g.Expect(res.Flushed).To(BeTrue())
+	g.Expect(res.Body.String()).To(Equal("{}\n"))
+}
@@ -18,9 +18,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/auth"
 	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/idempotency"
 	"github.com/seldonio/seldon-core/executor/api/metric"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"github.com/seldonio/seldon-core/executor/api/record"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/predictor"
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -39,14 +44,34 @@ type SeldonRestApi struct {
 	metrics         *metric.ServerMetrics
 	prometheusPath  string
 	fullHealthCheck bool
+	// Number of lines of a batch request processed concurrently
+	batchConcurrency int
+	// Maximum size of the body of a batch request
+	batchMaxBytes int64
+	// Validates JWT bearer tokens if set
+	authenticator *auth.Authenticator
+	// Limits the rate of requests if set
+	rateLimiter *ratelimit.Limiter
+	// Records a sample of prediction requests if set
+	recorder *record.Recorder
+	// Logs a line for each request if set
+	accessLog bool
+	// Returns stored responses to requests repeating an idempotency key if set
+	deduplicator *idempotency.Deduplicator
+	// The OpenAPI spec generated for the active graph
+	openapiSpec openapiSpecCache
+	// Closed when websocket connections should be closed, which is once the executor starts shutting down if unset
+	drainStarted <-chan struct{}
 }
 
-func NewServerRestApi(predictor *v1.PredictorSpec, client client.SeldonApiClient, probesOnly bool, serverUrl *url.URL, namespace string, protocol string, deploymentName string, prometheusPath string, fullHealthCheck bool) *SeldonRestApi {
+type ServerRestApiOption func(r *SeldonRestApi)
+
+func NewServerRestApi(predictor *v1.PredictorSpec, client client.SeldonApiClient, probesOnly bool, serverUrl *url.URL, namespace string, protocol string, deploymentName string, prometheusPath string, fullHealthCheck bool, options ...ServerRestApiOption) *SeldonRestApi {
 	var serverMetrics *metric.ServerMetrics
 	if !probesOnly {
 		serverMetrics = metric.NewServerMetrics(predictor, deploymentName)
 	}
-	return &SeldonRestApi{
+	r := &SeldonRestApi{
 		mux.NewRouter(),
 		client,
 		predictor,
@@ -59,7 +84,22 @@ func NewServerRestApi(predictor *v1.PredictorSpec, client client.SeldonApiClient
 		serverMetrics,
 		prometheusPath,
 		fullHealthCheck,
+		DefaultBatchConcurrency,
+		DefaultBatchMaxBytes,
+		nil,
+		nil,
+		nil,
+		false,
+		nil,
+		openapiSpecCache{},
+		nil,
+	}
+
+	for _, option := range options {
+		option(r)
 	}
+
+	return r
 }
 
 func (r *SeldonRestApi) CreateHttpServer(port int) *http.Server {
@@ -93,11 +133,7 @@ func (r *SeldonRestApi) respondWithSuccess(w http.ResponseWriter, code int, payl
 
 func (r *SeldonRestApi) respondWithError(w http.ResponseWriter, payload payload.SeldonPayload, err error) {
 
-	if serr, ok := err.(*httpStatusError); ok {
-		w.WriteHeader(serr.StatusCode)
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
-	}
+	w.WriteHeader(errorStatusCode(err))
 
 	if payload != nil && payload.GetPayload() != nil {
 		w.Header().Set("Content-Type", payload.GetContentType())
@@ -116,6 +152,9 @@ func (r *SeldonRestApi) respondWithError(w http.ResponseWriter, payload payload.
 }
 
 func (r *SeldonRestApi) wrapMetrics(service string, baseHandler http.HandlerFunc) http.HandlerFunc {
+	// Authentication and rate limits are checked inside the metrics so rejected requests are counted.
+	// Rate limits are applied after authentication so clients can be identified by their claims.
+	baseHandler = r.trackInFlight(service, r.logAccess(service, r.authenticate(service, r.rateLimit(service, r.deduplicate(service, r.record(service, baseHandler))))))
 
 	handler := promhttp.InstrumentHandlerDuration(
 		r.metrics.ServerHandledHistogram.MustCurryWith(prometheus.Labels{
@@ -145,11 +184,13 @@ func (r *SeldonRestApi) Initialise() {
 	if !r.ProbesOnly {
 		cloudeventHeaderMiddleware := CloudeventHeaderMiddleware{deploymentName: r.DeploymentName, namespace: r.Namespace}
 		r.Router.Use(puidHeader)
+		r.Router.Use(clientIdentityHeader)
 		r.Router.Use(cloudeventHeaderMiddleware.Middleware)
 		r.Router.Use(xssMiddleware)
 		r.Router.Use(mux.CORSMethodMiddleware(r.Router))
 		r.Router.Use(handleCORSRequests)
 
+		r.Router.NewRoute().Path("/"+OpenAPIFileName).Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.openapi))
 		switch r.Protocol {
 		case api.ProtocolSeldon:
 			//v0.1 API
@@ -159,15 +200,19 @@ func (r *SeldonRestApi) Initialise() {
 			r.Router.NewRoute().Path("/api/v0.1/status/{"+ModelHttpPathVariable+"}").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.status))
 			r.Router.NewRoute().Path("/api/v0.1/metadata/{"+ModelHttpPathVariable+"}").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.metadata))
 
+			r.Router.NewRoute().Path("/api/v0.1/doc/"+OpenAPIFileName).Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.openapi))
 			r.Router.NewRoute().PathPrefix("/api/v0.1/doc/").Handler(http.StripPrefix("/api/v0.1/doc/", http.FileServer(http.Dir("./openapi/"))))
 			//v1.0 API
 			api10 := r.Router.PathPrefix("/api/v1.0").Methods("OPTIONS", "POST").Subrouter()
 			api10.Handle("/predictions", r.wrapMetrics(metric.PredictionHttpServiceName, r.predictions))
+			api10.Handle("/predictions:batch", r.wrapMetrics(metric.PredictionBatchHttpServiceName, r.predictionsBatch))
+			r.Router.NewRoute().Path("/api/v1.0/predictions/ws").Methods("GET").HandlerFunc(r.wrapMetrics(metric.PredictionWebsocketHttpServiceName, r.predictionsWebsocket))
 			api10.Handle("/feedback", r.wrapMetrics(metric.FeedbackHttpServiceName, r.feedback))
 			r.Router.NewRoute().Path("/api/v1.0/status/{"+ModelHttpPathVariable+"}").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.status))
 			r.Router.NewRoute().Path("/api/v1.0/status").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.checkReady))
 			r.Router.NewRoute().Path("/api/v1.0/metadata").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.graphMetadata))
 			r.Router.NewRoute().Path("/api/v1.0/metadata/{"+ModelHttpPathVariable+"}").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.metadata))
+			r.Router.NewRoute().Path("/api/v1.0/doc/"+OpenAPIFileName).Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.openapi))
 			r.Router.NewRoute().PathPrefix("/api/v1.0/doc/").Handler(http.StripPrefix("/api/v1.0/doc/", http.FileServer(http.Dir("./openapi/"))))
 			//health
 			r.Router.NewRoute().Path("/api/v1.0/health/status").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.checkReady))
@@ -182,10 +227,15 @@ func (r *SeldonRestApi) Initialise() {
 			// Enabling for standard seldon core feedback API endpoint with standard schema
 			r.Router.NewRoute().Path("/api/v1.0/feedback").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.FeedbackHttpServiceName, r.feedback))
 		case api.ProtocolV2, api.ProtocolKFServing:
+			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/infer:batch").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.PredictionBatchHttpServiceName, r.predictionsBatch))
+			r.Router.NewRoute().Path("/v2/models/infer:batch").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.PredictionBatchHttpServiceName, r.predictionsBatch)) // Nonstandard path - Seldon extension
+			r.Router.NewRoute().Path("/v2/models/{" + ModelHttpPathVariable + "}/infer/ws").Methods("GET").HandlerFunc(r.wrapMetrics(metric.PredictionWebsocketHttpServiceName, r.predictionsWebsocket))
+			r.Router.NewRoute().Path("/v2/models/infer/ws").Methods("GET").HandlerFunc(r.wrapMetrics(metric.PredictionWebsocketHttpServiceName, r.predictionsWebsocket)) // Nonstandard path - Seldon extension
 			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/infer").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.PredictionHttpServiceName, r.predictions))
 			r.Router.NewRoute().Path("/v2/models/infer").Methods("OPTIONS", "POST").HandlerFunc(r.wrapMetrics(metric.PredictionHttpServiceName, r.predictions)) // Nonstandard path - Seldon extension
 			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}/ready").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.status))
 			r.Router.NewRoute().Path("/v2/models/{"+ModelHttpPathVariable+"}").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.metadata))
+			r.Router.NewRoute().Path("/v2/docs/"+OpenAPIFileName).Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.MetadataHttpServiceName, r.openapi))
 			r.Router.NewRoute().PathPrefix("/v2/docs/").Handler(http.StripPrefix("/v2/docs/", http.FileServer(http.Dir("./openapi/open-inference/"))))
 			// Health
 			r.Router.NewRoute().Path("/v2/health/ready").Methods("GET", "OPTIONS").HandlerFunc(r.wrapMetrics(metric.StatusHttpServiceName, r.checkReady))
@@ -195,10 +245,11 @@ func (r *SeldonRestApi) Initialise() {
 }
 
 func (r *SeldonRestApi) checkReady(w http.ResponseWriter, req *http.Request) {
-	err := predictor.Ready(r.Protocol, &r.predictor.Graph, r.fullHealthCheck)
+	err := predictor.Ready(r.Protocol, &predictor.ActiveSpec(r.predictor).Graph, r.fullHealthCheck)
 	if err != nil {
 		r.Log.Error(err, "Ready check failed")
-		w.WriteHeader(http.StatusServiceUnavailable)
+		// The reason is given so probes show why, such as warm-up failing
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
 	} else {
 		w.WriteHeader(http.StatusOK)
 	}
@@ -212,6 +263,12 @@ func setupTracing(ctx context.Context, req *http.Request, spanName string) (cont
 	tracer := opentracing.GlobalTracer()
 	spanCtx, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
 	serverSpan := tracer.StartSpan(spanName, ext.RPCServerOption(spanCtx))
+	if identity := req.Header.Get(payload.SeldonClientIdentityHeader); identity != "" {
+		serverSpan.SetTag(tracing.ClientIdentityTag, identity)
+	}
+	if entry := accessLogEntryFrom(ctx); entry != nil {
+		entry.traceID = tracing.TraceID(serverSpan)
+	}
 	ctx = opentracing.ContextWithSpan(ctx, serverSpan)
 	return ctx, serverSpan
 }
@@ -230,7 +287,7 @@ func (r *SeldonRestApi) metadata(w http.ResponseWriter, req *http.Request) {
 	modelName := vars[ModelHttpPathVariable]
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
-	resPayload, err := seldonPredictorProcess.Metadata(&r.predictor.Graph, modelName, nil)
+	resPayload, err := seldonPredictorProcess.Metadata(&predictor.ActiveSpec(r.predictor).Graph, modelName, nil)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -252,7 +309,7 @@ func (r *SeldonRestApi) status(w http.ResponseWriter, req *http.Request) {
 	modelName := vars[ModelHttpPathVariable]
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, modelName)
-	resPayload, err := seldonPredictorProcess.Status(&r.predictor.Graph, modelName, nil)
+	resPayload, err := seldonPredictorProcess.Status(&predictor.ActiveSpec(r.predictor).Graph, modelName, nil)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -284,7 +341,7 @@ func (r *SeldonRestApi) feedback(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	resPayload, err := seldonPredictorProcess.Feedback(&r.predictor.Graph, reqPayload)
+	resPayload, err := seldonPredictorProcess.Feedback(&predictor.ActiveSpec(r.predictor).Graph, reqPayload)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -323,7 +380,7 @@ func (r *SeldonRestApi) predictions(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	resPayload, err := seldonPredictorProcess.Predict(&r.predictor.Graph, reqPayload)
+	resPayload, err := seldonPredictorProcess.Predict(&predictor.ActiveSpec(r.predictor).Graph, reqPayload)
 	if err != nil {
 		r.respondWithError(w, resPayload, err)
 		return
@@ -345,7 +402,7 @@ func (r *SeldonRestApi) graphMetadata(w http.ResponseWriter, req *http.Request)
 
 	seldonPredictorProcess := predictor.NewPredictorProcess(ctx, r.Client, logf.Log.WithName(LoggingRestClientName), r.ServerUrl, r.Namespace, req.Header, "")
 
-	graphMetadata, err := seldonPredictorProcess.GraphMetadata(r.predictor)
+	graphMetadata, err := seldonPredictorProcess.GraphMetadata(predictor.ActiveSpec(r.predictor))
 
 	if err != nil {
 		r.respondWithError(w, nil, err)
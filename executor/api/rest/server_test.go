@@ -304,6 +304,102 @@ func TestModelWithServer(t *testing.T) {
 	g.Expect(called).To(Equal(true))
 }
 
+func TestCrossProtocolModelName(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+	called := false
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bodyBytes, err := ioutil.ReadAll(r.Body)
+		g.Expect(err).To(BeNil())
+		// The node speaks the seldon protocol so isn't called on the v2 path of the requested model
+		g.Expect(r.URL.Path).To(Equal("/predict"))
+		g.Expect(string(bodyBytes)).To(ContainSubstring(`"ndarray"`))
+		called = true
+		w.Write([]byte(`{"data":{"names":["output"],"ndarray":[[0.9]]}}`))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	url, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	port, err := strconv.Atoi(url.Port())
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Type:     &model,
+			Protocol: v1.ProtocolSeldon,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: url.Hostname(),
+				ServicePort: int32(port),
+				Type:        v1.REST,
+				HttpPort:    int32(port),
+			},
+		},
+	}
+
+	client, err := NewJSONRestClient(api.ProtocolV2, "dep", &p, nil)
+	g.Expect(err).To(BeNil())
+	r := NewServerRestApi(&p, client, false, url, "default", api.ProtocolV2, "test", "/metrics", true)
+	r.Initialise()
+
+	var data = `{"inputs":[{"name":"input","datatype":"FP64","shape":[1,2],"data":[1.0,2.0]}]}`
+	req, _ := http.NewRequest("POST", "/v2/models/mymodel/infer", strings.NewReader(data))
+	req.Header = map[string][]string{"Content-Type": []string{"application/json"}, payload.SeldonPUIDHeader: []string{TestSeldonPuid}}
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200), res.Body.String())
+	g.Expect(called).To(Equal(true))
+	g.Expect(res.Body.String()).To(ContainSubstring(`"outputs"`))
+}
+
+func TestEndpointTLSModelName(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.URL.Path).To(Equal("/v2/models/mymodel/infer"))
+		w.Write([]byte(`{"outputs":[{"name":"output","datatype":"FP64","shape":[1,1],"data":[0.9]}]}`))
+	}))
+	defer server.Close()
+	url, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	port, err := strconv.Atoi(url.Port())
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	p := v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name: "model",
+			Type: &model,
+			Endpoint: &v1.Endpoint{
+				ServiceHost: url.Hostname(),
+				ServicePort: int32(port),
+				Type:        v1.REST,
+				HttpPort:    int32(port),
+				TLS:         &v1.EndpointTLS{InsecureSkipVerify: true},
+			},
+		},
+	}
+
+	client, err := NewJSONRestClient(api.ProtocolV2, "dep", &p, nil)
+	g.Expect(err).To(BeNil())
+	r := NewServerRestApi(&p, client, false, url, "default", api.ProtocolV2, "test", "/metrics", true)
+	r.Initialise()
+
+	var data = `{"inputs":[{"name":"input","datatype":"FP64","shape":[1,2],"data":[1.0,2.0]}]}`
+	req, _ := http.NewRequest("POST", "/v2/models/mymodel/infer", strings.NewReader(data))
+	req.Header = map[string][]string{"Content-Type": []string{"application/json"}, payload.SeldonPUIDHeader: []string{TestSeldonPuid}}
+	res := httptest.NewRecorder()
+	r.Router.ServeHTTP(res, req)
+	g.Expect(res.Code).To(Equal(200), res.Body.String())
+	g.Expect(res.Body.String()).To(ContainSubstring(`"outputs"`))
+}
+
 func TestServerMetrics(t *testing.T) {
 	t.Logf("Started")
 	g := NewGomegaWithT(t)
@@ -0,0 +1,185 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	"golang.org/x/net/http2"
+)
+
+const (
+	DefaultMaxIdleConnsPerHost = 100
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultDialTimeout         = 30 * time.Second
+	// Matches all graph nodes in the list of nodes using h2c
+	allNodes = "*"
+)
+
+// TransportOptions configures the HTTP connections from the executor to the nodes of the graph.
+type TransportOptions struct {
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DialTimeout         time.Duration
+	// How long to wait for the response headers after sending a request, with no limit if zero
+	ResponseHeaderTimeout time.Duration
+	// Nodes called with HTTP/2 over cleartext (h2c), where * matches all nodes
+	H2CNodes []string
+}
+
+// TransportOptionsFromAnnotations reads the transport options from the deployment's annotations.
+func TransportOptionsFromAnnotations(annotations map[string]string) (TransportOptions, error) {
+	options := TransportOptions{
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+		DialTimeout:         DefaultDialTimeout,
+	}
+	if val := annotations[k8s.ANNOTATION_REST_MAX_IDLE_CONNS]; val != "" {
+		i, err := strconv.Atoi(val)
+		// http.Transport treats 0 as its default of 2, which isn't enough to reuse connections under load
+		if err != nil || i < 1 {
+			return options, fmt.Errorf("invalid %s %q", k8s.ANNOTATION_REST_MAX_IDLE_CONNS, val)
+		}
+		options.MaxIdleConnsPerHost = i
+	}
+	for name, d := range map[string]*time.Duration{
+		k8s.ANNOTATION_REST_IDLE_CONN_TIMEOUT: &options.IdleConnTimeout,
+		k8s.ANNOTATION_REST_DIAL_TIMEOUT:      &options.DialTimeout,
+		k8s.ANNOTATION_REST_RESPONSE_TIMEOUT:  &options.ResponseHeaderTimeout,
+	} {
+		if val := annotations[name]; val != "" {
+			parsed, err := time.ParseDuration(val)
+			if err != nil || parsed < 0 {
+				return options, fmt.Errorf("invalid %s %q", name, val)
+			}
+			*d = parsed
+		}
+	}
+	for _, node := range strings.Split(annotations[k8s.ANNOTATION_REST_H2C], ",") {
+		if node = strings.TrimSpace(node); node != "" {
+			options.H2CNodes = append(options.H2CNodes, node)
+		}
+	}
+	return options, nil
+}
+
+// UseH2C returns whether a node is called with HTTP/2 over cleartext.
+func (o TransportOptions) UseH2C(modelName string) bool {
+	for _, node := range o.H2CNodes {
+		if node == allNodes || node == modelName {
+			return true
+		}
+	}
+	return false
+}
+
+// transportMetrics track the use of the connections to graph nodes.
+type transportMetrics struct {
+	open        prometheus.Gauge
+	connections *prometheus.CounterVec
+}
+
+func newTransportMetrics(deploymentName string) *transportMetrics {
+	open := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metric.ClientOpenConnectionsMetricName,
+		Help: "Open HTTP connections from the executor to graph nodes",
+	}, []string{metric.DeploymentNameMetric})
+	if err := prometheus.Register(open); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			open = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+	connections := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metric.ClientConnectionsMetricName,
+		Help: "HTTP connections obtained for calls to graph nodes, by whether they were reused from the pool",
+	}, []string{metric.DeploymentNameMetric, metric.ModelNameMetric, metric.ConnectionReusedMetric})
+	if err := prometheus.Register(connections); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			connections = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	labels := prometheus.Labels{metric.DeploymentNameMetric: deploymentName}
+	return &transportMetrics{
+		open:        open.With(labels),
+		connections: connections.MustCurryWith(labels),
+	}
+}
+
+// trace adds a trace to the request counting whether its connection was reused.
+func (m *transportMetrics) trace(req *http.Request, modelName string) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			m.connections.WithLabelValues(modelName, strconv.FormatBool(info.Reused)).Inc()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// dialContext dials connections which are counted while open.
+func (m *transportMetrics) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		m.open.Inc()
+		return &countedConn{Conn: conn, closed: m.open.Dec}, nil
+	}
+}
+
+type countedConn struct {
+	net.Conn
+	once   sync.Once
+	closed func()
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(c.closed)
+	return c.Conn.Close()
+}
+
+// dialContext returns a function dialing the requested address, or a unix socket if a path is given.
+func (o TransportOptions) dialContext(unixSocket string, metrics *transportMetrics) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dial := metrics.dialContext(&net.Dialer{Timeout: o.DialTimeout, KeepAlive: 30 * time.Second})
+	if unixSocket == "" {
+		return dial
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dial(ctx, "unix", unixSocket)
+	}
+}
+
+// newTransport creates a transport using TLS if a config is given, connecting over a unix socket if a path
+// is given.
+func (o TransportOptions) newTransport(tlsConfig *tls.Config, unixSocket string, metrics *transportMetrics) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = o.dialContext(unixSocket, metrics)
+	transport.MaxIdleConns = 0
+	transport.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = o.IdleConnTimeout
+	transport.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	transport.TLSClientConfig = tlsConfig
+	return transport
+}
+
+// newH2CTransport creates a transport speaking HTTP/2 over cleartext connections, which are multiplexed
+// so a single connection is kept to each host. Connections are made over a unix socket if a path is given.
+func (o TransportOptions) newH2CTransport(unixSocket string, metrics *transportMetrics) *http2.Transport {
+	dial := o.dialContext(unixSocket, metrics)
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/k8s"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestTransportOptionsFromAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	options, err := TransportOptionsFromAnnotations(nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(options).To(Equal(TransportOptions{
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     DefaultIdleConnTimeout,
+		DialTimeout:         DefaultDialTimeout,
+	}))
+
+	options, err = TransportOptionsFromAnnotations(map[string]string{
+		k8s.ANNOTATION_REST_MAX_IDLE_CONNS:    "20",
+		k8s.ANNOTATION_REST_IDLE_CONN_TIMEOUT: "1m",
+		k8s.ANNOTATION_REST_DIAL_TIMEOUT:      "2s",
+		k8s.ANNOTATION_REST_RESPONSE_TIMEOUT:  "10s",
+		k8s.ANNOTATION_REST_H2C:               "model-a, model-b",
+	})
+	g.Expect(err).To(BeNil())
+	g.Expect(options).To(Equal(TransportOptions{
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       time.Minute,
+		DialTimeout:           2 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		H2CNodes:              []string{"model-a", "model-b"},
+	}))
+	g.Expect(options.UseH2C("model-b")).To(BeTrue())
+	g.Expect(options.UseH2C("model-c")).To(BeFalse())
+	g.Expect(TransportOptions{H2CNodes: []string{"*"}}.UseH2C("model-c")).To(BeTrue())
+
+	_, err = TransportOptionsFromAnnotations(map[string]string{k8s.ANNOTATION_REST_MAX_IDLE_CONNS: "many"})
+	g.Expect(err).ToNot(BeNil())
+	_, err = TransportOptionsFromAnnotations(map[string]string{k8s.ANNOTATION_REST_MAX_IDLE_CONNS: "0"})
+	g.Expect(err).ToNot(BeNil())
+	_, err = TransportOptionsFromAnnotations(map[string]string{k8s.ANNOTATION_REST_DIAL_TIMEOUT: "-1s"})
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestH2CTransport(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var protos []string
+	server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protos = append(protos, r.Proto)
+		w.Write([]byte(okPredictResponse))
+	}), &http2.Server{}))
+	defer server.Close()
+	serverUrl, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	port, err := strconv.Atoi(serverUrl.Port())
+	g.Expect(err).Should(BeNil())
+
+	model := v1.MODEL
+	predictor := v1.PredictorSpec{
+		Name:        "test",
+		Annotations: map[string]string{},
+		Graph: v1.PredictiveUnit{
+			Name: "h2c-model",
+			Type: &model,
+		},
+	}
+	seldonRestClient, err := NewJSONRestClient(api.ProtocolSeldon, "h2c-test", &predictor, map[string]string{k8s.ANNOTATION_REST_H2C: "h2c-model"})
+	g.Expect(err).To(BeNil())
+	metrics := seldonRestClient.(*JSONRestClient).transportMetrics
+
+	for i := 0; i < 2; i++ {
+		for _, modelName := range []string{"h2c-model", "other"} {
+			resPayload, err := seldonRestClient.Predict(createTestContext(), modelName, serverUrl.Hostname(), int32(port), createPayload(g), map[string][]string{})
+			g.Expect(err).Should(BeNil())
+			g.Expect(string(resPayload.GetPayload().([]byte))).To(Equal(okPredictResponse))
+		}
+	}
+	g.Expect(protos).To(Equal([]string{"HTTP/2.0", "HTTP/1.1", "HTTP/2.0", "HTTP/1.1"}))
+
+	// Nodes are called with h2c when the request names another model
+	_, err = seldonRestClient.Predict(client.WithNodeName(createTestContext(), "h2c-model"), "mymodel", serverUrl.Hostname(), int32(port), createPayload(g), map[string][]string{})
+	g.Expect(err).Should(BeNil())
+	g.Expect(protos[len(protos)-1]).To(Equal("HTTP/2.0"))
+
+	// Connections are kept open and reused
+	g.Expect(testutil.ToFloat64(metrics.open)).To(Equal(2.0))
+	g.Expect(testutil.ToFloat64(metrics.connections.WithLabelValues("other", "false"))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.connections.WithLabelValues("other", "true"))).To(Equal(1.0))
+}
@@ -0,0 +1,192 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/util"
+	"github.com/seldonio/seldon-core/executor/predictor"
+)
+
+const (
+	// Maximum number of requests from a single connection being processed at once.
+	// Reading further messages blocks until a slot is free.
+	websocketMaxInFlight = 64
+	// How long to wait to send the close frame when the executor starts shutting down
+	websocketCloseTimeout = time.Second
+)
+
+// WebsocketRequest is a single inference request sent over a websocket connection.
+// Payload is a seldon or v2 JSON request depending on the deployment's protocol.
+type WebsocketRequest struct {
+	Id      string          `json:"id"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// WebsocketResponse is returned for every WebsocketRequest and carries the same Id.
+// Status follows the HTTP status code the equivalent REST call would have returned.
+type WebsocketResponse struct {
+	Id      string          `json:"id"`
+	Puid    string          `json:"puid,omitempty"`
+	Status  int             `json:"status"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// websocketCheckOrigin accepts connections from the origins allowed for CORS requests.
+func websocketCheckOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	allowed := util.GetEnv(corsAllowOriginEnvVar, corsAllowOriginValueAll)
+	for _, o := range strings.Split(allowed, ",") {
+		o = strings.TrimSpace(o)
+		if o == corsAllowOriginValueAll || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+var websocketUpgrader = websocket.Upgrader{
+	CheckOrigin: websocketCheckOrigin,
+}
+
+type websocketConn struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (c *websocketConn) writeResponse(res *WebsocketResponse) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(res)
+}
+
+// writeClose sends a close frame telling the client why the connection is being closed.
+func (c *websocketConn) writeClose(code int, text string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(websocketCloseTimeout))
+}
+
+func (r *SeldonRestApi) websocketResponse(id string, puid string, resPayload payload.SeldonPayload, err error) *WebsocketResponse {
+	status := http.StatusOK
+	if err != nil {
+		status = errorStatusCode(err)
+		if resPayload == nil || resPayload.GetPayload() == nil {
+			resPayload = r.Client.CreateErrorPayload(err)
+		}
+	}
+	out, err := compactJSONPayload(resPayload)
+	if err != nil {
+		status = http.StatusInternalServerError
+		out, _ = compactJSONPayload(r.Client.CreateErrorPayload(err))
+	}
+	return &WebsocketResponse{Id: id, Puid: puid, Status: status, Payload: out}
+}
+
+func (r *SeldonRestApi) predictionsWebsocket(w http.ResponseWriter, req *http.Request) {
+	r.Log.V(1).Info("Websocket predictions called")
+
+	conn, err := websocketUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		// Upgrade has already replied with an error
+		r.Log.Error(err, "Failed to upgrade websocket connection")
+		return
+	}
+	wsConn := &websocketConn{conn: conn}
+	defer conn.Close()
+
+	ctx := req.Context()
+	vars := mux.Vars(req)
+	modelName := vars[ModelHttpPathVariable]
+
+	sem := make(chan struct{}, websocketMaxInFlight)
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	messages := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go r.readWebsocketMessages(conn, messages, done)
+	drainStarted := r.drainStarted
+	if drainStarted == nil {
+		drainStarted = predictor.DrainStarted()
+	}
+	for {
+		var msg []byte
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			msg = m
+		case <-drainStarted:
+			// Answer the messages being processed before telling the client to reconnect to another replica
+			wg.Wait()
+			if err := wsConn.writeClose(websocket.CloseGoingAway, "server shutting down"); err != nil {
+				r.Log.Error(err, "Failed to close websocket connection")
+			}
+			return
+		}
+
+		wsReq := WebsocketRequest{}
+		if err := json.Unmarshal(msg, &wsReq); err != nil || len(wsReq.Payload) == 0 {
+			res := r.websocketResponse(wsReq.Id, "", nil, invalidPayload("websocket messages must be of the form {\"id\": ..., \"payload\": ...}"))
+			res.Status = http.StatusBadRequest
+			if err := wsConn.writeResponse(res); err != nil {
+				r.Log.Error(err, "Failed to write websocket response")
+				return
+			}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		// Each message is in flight until answered, rather than the connection, so shutdown isn't held up by idle connections
+		untrack := predictor.TrackRequest(metric.PredictionWebsocketHttpServiceName+" "+req.URL.Path, req.Header.Get(payload.SeldonPUIDHeader))
+		go func(wsReq WebsocketRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer untrack()
+			puid, resPayload, err := r.predictWithNewPuid(ctx, req.Header, modelName, wsReq.Payload)
+			if err != nil {
+				r.Log.Error(err, "Websocket prediction failed", "id", wsReq.Id, "puid", puid)
+			}
+			if err := wsConn.writeResponse(r.websocketResponse(wsReq.Id, puid, resPayload, err)); err != nil {
+				r.Log.Error(err, "Failed to write websocket response")
+			}
+		}(wsReq)
+	}
+}
+
+// readWebsocketMessages passes on the messages read from conn until reading fails or done is closed.
+func (r *SeldonRestApi) readWebsocketMessages(conn *websocket.Conn, messages chan<- []byte, done <-chan struct{}) {
+	defer close(messages)
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-done:
+				// The connection was closed by the handler
+			default:
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					r.Log.Error(err, "Failed to read websocket message")
+				}
+			}
+			return
+		}
+		select {
+		case messages <- msg:
+		case <-done:
+			return
+		}
+	}
+}
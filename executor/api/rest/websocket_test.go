@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/predictor"
+)
+
+func TestPredictionsWebsocket(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	r, closer := createBatchTestServer(g, api.ProtocolSeldon)
+	defer closer()
+	server := httptest.NewServer(r.Router)
+	defer server.Close()
+
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1.0/predictions/ws"
+	conn, res, err := websocket.DefaultDialer.Dial(wsUrl, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+	defer conn.Close()
+
+	const numRequests = 5
+	for i := 0; i < numRequests; i++ {
+		msg := fmt.Sprintf(`{"id":"req-%d","payload":{"data":{"ndarray":[%d]}}}`, i, i)
+		if i == 2 {
+			msg = `{"id":"req-2","payload":{"data":{"strData":"fail"}}}`
+		}
+		g.Expect(conn.WriteMessage(websocket.TextMessage, []byte(msg))).To(BeNil())
+	}
+	g.Expect(conn.WriteMessage(websocket.TextMessage, []byte(`not json`))).To(BeNil())
+
+	responses := map[string]WebsocketResponse{}
+	for i := 0; i < numRequests+1; i++ {
+		wsRes := WebsocketResponse{}
+		g.Expect(conn.ReadJSON(&wsRes)).To(BeNil())
+		responses[wsRes.Id] = wsRes
+	}
+
+	g.Expect(responses).To(HaveLen(numRequests + 1))
+	for i := 0; i < numRequests; i++ {
+		wsRes := responses[fmt.Sprintf("req-%d", i)]
+		g.Expect(wsRes.Puid).ToNot(BeEmpty())
+		if i == 2 {
+			g.Expect(wsRes.Status).To(Equal(http.StatusBadRequest))
+			continue
+		}
+		g.Expect(wsRes.Status).To(Equal(http.StatusOK))
+		body := map[string]interface{}{}
+		g.Expect(json.Unmarshal(wsRes.Payload, &body)).To(BeNil())
+		g.Expect(body["meta"].(map[string]interface{})["puid"]).To(Equal(wsRes.Puid))
+	}
+	g.Expect(responses[""].Status).To(Equal(http.StatusBadRequest))
+}
+
+func TestPredictionsWebsocketDrain(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r, closer := createBatchTestServer(g, api.ProtocolSeldon)
+	defer closer()
+	drainStarted := make(chan struct{})
+	r.drainStarted = drainStarted
+	server := httptest.NewServer(r.Router)
+	defer server.Close()
+
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1.0/predictions/ws"
+	conn, _, err := websocket.DefaultDialer.Dial(wsUrl, nil)
+	g.Expect(err).To(BeNil())
+	defer conn.Close()
+
+	g.Expect(conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"req-1","payload":{"data":{"ndarray":[1]}}}`))).To(BeNil())
+	wsRes := WebsocketResponse{}
+	g.Expect(conn.ReadJSON(&wsRes)).To(BeNil())
+	g.Expect(wsRes.Status).To(Equal(http.StatusOK))
+
+	// Idle connections don't hold up shutdown
+	g.Expect(predictor.InFlight()).To(BeEmpty())
+
+	close(drainStarted)
+	_, _, err = conn.ReadMessage()
+	g.Expect(websocket.IsCloseError(err, websocket.CloseGoingAway)).To(BeTrue())
+}
+
+func TestPredictionsWebsocketOrigin(t *testing.T) {
+	t.Logf("Started")
+	g := NewGomegaWithT(t)
+
+	os.Setenv(corsAllowOriginEnvVar, "http://allowed.example.com")
+	defer os.Unsetenv(corsAllowOriginEnvVar)
+
+	r, closer := createBatchTestServer(g, api.ProtocolV2)
+	defer closer()
+	server := httptest.NewServer(r.Router)
+	defer server.Close()
+
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http") + "/v2/models/model/infer/ws"
+	_, res, err := websocket.DefaultDialer.Dial(wsUrl, http.Header{"Origin": []string{"http://other.example.com"}})
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(res.StatusCode).To(Equal(http.StatusForbidden))
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsUrl, http.Header{"Origin": []string{"http://allowed.example.com"}})
+	g.Expect(err).To(BeNil())
+	conn.Close()
+}
@@ -0,0 +1,186 @@
+package stub
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/golang/protobuf/jsonpb"
+	protoV1 "github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/proto/tensorflow/serving"
+)
+
+const statusResponse = `{"status":"ok"}`
+
+// Client answers calls to stub nodes in-process and passes calls to all other nodes to the wrapped client.
+type Client struct {
+	client.SeldonApiClient
+	Protocol string
+	models   map[string]*Model
+}
+
+// NewClient wraps a client so calls to the given stubs are answered in-process.
+func NewClient(wrapped client.SeldonApiClient, protocol string, models map[string]*Model) *Client {
+	return &Client{
+		SeldonApiClient: wrapped,
+		Protocol:        protocol,
+		models:          models,
+	}
+}
+
+// toPayload converts a JSON response to a payload of the type the wrapped client would return.
+func (c *Client) toPayload(b []byte) (payload.SeldonPayload, error) {
+	if !c.IsGrpc() {
+		return &payload.BytesPayload{Msg: b, ContentType: "application/json"}, nil
+	}
+	var msg protoV1.Message
+	switch c.Protocol {
+	case api.ProtocolSeldon:
+		msg = &proto.SeldonMessage{}
+	case api.ProtocolTensorflow:
+		msg = &serving.PredictResponse{}
+	case api.ProtocolV2, api.ProtocolKFServing:
+		msg = &inference.ModelInferResponse{}
+	default:
+		return nil, errors.Errorf("unknown protocol %s", c.Protocol)
+	}
+	if err := jsonpb.UnmarshalString(string(b), msg); err != nil {
+		return nil, errors.Wrap(err, "invalid stub response")
+	}
+	return &payload.ProtoPayload{Msg: msg}, nil
+}
+
+// respond answers a call to a stub, echoing msg if the stub does so.
+func (c *Client) respond(ctx context.Context, model *Model, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	if err := model.wait(ctx); err != nil {
+		return nil, err
+	}
+	switch model.Behaviour {
+	case BehaviourConstant:
+		return c.toPayload(model.Response)
+	case BehaviourRandom:
+		b, err := model.randomResponse(c.Protocol, c.IsGrpc())
+		if err != nil {
+			return nil, err
+		}
+		return c.toPayload(b)
+	default:
+		return msg, nil
+	}
+}
+
+// model returns the stub for the node being called, which may differ from modelName when the graph overrides model names.
+func (c *Client) model(ctx context.Context, modelName string) (*Model, bool) {
+	model, ok := c.models[client.NodeName(ctx, modelName)]
+	return model, ok
+}
+
+func (c *Client) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if model, ok := c.model(ctx, modelName); ok {
+		return c.respond(ctx, model, msg)
+	}
+	return c.SeldonApiClient.Predict(ctx, modelName, host, port, msg, meta)
+}
+
+func (c *Client) TransformInput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if model, ok := c.model(ctx, modelName); ok {
+		return c.respond(ctx, model, msg)
+	}
+	return c.SeldonApiClient.TransformInput(ctx, modelName, host, port, msg, meta)
+}
+
+func (c *Client) TransformOutput(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if model, ok := c.model(ctx, modelName); ok {
+		return c.respond(ctx, model, msg)
+	}
+	return c.SeldonApiClient.TransformOutput(ctx, modelName, host, port, msg, meta)
+}
+
+func (c *Client) Route(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (int, error) {
+	if model, ok := c.model(ctx, modelName); ok {
+		if err := model.wait(ctx); err != nil {
+			return 0, err
+		}
+		return model.route(), nil
+	}
+	return c.SeldonApiClient.Route(ctx, modelName, host, port, msg, meta)
+}
+
+// Combine echoes the first of the messages for stubs which echo their input.
+func (c *Client) Combine(ctx context.Context, modelName string, host string, port int32, msgs []payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if model, ok := c.model(ctx, modelName); ok {
+		if len(msgs) == 0 {
+			return nil, errors.Errorf("stub %s has no messages to combine", modelName)
+		}
+		return c.respond(ctx, model, msgs[0])
+	}
+	return c.SeldonApiClient.Combine(ctx, modelName, host, port, msgs, meta)
+}
+
+func (c *Client) Feedback(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if model, ok := c.model(ctx, modelName); ok {
+		if err := model.wait(ctx); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+	return c.SeldonApiClient.Feedback(ctx, modelName, host, port, msg, meta)
+}
+
+func (c *Client) Status(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	if _, ok := c.model(ctx, modelName); ok && !c.IsGrpc() {
+		return &payload.BytesPayload{Msg: []byte(statusResponse), ContentType: "application/json"}, nil
+	}
+	return c.SeldonApiClient.Status(ctx, modelName, host, port, msg, meta)
+}
+
+func (c *Client) Metadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	model, ok := c.model(ctx, modelName)
+	if !ok {
+		return c.SeldonApiClient.Metadata(ctx, modelName, host, port, msg, meta)
+	}
+	b, err := json.Marshal(model.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsGrpc() {
+		return &payload.BytesPayload{Msg: b, ContentType: "application/json"}, nil
+	}
+	if c.Protocol != api.ProtocolSeldon {
+		return nil, errors.Errorf("stub metadata is not supported over gRPC for protocol %s", c.Protocol)
+	}
+	metadata := &proto.SeldonModelMetadata{}
+	if err := jsonpb.UnmarshalString(string(b), metadata); err != nil {
+		return nil, errors.Wrapf(err, "invalid metadata for stub %s", modelName)
+	}
+	return &payload.ProtoPayload{Msg: metadata}, nil
+}
+
+func (c *Client) ModelMetadata(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.ModelMetadata, error) {
+	model, ok := c.model(ctx, modelName)
+	if !ok {
+		return c.SeldonApiClient.ModelMetadata(ctx, modelName, host, port, msg, meta)
+	}
+	if !(c.IsGrpc() && c.Protocol == api.ProtocolSeldon) {
+		return model.Metadata, nil
+	}
+	// The seldon gRPC server expects metadata decoded from protobuf
+	resPayload, err := c.Metadata(ctx, modelName, host, port, msg, meta)
+	if err != nil {
+		return payload.ModelMetadata{}, err
+	}
+	metadata := resPayload.GetPayload().(*proto.SeldonModelMetadata)
+	return payload.ModelMetadata{
+		Name:     metadata.GetName(),
+		Platform: metadata.GetPlatform(),
+		Versions: metadata.GetVersions(),
+		Inputs:   metadata.GetInputs(),
+		Outputs:  metadata.GetOutputs(),
+		Custom:   metadata.GetCustom(),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package stub
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	"github.com/seldonio/seldon-core/executor/proto/tensorflow/serving"
+)
+
+func TestClientPassesThrough(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := NewClient(test.SeldonMessageTestClient{ChosenRoute: 1}, api.ProtocolSeldon, map[string]*Model{
+		"stub": {Name: "stub", Behaviour: BehaviourEcho, Children: 2},
+	})
+	route, err := c.Route(context.Background(), "stub", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(route).To(Equal(0))
+	route, err = c.Route(context.Background(), "model", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(route).To(Equal(1))
+
+	msg := &payload.ProtoPayload{Msg: &proto.SeldonMessage{}}
+	res, err := c.Predict(context.Background(), "stub", "", 0, msg, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res).To(Equal(msg))
+}
+
+func TestClientGrpcPayloads(t *testing.T) {
+	g := NewGomegaWithT(t)
+	models := map[string]*Model{"stub": {Name: "stub", Behaviour: BehaviourRandom}}
+
+	res, err := NewClient(test.SeldonMessageTestClient{}, api.ProtocolSeldon, models).Predict(context.Background(), "stub", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res.GetPayload().(*proto.SeldonMessage).GetData().GetTensor().GetValues()).To(HaveLen(1))
+
+	res, err = NewClient(test.SeldonMessageTestClient{}, api.ProtocolTensorflow, models).Predict(context.Background(), "stub", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res.GetPayload().(*serving.PredictResponse).Outputs[defaultOutputName].GetDoubleVal()).To(HaveLen(1))
+
+	res, err = NewClient(test.SeldonMessageTestClient{}, api.ProtocolV2, models).Predict(context.Background(), "stub", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res.GetPayload().(*inference.ModelInferResponse).Outputs[0].GetContents().GetFp64Contents()).To(HaveLen(1))
+
+	models["stub"] = &Model{Name: "stub", Behaviour: BehaviourConstant, Response: []byte(`{"strData":"constant"}`)}
+	res, err = NewClient(test.SeldonMessageTestClient{}, api.ProtocolSeldon, models).TransformInput(context.Background(), "stub", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(res.GetPayload().(*proto.SeldonMessage).GetStrData()).To(Equal("constant"))
+}
+
+func TestClientStubByNodeName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	c := NewClient(test.SeldonMessageTestClient{ChosenRoute: 1}, api.ProtocolSeldon, map[string]*Model{
+		"stub": {Name: "stub", Behaviour: BehaviourEcho, Children: 2},
+	})
+	route, err := c.Route(client.WithNodeName(context.Background(), "stub"), "override", "", 0, nil, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(route).To(Equal(0))
+}
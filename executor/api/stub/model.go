@@ -0,0 +1,248 @@
+package stub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// Node parameters configuring a stub
+const (
+	ParameterBehaviour = "stub"
+	ParameterResponse  = "stub_response"
+	ParameterMetadata  = "stub_metadata"
+	ParameterLatency   = "stub_latency"
+	ParameterErrorRate = "stub_error_rate"
+)
+
+// Behaviours of a stub
+const (
+	BehaviourEcho     = "echo"
+	BehaviourConstant = "constant"
+	BehaviourRandom   = "random"
+	BehaviourError    = "error"
+)
+
+const defaultOutputName = "output-0"
+
+// InjectedError is returned by a stub failing a request on purpose.
+type InjectedError struct {
+	Node string
+}
+
+func (e *InjectedError) Error() string {
+	return fmt.Sprintf("stub %s failed the request", e.Node)
+}
+
+// Model is an in-process fake of a graph node, answering the calls the executor makes to it.
+type Model struct {
+	Name      string
+	Behaviour string
+	// Response returned by constant stubs, in the JSON format of the transport used to call the node
+	Response []byte
+	// Metadata returned by the stub, whose first output sets the shape of random responses
+	Metadata  payload.ModelMetadata
+	Latency   time.Duration
+	ErrorRate float64
+	Children  int
+}
+
+// ModelsFromGraph returns the stubs configured by the parameters of the graph's nodes, keyed by node name.
+// Stub nodes without an endpoint are given an empty one as the executor walks the graph as if they were remote.
+func ModelsFromGraph(node *v1.PredictiveUnit) (map[string]*Model, error) {
+	models := map[string]*Model{}
+	for _, pu := range v1.GetPredictiveUnitList(node) {
+		model, err := newModel(pu)
+		if err != nil {
+			return nil, err
+		}
+		if model != nil {
+			models[pu.Name] = model
+			if pu.Endpoint == nil {
+				pu.Endpoint = &v1.Endpoint{}
+			}
+		}
+	}
+	return models, nil
+}
+
+func newModel(pu *v1.PredictiveUnit) (*Model, error) {
+	params := map[string]string{}
+	for _, param := range pu.Parameters {
+		params[param.Name] = param.Value
+	}
+	behaviour, ok := params[ParameterBehaviour]
+	if !ok {
+		return nil, nil
+	}
+	model := &Model{
+		Name:      pu.Name,
+		Behaviour: behaviour,
+		Metadata:  payload.ModelMetadata{Name: pu.Name},
+		Children:  len(pu.Children),
+	}
+	switch behaviour {
+	case BehaviourEcho, BehaviourRandom:
+	case BehaviourConstant:
+		response, ok := params[ParameterResponse]
+		if !ok {
+			return nil, errors.Errorf("stub %s is %s but has no %s parameter", pu.Name, behaviour, ParameterResponse)
+		}
+		model.Response = []byte(response)
+	case BehaviourError:
+		model.ErrorRate = 1
+	default:
+		return nil, errors.Errorf("stub %s has unknown behaviour %s", pu.Name, behaviour)
+	}
+	if metadata, ok := params[ParameterMetadata]; ok {
+		if err := json.Unmarshal([]byte(metadata), &model.Metadata); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s for stub %s", ParameterMetadata, pu.Name)
+		}
+	}
+	if latency, ok := params[ParameterLatency]; ok {
+		d, err := time.ParseDuration(latency)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s for stub %s", ParameterLatency, pu.Name)
+		}
+		model.Latency = d
+	}
+	if errorRate, ok := params[ParameterErrorRate]; ok {
+		rate, err := strconv.ParseFloat(errorRate, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, errors.Errorf("invalid %s %s for stub %s", ParameterErrorRate, errorRate, pu.Name)
+		}
+		model.ErrorRate = rate
+	}
+	return model, nil
+}
+
+// wait injects the stub's latency and errors into a call.
+func (m *Model) wait(ctx context.Context) error {
+	if m.Latency > 0 {
+		select {
+		case <-time.After(m.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if m.ErrorRate > 0 && rand.Float64() < m.ErrorRate {
+		return &InjectedError{Node: m.Name}
+	}
+	return nil
+}
+
+// route returns the child a router stub sends a request to.
+func (m *Model) route() int {
+	if m.Behaviour == BehaviourRandom && m.Children > 0 {
+		return rand.Intn(m.Children)
+	}
+	return 0
+}
+
+// outputShape returns the shape of random responses, with variable dimensions set to 1.
+func (m *Model) outputShape() (string, []int) {
+	name := defaultOutputName
+	shape := []int{1, 1}
+	if outputs, ok := m.Metadata.Outputs.([]interface{}); ok && len(outputs) > 0 {
+		if output, ok := outputs[0].(map[string]interface{}); ok {
+			if n, ok := output["name"].(string); ok && n != "" {
+				name = n
+			}
+			if dims, ok := output["shape"].([]interface{}); ok && len(dims) > 0 {
+				shape = make([]int, len(dims))
+				for i, dim := range dims {
+					shape[i] = 1
+					if size, ok := dim.(float64); ok && size > 0 {
+						shape[i] = int(size)
+					}
+				}
+			}
+		}
+	}
+	return name, shape
+}
+
+// randomResponse returns a response of random values in the JSON format of the protocol and transport.
+func (m *Model) randomResponse(protocol string, grpc bool) ([]byte, error) {
+	name, shape := m.outputShape()
+	size := 1
+	for _, dim := range shape {
+		size *= dim
+	}
+	values := make([]float64, size)
+	for i := range values {
+		values[i] = rand.Float64()
+	}
+
+	var response interface{}
+	switch protocol {
+	case api.ProtocolSeldon:
+		response = map[string]interface{}{
+			"data": map[string]interface{}{
+				"tensor": map[string]interface{}{"shape": shape, "values": values},
+			},
+		}
+	case api.ProtocolTensorflow:
+		if grpc {
+			dims := make([]map[string]int, len(shape))
+			for i, dim := range shape {
+				dims[i] = map[string]int{"size": dim}
+			}
+			response = map[string]interface{}{
+				"outputs": map[string]interface{}{
+					name: map[string]interface{}{"dtype": "DT_DOUBLE", "tensorShape": map[string]interface{}{"dim": dims}, "doubleVal": values},
+				},
+			}
+		} else {
+			response = map[string]interface{}{"predictions": nest(values, shape)}
+		}
+	case api.ProtocolV2, api.ProtocolKFServing:
+		output := map[string]interface{}{"name": name, "datatype": "FP64", "shape": shape}
+		if grpc {
+			output["contents"] = map[string]interface{}{"fp64Contents": values}
+			response = map[string]interface{}{"modelName": m.Name, "outputs": []interface{}{output}}
+		} else {
+			output["data"] = values
+			response = map[string]interface{}{"model_name": m.Name, "outputs": []interface{}{output}}
+		}
+	default:
+		return nil, errors.Errorf("unknown protocol %s", protocol)
+	}
+	return json.Marshal(response)
+}
+
+// nest arranges flat values into nested arrays of the given shape.
+func nest(values []float64, shape []int) interface{} {
+	if len(shape) <= 1 {
+		return values
+	}
+	step := len(values) / shape[0]
+	nested := make([]interface{}, shape[0])
+	for i := range nested {
+		nested[i] = nest(values[i*step:(i+1)*step], shape[1:])
+	}
+	return nested
+}
+
+func (m *Model) String() string {
+	var opts []string
+	if m.Latency > 0 {
+		opts = append(opts, "latency="+m.Latency.String())
+	}
+	if m.ErrorRate > 0 && m.Behaviour != BehaviourError {
+		opts = append(opts, fmt.Sprintf("errorRate=%g", m.ErrorRate))
+	}
+	if len(opts) == 0 {
+		return m.Behaviour
+	}
+	return m.Behaviour + "(" + strings.Join(opts, ",") + ")"
+}
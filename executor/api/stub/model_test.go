@@ -0,0 +1,97 @@
+package stub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestModelsFromGraph(t *testing.T) {
+	g := NewGomegaWithT(t)
+	model := v1.MODEL
+	graph := v1.PredictiveUnit{
+		Name: "router",
+		Parameters: []v1.Parameter{
+			{Name: ParameterBehaviour, Value: BehaviourRandom},
+			{Name: ParameterLatency, Value: "10ms"},
+			{Name: ParameterErrorRate, Value: "0.5"},
+		},
+		Children: []v1.PredictiveUnit{
+			{Name: "a", Type: &model, Parameters: []v1.Parameter{{Name: ParameterBehaviour, Value: BehaviourConstant}, {Name: ParameterResponse, Value: `{"data":{"ndarray":[1]}}`}}},
+			{Name: "b", Type: &model, Endpoint: &v1.Endpoint{ServiceHost: "localhost", HttpPort: 9000}},
+		},
+	}
+	models, err := ModelsFromGraph(&graph)
+	g.Expect(err).To(BeNil())
+	g.Expect(models).To(HaveLen(2))
+	g.Expect(models["router"].Latency).To(Equal(10 * time.Millisecond))
+	g.Expect(models["router"].ErrorRate).To(Equal(0.5))
+	g.Expect(models["router"].Children).To(Equal(2))
+	g.Expect(string(models["a"].Response)).To(Equal(`{"data":{"ndarray":[1]}}`))
+	// Stubs are given an endpoint so the graph can be walked, other nodes are left alone
+	g.Expect(graph.Endpoint).ToNot(BeNil())
+	g.Expect(graph.Children[0].Endpoint).ToNot(BeNil())
+	g.Expect(graph.Children[1].Endpoint.HttpPort).To(Equal(int32(9000)))
+
+	invalid := [][]v1.Parameter{
+		{{Name: ParameterBehaviour, Value: "unknown"}},
+		{{Name: ParameterBehaviour, Value: BehaviourConstant}},
+		{{Name: ParameterBehaviour, Value: BehaviourEcho}, {Name: ParameterLatency, Value: "soon"}},
+		{{Name: ParameterBehaviour, Value: BehaviourEcho}, {Name: ParameterErrorRate, Value: "2"}},
+		{{Name: ParameterBehaviour, Value: BehaviourRandom}, {Name: ParameterMetadata, Value: "{"}},
+	}
+	for _, params := range invalid {
+		_, err := ModelsFromGraph(&v1.PredictiveUnit{Name: "stub", Parameters: params})
+		g.Expect(err).ToNot(BeNil(), params[len(params)-1].Name)
+	}
+}
+
+func TestModelWait(t *testing.T) {
+	g := NewGomegaWithT(t)
+	m := &Model{Name: "stub", Behaviour: BehaviourError, ErrorRate: 1}
+	err := m.wait(context.Background())
+	g.Expect(err).To(BeAssignableToTypeOf(&InjectedError{}))
+
+	m = &Model{Name: "stub", Behaviour: BehaviourEcho, Latency: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	g.Expect(m.wait(ctx)).To(Equal(context.DeadlineExceeded))
+}
+
+func TestRandomResponse(t *testing.T) {
+	g := NewGomegaWithT(t)
+	m := &Model{Name: "stub", Behaviour: BehaviourRandom}
+	g.Expect(json.Unmarshal([]byte(`{"outputs":[{"name":"proba","datatype":"FP64","shape":[-1,2,3]}]}`), &m.Metadata)).To(BeNil())
+	name, shape := m.outputShape()
+	g.Expect(name).To(Equal("proba"))
+	g.Expect(shape).To(Equal([]int{1, 2, 3}))
+
+	b, err := m.randomResponse(api.ProtocolTensorflow, false)
+	g.Expect(err).To(BeNil())
+	var tf struct {
+		Predictions [][][]float64 `json:"predictions"`
+	}
+	g.Expect(json.Unmarshal(b, &tf)).To(BeNil())
+	g.Expect(tf.Predictions).To(HaveLen(1))
+	g.Expect(tf.Predictions[0]).To(HaveLen(2))
+	g.Expect(tf.Predictions[0][1]).To(HaveLen(3))
+
+	b, err = m.randomResponse(api.ProtocolV2, false)
+	g.Expect(err).To(BeNil())
+	var v2 struct {
+		Outputs []struct {
+			Name  string    `json:"name"`
+			Shape []int     `json:"shape"`
+			Data  []float64 `json:"data"`
+		} `json:"outputs"`
+	}
+	g.Expect(json.Unmarshal(b, &v2)).To(BeNil())
+	g.Expect(v2.Outputs[0].Name).To(Equal("proba"))
+	g.Expect(v2.Outputs[0].Shape).To(Equal([]int{1, 2, 3}))
+	g.Expect(v2.Outputs[0].Data).To(HaveLen(6))
+}
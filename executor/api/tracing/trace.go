@@ -5,10 +5,16 @@ import (
 	"os"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 	"github.com/uber/jaeger-client-go/zipkin"
 )
 
+const (
+	// Span tag holding the identity of clients verified with mutual TLS
+	ClientIdentityTag = "client.identity"
+)
+
 func InitTracing() (io.Closer, error) {
 	//Initialise tracing
 	cfg, err := jaegercfg.FromEnv()
@@ -50,3 +56,14 @@ func newDefaultTracer(cfg *jaegercfg.Configuration) (io.Closer, error) {
 	closer, err := cfg.InitGlobalTracer(cfg.ServiceName)
 	return closer, err
 }
+
+// TraceID returns the id of the trace a span belongs to, or an empty string if it isn't a jaeger span.
+func TraceID(span opentracing.Span) string {
+	if span == nil {
+		return ""
+	}
+	if spanContext, ok := span.Context().(jaeger.SpanContext); ok {
+		return spanContext.TraceID().String()
+	}
+	return ""
+}
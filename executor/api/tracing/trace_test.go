@@ -42,3 +42,20 @@ func TestInitTracing(t *testing.T) {
 		t.Error("trace extract error", err.Error())
 	}
 }
+
+func TestTraceID(t *testing.T) {
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+
+	if id := TraceID(span); id != span.Context().(jaeger.SpanContext).TraceID().String() || id == "" {
+		t.Errorf("TraceID returned %q", id)
+	}
+	if id := TraceID(opentracing.NoopTracer{}.StartSpan("noop")); id != "" {
+		t.Errorf("TraceID of a noop span returned %q", id)
+	}
+	if id := TraceID(nil); id != "" {
+		t.Errorf("TraceID of no span returned %q", id)
+	}
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+// WatchFiles calls reload whenever one of the files at paths changes until the returned watcher is closed. A failed
+// reload is logged and name describes what the files hold in the log. The directories containing the files are
+// watched rather than the files themselves as mounted ConfigMaps and Secrets are updated by swapping symlinks.
+func WatchFiles(paths []string, name string, reload func() error, log logr.Logger) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range uniqueDirs(paths) {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, errors.Wrapf(err, "failed to watch %s", dir)
+		}
+	}
+	go watch(watcher, name, reload, log)
+	return watcher, nil
+}
+
+func uniqueDirs(paths []string) []string {
+	var dirs []string
+	seen := map[string]bool{}
+	for _, p := range paths {
+		dir := filepath.Dir(p)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func watch(watcher *fsnotify.Watcher, name string, reload func() error, log logr.Logger) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if err := reload(); err != nil {
+				log.Error(err, "Failed to reload "+name+", continuing with the previous one")
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error(err, "File watcher error", "watching", name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestWatchFilesSymlinkSwap(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+	g.Expect(os.Mkdir(filepath.Join(dir, "v1"), 0755)).To(BeNil())
+	g.Expect(os.WriteFile(filepath.Join(dir, "v1", "file"), []byte("1"), 0644)).To(BeNil())
+	g.Expect(os.Symlink("v1", filepath.Join(dir, "data"))).To(BeNil())
+	g.Expect(os.Symlink(filepath.Join("data", "file"), filepath.Join(dir, "file"))).To(BeNil())
+
+	reloads := make(chan struct{}, 10)
+	watcher, err := WatchFiles([]string{filepath.Join(dir, "file")}, "test", func() error {
+		reloads <- struct{}{}
+		return nil
+	}, logf.Log)
+	g.Expect(err).To(BeNil())
+	defer watcher.Close()
+
+	// Mounted volumes are updated by swapping the symlink to the directory holding the files
+	g.Expect(os.Mkdir(filepath.Join(dir, "v2"), 0755)).To(BeNil())
+	g.Expect(os.WriteFile(filepath.Join(dir, "v2", "file"), []byte("2"), 0644)).To(BeNil())
+	g.Expect(os.Symlink("v2", filepath.Join(dir, "data.tmp"))).To(BeNil())
+	g.Expect(os.Rename(filepath.Join(dir, "data.tmp"), filepath.Join(dir, "data"))).To(BeNil())
+	g.Eventually(reloads, time.Second).Should(Receive())
+}
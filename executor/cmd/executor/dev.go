@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/go-logr/logr"
+	seldonclient "github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/stub"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// devCommand runs the executor locally against a graph file, serving stubbed nodes in-process.
+const devCommand = "dev"
+
+// parseDevCommand removes the dev subcommand from the arguments, returning whether it was given.
+func parseDevCommand() bool {
+	if len(os.Args) > 1 && os.Args[1] == devCommand {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		return true
+	}
+	return false
+}
+
+// setDevDefaults sets the arguments which identify the deployment inside Kubernetes, which aren't needed locally.
+func setDevDefaults() {
+	if *filename == "" {
+		log.Fatal("Required argument file missing")
+	}
+	if *sdepName == "" {
+		*sdepName = devCommand
+	}
+	if *namespace == "" {
+		*namespace = "default"
+	}
+}
+
+// loadStubs returns the stubs configured in the graph, exiting if any are invalid.
+func loadStubs(predictor *v1.PredictorSpec, logger logr.Logger) map[string]*stub.Model {
+	models, err := stub.ModelsFromGraph(&predictor.Graph)
+	if err != nil {
+		log.Fatalf("Failed to load stubs: %v", err)
+	}
+	for name, model := range models {
+		logger.Info("Serving stub", "node", name, "behaviour", model.String())
+	}
+	return models
+}
+
+// stubClient wraps a client so the graph's stubs are answered in-process.
+func stubClient(client seldonclient.SeldonApiClient, models map[string]*stub.Model) seldonclient.SeldonApiClient {
+	if len(models) == 0 {
+		return client
+	}
+	return stub.NewClient(client, *protocol, models)
+}
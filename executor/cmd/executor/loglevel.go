@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Level of the executor's logs, which can be changed while it runs
+var atomicLevel = zap.NewAtomicLevel()
+
+// toggleDebugOnSignal switches the log level to debug on SIGUSR1, and back to the level it was at on the next.
+func toggleDebugOnSignal(logger logr.Logger) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+	previous := atomicLevel.Level()
+	for range c {
+		if atomicLevel.Level() == zapcore.DebugLevel && previous != zapcore.DebugLevel {
+			atomicLevel.SetLevel(previous)
+		} else {
+			previous = atomicLevel.Level()
+			atomicLevel.SetLevel(zapcore.DebugLevel)
+		}
+		logger.Info("Log level changed by signal", "level", atomicLevel.Level().String())
+	}
+}
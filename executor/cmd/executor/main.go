@@ -18,18 +18,29 @@ import (
 	"time"
 
 	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/admin"
+	"github.com/seldonio/seldon-core/executor/api/auth"
+	"github.com/seldonio/seldon-core/executor/api/cache"
+	"github.com/seldonio/seldon-core/executor/api/cert"
 	seldonclient "github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/concurrency"
 	"github.com/seldonio/seldon-core/executor/api/grpc"
 	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving"
 	kfproto "github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/grpc/tensorflow"
+	"github.com/seldonio/seldon-core/executor/api/idempotency"
 	"github.com/seldonio/seldon-core/executor/api/kafka"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/ratelimit"
+	"github.com/seldonio/seldon-core/executor/api/record"
 	"github.com/seldonio/seldon-core/executor/api/rest"
+	"github.com/seldonio/seldon-core/executor/api/stub"
 	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/api/util"
 	"github.com/seldonio/seldon-core/executor/k8s"
@@ -39,6 +50,8 @@ import (
 	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"go.uber.org/automaxprocs/maxprocs"
 	"go.uber.org/zap"
+	grpc2 "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	zapf "sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -51,8 +64,14 @@ const (
 	certMountPathEnvVar   = "SELDON_CERT_MOUNT_PATH"
 	certFileEnvVar        = "SELDON_CERT_FILE_NAME"
 	certKeyFileNameEnvVar = "SELDON_CERT_KEY_FILE_NAME"
+	clientAuthEnvVar      = "SELDON_CLIENT_AUTH"
+	clientCAFileEnvVar    = "SELDON_CLIENT_CA_FILE_NAME"
+	clientAllowedEnvVar   = "SELDON_CLIENT_ALLOWED_NAMES"
 )
 
+// Environment variables configuring the executor are shown on the admin port
+var adminEnvPrefixes = []string{"SELDON_", "JAEGER_", predictor2.ENV_REQUEST_LOGGER_DEFAULT_ENDPOINT}
+
 var (
 	serverType = flag.String("server_type", "rpc", "Server type: rpc or kafka")
 
@@ -69,6 +88,7 @@ var (
 	protocol          = flag.String("protocol", "seldon", "The payload protocol")
 	transport         = flag.String("transport", "rest", "The network transport mechanism rest, grpc")
 	filename          = flag.String("file", "", "Load graph from file")
+	reloadGraph       = flag.Bool("reload_graph", false, "Reload the graph for new requests when the file it was loaded from changes")
 	hostname          = flag.String("hostname", "", "The hostname of the running server")
 	logWorkers        = flag.Int("logger_workers", 10, "Number of workers handling payload logging")
 	logWorkBufferSize = flag.Int("log_work_buffer_size", loghandler.DefaultWorkQueueSize, "Limit of buffered logs in memory while waiting for downstream request ingestion")
@@ -83,6 +103,10 @@ var (
 	logKafkaBroker    = flag.String("log_kafka_broker", "", "The kafka log broker")
 	logKafkaTopic     = flag.String("log_kafka_topic", "", "The kafka log topic")
 	fullHealthChecks  = flag.Bool("full_health_checks", false, "Full health checks via chosen protocol API")
+	batchConcurrency  = flag.Int("batch_concurrency", rest.DefaultBatchConcurrency, "Number of lines of a batch prediction request processed concurrently")
+	batchMaxBytes     = flag.Int64("batch_max_bytes", rest.DefaultBatchMaxBytes, "Maximum size in bytes of the body of a batch prediction request")
+	adminPort         = flag.Int("admin_port", 0, "Port serving the loaded graph, configuration and node health, or 0 to disable")
+	adminPprof        = flag.Bool("admin_pprof", false, "Serve pprof profiles on the admin port from startup")
 	debug             = flag.Bool(
 		"debug",
 		util.GetEnvAsBool(debugEnvVar, debugDefault),
@@ -96,20 +120,23 @@ var (
 
 	certMountPath   = util.GetEnv(certMountPathEnvVar, "")
 	certFileName    = util.GetEnv(certFileEnvVar, "tls.crt")
-	certKeyFileName = util.GetEnv(certFileEnvVar, "tls.key")
+	certKeyFileName = util.GetEnv(certKeyFileNameEnvVar, "tls.key")
+	clientAuth      = util.GetEnv(clientAuthEnvVar, "")
+	clientCAFile    = util.GetEnv(clientCAFileEnvVar, "ca.crt")
+	clientAllowed   = util.GetEnv(clientAllowedEnvVar, "")
 )
 
 func getServerUrl(hostname string, port int) (*url.URL, error) {
 	return url.Parse(fmt.Sprintf("http://%s:%d/", hostname, port))
 }
 
-func runHttpServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, logger logr.Logger, predictor *v1.PredictorSpec, client seldonclient.SeldonApiClient, port int, probesOnly bool, serverUrl *url.URL, namespace string, protocol string, deploymentName string, prometheusPath string, fullHealthChecks bool) {
+func runHttpServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, logger logr.Logger, predictor *v1.PredictorSpec, client seldonclient.SeldonApiClient, port int, probesOnly bool, serverUrl *url.URL, namespace string, protocol string, deploymentName string, prometheusPath string, fullHealthChecks bool, options ...rest.ServerRestApiOption) {
 	wg.Add(1)
 	defer wg.Done()
 	defer lis.Close()
 
 	// Create REST API
-	seldonRest := rest.NewServerRestApi(predictor, client, probesOnly, serverUrl, namespace, protocol, deploymentName, prometheusPath, fullHealthChecks)
+	seldonRest := rest.NewServerRestApi(predictor, client, probesOnly, serverUrl, namespace, protocol, deploymentName, prometheusPath, fullHealthChecks, options...)
 	seldonRest.Initialise()
 	srv := seldonRest.CreateHttpServer(port)
 
@@ -136,11 +163,34 @@ func runHttpServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, log
 	logger.Info("http server shutdown")
 }
 
-func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, logger logr.Logger, predictor *v1.PredictorSpec, client seldonclient.SeldonApiClient, serverUrl *url.URL, namespace string, protocol string, deploymentName string, annotations map[string]string) {
+func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, logger logr.Logger, predictor *v1.PredictorSpec, client seldonclient.SeldonApiClient, serverUrl *url.URL, namespace string, protocol string, deploymentName string, annotations map[string]string, fullHealthChecks bool, tlsConfig *tls.Config, authenticator *auth.Authenticator, rateLimiter *ratelimit.Limiter, deduplicator *idempotency.Deduplicator, accessLog bool) {
 	wg.Add(1)
 	defer wg.Done()
 	defer lis.Close()
-	grpcServer, err := grpc.CreateGrpcServer(predictor, deploymentName, annotations, logger)
+	// Rate limits are applied after authentication so clients can be identified by their claims
+	// Calls are counted as in flight first so shutdown waits for everything after them
+	interceptors := []grpc2.UnaryServerInterceptor{grpc.InFlightInterceptor(predictor2.TrackRequest)}
+	if accessLog {
+		interceptors = append(interceptors, grpc.AccessLogInterceptor(logger))
+	}
+	interceptors = append(interceptors, grpc.AuthInterceptor(authenticator))
+	if rateLimiter != nil {
+		interceptors = append(interceptors, grpc.RateLimitInterceptor(rateLimiter))
+	}
+	if deduplicator != nil {
+		clientKey := ratelimit.ClientKey{Source: ratelimit.KeySourceIP}
+		if rateLimiter != nil {
+			clientKey = rateLimiter.Key
+		}
+		interceptors = append(interceptors, grpc.IdempotencyInterceptor(deduplicator, clientKey))
+	}
+	serverOptions := []grpc2.ServerOption{grpc2.ChainUnaryInterceptor(interceptors...), grpc2.StreamInterceptor(grpc.InFlightStreamInterceptor(predictor2.TrackRequest))}
+	if tlsConfig != nil {
+		// TLS is handled by gRPC so the client certificate is available to interceptors
+		serverOptions = append(serverOptions, grpc2.Creds(credentials.NewTLS(tlsConfig)))
+		logger.Info("Serving gRPC with TLS")
+	}
+	grpcServer, err := grpc.CreateGrpcServer(predictor, deploymentName, annotations, logger, serverOptions...)
 	if err != nil {
 		log.Fatalf("Failed to create gRPC server: %v", err)
 	}
@@ -148,8 +198,6 @@ func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, log
 	case api.ProtocolSeldon:
 		seldonGrpcServer := seldon.NewGrpcSeldonServer(predictor, client, serverUrl, namespace)
 		proto.RegisterSeldonServer(grpcServer, seldonGrpcServer)
-		// Register reflection service on gRPC server.
-		reflection.Register(grpcServer)
 	case api.ProtocolTensorflow:
 		tensorflowGrpcServer := tensorflow.NewGrpcTensorflowServer(predictor, client, serverUrl, namespace)
 		serving.RegisterPredictionServiceServer(grpcServer, tensorflowGrpcServer)
@@ -158,6 +206,12 @@ func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, log
 		kfservingGrpcServer := kfserving.NewGrpcKFServingServer(predictor, client, serverUrl, namespace)
 		kfproto.RegisterGRPCInferenceServiceServer(grpcServer, kfservingGrpcServer)
 	}
+	// Register health service backed by the graph's readiness
+	grpc.RegisterHealthServer(grpcServer, func() error {
+		return predictor2.Ready(protocol, &predictor2.ActiveSpec(predictor).Graph, fullHealthChecks)
+	}, logger.WithName("GrpcHealth"))
+	// Register reflection service on gRPC server.
+	reflection.Register(grpcServer)
 
 	go func() {
 		logger.Info("gRPC server started")
@@ -171,6 +225,47 @@ func runGrpcServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, log
 	logger.Info("gRPC server shutdown")
 }
 
+func runAdminServer(wg *sync.WaitGroup, shutdown chan bool, lis net.Listener, logger logr.Logger, adminServer *admin.Server, port int) {
+	wg.Add(1)
+	defer wg.Done()
+	defer lis.Close()
+
+	srv := adminServer.CreateHttpServer(port)
+	go func() {
+		logger.Info("admin server started")
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err, "admin server error")
+		}
+	}()
+
+	<-shutdown
+	if err := srv.Shutdown(context.Background()); err != nil {
+		logger.Error(err, "admin server shutdown error")
+	}
+	logger.Info("admin server shutdown")
+}
+
+// adminConfig collects the flags, annotations and environment variables the executor was configured with.
+func adminConfig(annotations map[string]string) admin.Config {
+	config := admin.Config{
+		Flags:       map[string]string{},
+		Annotations: annotations,
+		Environment: map[string]string{},
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		config.Flags[f.Name] = f.Value.String()
+	})
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		for _, prefix := range adminEnvPrefixes {
+			if strings.HasPrefix(parts[0], prefix) && len(parts) == 2 {
+				config.Environment[parts[0]] = parts[1]
+			}
+		}
+	}
+	return config
+}
+
 func waitForShutdown(logger logr.Logger, wg *sync.WaitGroup, chs ...chan bool) {
 	// wait for and then signal servers have exited
 	done := make(chan struct{})
@@ -187,13 +282,25 @@ func waitForShutdown(logger logr.Logger, wg *sync.WaitGroup, chs ...chan bool) {
 
 	// Block until we receive our signal.
 	sig := <-c
-	logger.Info("shutdown signal received", "signal", sig, "shutdown_delay", *delay)
+	// Fail readiness straight away so load balancers stop sending new requests
+	predictor2.Drain()
+	logger.Info("shutdown signal received", "signal", sig, "shutdown_delay", *delay, "in_flight", len(predictor2.InFlight()))
 	time.Sleep(*delay) // shutdown_delay
 
 	// Create a deadline to wait for graceful shutdown.
 	ctx, cancel := context.WithTimeout(context.Background(), *wait)
 	defer cancel()
 
+	// Wait for in-flight requests, streams and jobs before stopping the servers
+	if cutOff := predictor2.WaitForInFlight(ctx); len(cutOff) > 0 {
+		logger.Error(ctx.Err(), "graceful_timeout exceeded with requests in flight", "graceful_timeout", *wait, "in_flight", len(cutOff))
+		for _, request := range cutOff {
+			logger.Info("Request cut off by shutdown", "name", request.Name, "puid", request.Puid, "duration", time.Since(request.Start).String())
+		}
+	} else {
+		logger.Info("in-flight requests finished")
+	}
+
 	// send signals to server channels to initiate shutdown.
 	for _, ch := range chs {
 		ch <- true
@@ -224,7 +331,7 @@ func setupLogger() {
 		level = zap.FatalLevel
 	}
 
-	atomicLevel := zap.NewAtomicLevelAt(level)
+	atomicLevel = zap.NewAtomicLevelAt(level)
 
 	logger := zapf.New(
 		zapf.UseDevMode(*debug),
@@ -235,7 +342,14 @@ func setupLogger() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == replayCommand {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+	devMode := parseDevCommand()
 	flag.Parse()
+	if devMode {
+		setDevDefaults()
+	}
 
 	if *sdepName == "" {
 		log.Fatal("Required argument sdep missing")
@@ -245,7 +359,7 @@ func main() {
 		log.Fatal("Required argument namespace missing")
 	}
 
-	if *predictorName == "" {
+	if *predictorName == "" && !devMode {
 		log.Fatal("Required argument predictor missing")
 	}
 
@@ -320,6 +434,7 @@ func main() {
 
 	setupLogger()
 	logger := logf.Log.WithName("entrypoint")
+	go toggleDebugOnSignal(logger)
 
 	logger.Info("Full health checks ", "value", fullHealthChecks)
 
@@ -349,11 +464,26 @@ func main() {
 		os.Exit(-1)
 
 	}
+	var stubs map[string]*stub.Model
+	if devMode {
+		*predictorName = predictor.Name
+		stubs = loadStubs(predictor, logger)
+	}
 
-	// Ensure standard OpenAPI seldon API file has this deployment's values
-	err = rest.EmbedSeldonDeploymentValuesInSwaggerFile(*namespace, *sdepName)
-	if err != nil {
-		logger.Error(err, "Failed to embed variables on OpenAPI template")
+	if *reloadGraph {
+		if *filename == "" {
+			log.Fatal("Reloading the graph requires it to be loaded from a file")
+		}
+		graphReloader, err := predictor2.NewGraphReloader(*predictorName, *filename, predictor, *sdepName, *transport, *protocol, logger)
+		if err != nil {
+			log.Fatalf("Failed to watch graph file: %v", err)
+		}
+		logger.Info("Reloading graph on changes", "file", *filename, "version", graphReloader.Version())
+		predictor2.SetGraphReloader(graphReloader)
+	}
+
+	if err := predictor2.ValidateProtocols(predictor, *transport, *protocol); err != nil {
+		log.Fatal(err)
 	}
 
 	annotations, err := k8s.GetAnnotations()
@@ -361,6 +491,77 @@ func main() {
 		logger.Error(err, "Failed to load annotations")
 	}
 
+	authenticator, err := auth.NewAuthenticatorFromAnnotations(annotations, logger)
+	if err != nil {
+		log.Fatalf("Failed to configure JWT authentication: %v", err)
+	}
+	if authenticator != nil {
+		logger.Info("Authenticating requests with JWT", "jwks", annotations[k8s.ANNOTATION_JWT_JWKS])
+	}
+
+	rateLimiter, err := ratelimit.NewLimiterFromAnnotations(annotations, *sdepName)
+	if err != nil {
+		log.Fatalf("Failed to configure rate limits: %v", err)
+	}
+	if rateLimiter != nil {
+		logger.Info("Limiting request rates", "global", annotations[k8s.ANNOTATION_RATE_LIMIT], "perClient", annotations[k8s.ANNOTATION_RATE_LIMIT_PER_CLIENT], "clientKey", rateLimiter.Key)
+	}
+
+	concurrencyLimiter, err := concurrency.NewLimiterFromAnnotations(annotations, *sdepName)
+	if err != nil {
+		log.Fatalf("Failed to configure concurrency limit: %v", err)
+	}
+	if concurrencyLimiter != nil {
+		logger.Info("Limiting concurrent requests", "max", annotations[k8s.ANNOTATION_MAX_CONCURRENCY], "queue", annotations[k8s.ANNOTATION_CONCURRENCY_QUEUE_LENGTH], "adaptive", annotations[k8s.ANNOTATION_CONCURRENCY_ADAPTIVE])
+		predictor2.SetConcurrencyLimiter(concurrencyLimiter)
+	}
+	recorder, err := record.NewRecorderFromAnnotations(annotations, *sdepName, logger)
+	if err != nil {
+		log.Fatalf("Failed to configure recording: %v", err)
+	}
+	if recorder != nil {
+		logger.Info("Recording requests", "file", annotations[k8s.ANNOTATION_RECORD_FILE], "sampleRate", annotations[k8s.ANNOTATION_RECORD_SAMPLE_RATE])
+		defer recorder.Close()
+	}
+	deduplicator, err := idempotency.NewDeduplicatorFromAnnotations(annotations, *sdepName)
+	if err != nil {
+		log.Fatalf("Failed to configure idempotency keys: %v", err)
+	}
+	if deduplicator != nil {
+		logger.Info("Deduplicating requests by idempotency key", "header", payload.IdempotencyKeyHeader, "ttl", annotations[k8s.ANNOTATION_IDEMPOTENCY_TTL])
+	}
+	accessLog := false
+	if val, ok := annotations[k8s.ANNOTATION_ACCESS_LOG]; ok {
+		if accessLog, err = strconv.ParseBool(val); err != nil {
+			log.Fatalf("Invalid %s %q", k8s.ANNOTATION_ACCESS_LOG, val)
+		}
+	}
+	if debugTrace, ok := annotations[k8s.ANNOTATION_DEBUG_TRACE]; ok {
+		enabled, err := strconv.ParseBool(debugTrace)
+		if err != nil {
+			log.Fatalf("Invalid %s %q", k8s.ANNOTATION_DEBUG_TRACE, debugTrace)
+		}
+		if enabled {
+			logger.Info("Returning debug traces to requests with header", "header", payload.SeldonDebugTraceHeader)
+			predictor2.EnableDebugTrace(*protocol)
+		}
+	}
+	if bulkheads := concurrency.NewBulkheads(&predictor.Graph, *sdepName); bulkheads != nil {
+		logger.Info("Limiting concurrent calls to graph nodes", "nodes", len(bulkheads))
+		predictor2.SetBulkheads(bulkheads)
+	}
+	caches, err := cache.NewCaches(predictor, predictor2.GraphVersion(predictor), *sdepName)
+	if err != nil {
+		log.Fatalf("Failed to configure response caches: %v", err)
+	}
+	if caches != nil {
+		logger.Info("Caching responses", "graph", caches.Graph != nil, "nodes", len(caches.Nodes))
+		// A hot reloaded graph's caches are created by the reloader for each version of the graph
+		if !*reloadGraph {
+			predictor2.SetCaches(caches)
+		}
+	}
+
 	//Start Logger Dispacther
 	err = loghandler.StartDispatcher(*logWorkers, *logWorkBufferSize, *logWriteTimeoutMs, logger, *sdepName, *namespace, *predictorName, *logKafkaBroker, *logKafkaTopic, *protocol)
 	if err != nil {
@@ -396,39 +597,90 @@ func main() {
 	var clientGrpc seldonclient.SeldonApiClient
 	switch *protocol {
 	case api.ProtocolSeldon:
-		clientGrpc = seldon.NewSeldonGrpcClient(predictor, *sdepName, annotations)
+		clientGrpc, err = seldon.NewSeldonGrpcClient(predictor, *sdepName, annotations)
 	case api.ProtocolTensorflow:
-		clientGrpc = tensorflow.NewTensorflowGrpcClient(predictor, *sdepName, annotations)
+		clientGrpc, err = tensorflow.NewTensorflowGrpcClient(predictor, *sdepName, annotations)
 	case api.ProtocolV2, api.ProtocolKFServing:
-		clientGrpc = kfserving.NewKFServingGrpcClient(predictor, *sdepName, annotations)
+		clientGrpc, err = kfserving.NewKFServingGrpcClient(predictor, *sdepName, annotations)
 	default:
 		log.Fatalf("Failed to create grpc client. Unknown protocol %s: %v", *protocol, err)
 	}
+	if err != nil {
+		log.Fatalf("Failed to create grpc client: %v", err)
+	}
+
+	clientRest = stubClient(clientRest, stubs)
+	clientGrpc = stubClient(clientGrpc, stubs)
+
+	warmupClient := clientRest
+	if *transport == api.TransportGrpc {
+		warmupClient = clientGrpc
+	}
+	warmer, err := predictor2.NewWarmer(predictor, warmupClient, *protocol, *fullHealthChecks, logger)
+	if err != nil {
+		log.Fatalf("Failed to configure warm-up: %v", err)
+	}
+	if warmer != nil {
+		logger.Info("Warming up graph before reporting ready", "payloads", len(predictor.Warmup.Payloads), "file", predictor.Warmup.File)
+		predictor2.SetWarmer(warmer)
+		go warmer.Run(context.Background())
+	}
+
+	tlsConfig := createTLSConfig(logger)
 
 	wg := sync.WaitGroup{}
 	logger.Info("Running http server ", "port", *httpPort)
 	httpStop := make(chan bool, 1)
-	go runHttpServer(&wg, httpStop, createListener(*httpPort, logger), logger, predictor, clientRest, *httpPort, false, serverUrl, *namespace, *protocol, *sdepName, *prometheusPath, *fullHealthChecks)
+	go runHttpServer(&wg, httpStop, createListener(*httpPort, tlsConfig, logger), logger, predictor, clientRest, *httpPort, false, serverUrl, *namespace, *protocol, *sdepName, *prometheusPath, *fullHealthChecks, rest.WithBatchConcurrency(*batchConcurrency), rest.WithBatchMaxBytes(*batchMaxBytes), rest.WithAuthenticator(authenticator), rest.WithRateLimiter(rateLimiter), rest.WithRecorder(recorder), rest.WithDeduplicator(deduplicator), rest.WithAccessLog(accessLog))
 
 	logger.Info("Running grpc server ", "port", *grpcPort)
 	grpcStop := make(chan bool, 1)
-	go runGrpcServer(&wg, grpcStop, createListener(*grpcPort, logger), logger, predictor, clientGrpc, serverUrl, *namespace, *protocol, *sdepName, annotations)
-	waitForShutdown(logger, &wg, httpStop, grpcStop)
+	go runGrpcServer(&wg, grpcStop, createListener(*grpcPort, nil, logger), logger, predictor, clientGrpc, serverUrl, *namespace, *protocol, *sdepName, annotations, *fullHealthChecks, tlsConfig, authenticator, rateLimiter, deduplicator, accessLog)
+	stops := []chan bool{httpStop, grpcStop}
+	if *adminPort > 0 {
+		logger.Info("Running admin server ", "port", *adminPort)
+		adminStop := make(chan bool, 1)
+		adminServer := admin.NewServer(predictor, *protocol, *fullHealthChecks, adminConfig(annotations), *adminPprof, logger, admin.WithLogLevel(&atomicLevel))
+		go runAdminServer(&wg, adminStop, createListener(*adminPort, nil, logger), logger, adminServer, *adminPort)
+		stops = append(stops, adminStop)
+	}
+	waitForShutdown(logger, &wg, stops...)
 }
 
-func createListener(port int, logger logr.Logger) net.Listener {
+// createTLSConfig returns the TLS configuration for the server listeners, or nil if no certificate is mounted.
+// The certificate is reloaded whenever the mounted files change.
+func createTLSConfig(logger logr.Logger) *tls.Config {
+	if len(certMountPath) == 0 {
+		return nil
+	}
+	certPath := path.Join(certMountPath, certFileName)
+	keyPath := path.Join(certMountPath, certKeyFileName)
+	reloader, err := cert.NewCertificateReloader(certPath, keyPath, logger)
+	if err != nil {
+		log.Fatalf("Error certificate could not be found: %v", err)
+	}
+	tlsConfig := &tls.Config{GetCertificate: reloader.GetCertificate}
+
+	var allowedNames []string
+	if clientAllowed != "" {
+		allowedNames = strings.Split(clientAllowed, ",")
+	}
+	if err := cert.ConfigureClientAuth(tlsConfig, clientAuth, path.Join(certMountPath, clientCAFile), allowedNames); err != nil {
+		log.Fatalf("Failed to configure client authentication: %v", err)
+	}
+	if clientAuth != "" {
+		logger.Info("Authenticating clients with mutual TLS", "mode", clientAuth, "allowedNames", allowedNames)
+	}
+	return tlsConfig
+}
+
+func createListener(port int, tlsConfig *tls.Config, logger logr.Logger) net.Listener {
 	// Create a listener at the desired port.
 	var lis net.Listener
 	var err error
-	if len(certMountPath) > 0 {
+	if tlsConfig != nil {
 		logger.Info("Creating TLS listener", "port", port)
-		certPath := path.Join(certMountPath, certFileName)
-		keyPath := path.Join(certMountPath, certKeyFileName)
-		cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-		if err != nil {
-			log.Fatalf("Error certificate could not be found: %v", err)
-		}
-		lis, err = tls.Listen("tcp", fmt.Sprintf(":%d", port), &tls.Config{Certificates: []tls.Certificate{cert}})
+		lis, err = tls.Listen("tcp", fmt.Sprintf(":%d", port), tlsConfig)
 		if err != nil {
 			log.Fatalf("failed to create listener: %v", err)
 		}
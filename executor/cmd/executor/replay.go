@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/seldonio/seldon-core/executor/api/record"
+)
+
+// replayCommand sends the requests in recordings to an executor and reports how its responses differ.
+const replayCommand = "replay"
+
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet(replayCommand, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] recording...\n", os.Args[0], replayCommand)
+		flags.PrintDefaults()
+	}
+	target := flags.String("target", "http://localhost:8000", "URL of the executor to send requests to")
+	rate := flags.Float64("rate", 0, "Requests sent per second, or 0 for no limit")
+	concurrency := flags.Int("concurrency", 1, "Number of requests sent at once")
+	ignoreFields := flags.String("ignore_fields", "", "Comma separated top level response fields which aren't compared, such as meta")
+	maxDiffs := flags.Int("max_diffs", 10, "Number of differing responses listed")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	targetUrl, err := url.Parse(*target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid target %s: %v\n", *target, err)
+		return 2
+	}
+
+	var exchanges []*record.Exchange
+	for _, filename := range flags.Args() {
+		file, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open recording: %v\n", err)
+			return 1
+		}
+		read, err := record.ReadExchanges(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read recording %s: %v\n", filename, err)
+			return 1
+		}
+		exchanges = append(exchanges, read...)
+	}
+
+	replayer := &record.Replayer{
+		Target:      targetUrl,
+		Rate:        *rate,
+		Concurrency: *concurrency,
+	}
+	if *ignoreFields != "" {
+		replayer.IgnoreFields = strings.Split(*ignoreFields, ",")
+	}
+	report := replayer.Replay(context.Background(), exchanges)
+	report.Write(os.Stdout, *maxDiffs)
+	if report.Errors > 0 || len(report.Diffs) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -8,10 +8,42 @@ import (
 )
 
 const (
-	ANNOTATIONS_FILE                 = "/etc/podinfo/annotations"
-	ANNOTATION_GRPC_MAX_MESSAGE_SIZE = "seldon.io/grpc-max-message-size"
-	ANNOTATION_GRPC_TIMEOUT          = "seldon.io/grpc-timeout"
-	ANNOTATION_REST_TIMEOUT          = "seldon.io/rest-timeout"
+	ANNOTATIONS_FILE                       = "/etc/podinfo/annotations"
+	ANNOTATION_GRPC_MAX_MESSAGE_SIZE       = "seldon.io/grpc-max-message-size"
+	ANNOTATION_GRPC_TIMEOUT                = "seldon.io/grpc-timeout"
+	ANNOTATION_GRPC_POOL_SIZE              = "seldon.io/grpc-pool-size"
+	ANNOTATION_GRPC_KEEPALIVE_TIME         = "seldon.io/grpc-keepalive-time"
+	ANNOTATION_GRPC_KEEPALIVE_TIMEOUT      = "seldon.io/grpc-keepalive-timeout"
+	ANNOTATION_GRPC_LOAD_BALANCING         = "seldon.io/grpc-load-balancing"
+	ANNOTATION_REST_TIMEOUT                = "seldon.io/rest-timeout"
+	ANNOTATION_REST_MAX_IDLE_CONNS         = "seldon.io/rest-max-idle-conns-per-host"
+	ANNOTATION_REST_IDLE_CONN_TIMEOUT      = "seldon.io/rest-idle-conn-timeout"
+	ANNOTATION_REST_DIAL_TIMEOUT           = "seldon.io/rest-dial-timeout"
+	ANNOTATION_REST_RESPONSE_TIMEOUT       = "seldon.io/rest-response-header-timeout"
+	ANNOTATION_REST_H2C                    = "seldon.io/rest-h2c"
+	ANNOTATION_JWT_JWKS                    = "seldon.io/jwt-jwks"
+	ANNOTATION_JWT_JWKS_REFRESH            = "seldon.io/jwt-jwks-refresh"
+	ANNOTATION_JWT_ISSUER                  = "seldon.io/jwt-issuer"
+	ANNOTATION_JWT_AUDIENCES               = "seldon.io/jwt-audiences"
+	ANNOTATION_JWT_RULES                   = "seldon.io/jwt-rules"
+	ANNOTATION_RATE_LIMIT                  = "seldon.io/rate-limit"
+	ANNOTATION_RATE_LIMIT_BURST            = "seldon.io/rate-limit-burst"
+	ANNOTATION_RATE_LIMIT_PER_CLIENT       = "seldon.io/rate-limit-per-client"
+	ANNOTATION_RATE_LIMIT_PER_CLIENT_BURST = "seldon.io/rate-limit-per-client-burst"
+	ANNOTATION_RATE_LIMIT_CLIENT_KEY       = "seldon.io/rate-limit-client-key"
+	ANNOTATION_MAX_CONCURRENCY             = "seldon.io/max-concurrency"
+	ANNOTATION_CONCURRENCY_QUEUE_LENGTH    = "seldon.io/concurrency-queue-length"
+	ANNOTATION_CONCURRENCY_QUEUE_TIMEOUT   = "seldon.io/concurrency-queue-timeout"
+	ANNOTATION_CONCURRENCY_ADAPTIVE        = "seldon.io/concurrency-adaptive"
+	ANNOTATION_CONCURRENCY_TARGET_LATENCY  = "seldon.io/concurrency-target-latency"
+	ANNOTATION_RECORD_FILE                 = "seldon.io/record-file"
+	ANNOTATION_RECORD_SAMPLE_RATE          = "seldon.io/record-sample-rate"
+	ANNOTATION_RECORD_MAX_MEGABYTES        = "seldon.io/record-max-megabytes"
+	ANNOTATION_RECORD_MAX_FILES            = "seldon.io/record-max-files"
+	ANNOTATION_DEBUG_TRACE                 = "seldon.io/debug-trace"
+	ANNOTATION_ACCESS_LOG                  = "seldon.io/access-log"
+	ANNOTATION_IDEMPOTENCY_TTL             = "seldon.io/idempotency-ttl"
+	ANNOTATION_IDEMPOTENCY_MAX_ENTRIES     = "seldon.io/idempotency-max-entries"
 )
 
 func trimQuotes(v string) string {
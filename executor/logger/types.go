@@ -22,4 +22,6 @@ type LogRequest struct {
 	SourceUri       *url.URL
 	ModelId         string
 	RequestId       string
+	ClientIdentity  string
+	JwtClaims       string
 }
@@ -26,6 +26,8 @@ const (
 	NamespaceAttr            = "namespace"
 	EndpointAttr             = "endpoint"
 	ProtocolAttr             = "protocol"
+	ClientIdentityAttr       = "clientidentity"
+	JwtClaimsAttr            = "jwtclaims"
 	KafkaTypeHeader          = "type"
 	KafkaContentTypeHeader   = "content-type"
 )
@@ -127,6 +129,12 @@ func (w *Worker) sendKafkaEvent(logReq LogRequest) error {
 		{Key: EndpointAttr, Value: []byte(w.PredictorName)},
 		{Key: ProtocolAttr, Value: []byte(w.PayloadProtocol)},
 	}
+	if logReq.ClientIdentity != "" {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: ClientIdentityAttr, Value: []byte(logReq.ClientIdentity)})
+	}
+	if logReq.JwtClaims != "" {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: JwtClaimsAttr, Value: []byte(logReq.JwtClaims)})
+	}
 	w.Log.Info("kafkaHeaders is", "kafkaHeaders", kafkaHeaders)
 	err = w.Producer.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &w.KafkaTopic, Partition: kafka.PartitionAny},
@@ -181,6 +189,12 @@ func (w *Worker) sendCloudEvent(logReq LogRequest) error {
 	//use 'endpoint' for the header to align with kfserving - https://github.com/kubeflow/kfserving/pull/699/files#r385360114
 	event.SetExtension(EndpointAttr, w.PredictorName)
 	event.SetExtension(ProtocolAttr, w.PayloadProtocol)
+	if logReq.ClientIdentity != "" {
+		event.SetExtension(ClientIdentityAttr, logReq.ClientIdentity)
+	}
+	if logReq.JwtClaims != "" {
+		event.SetExtension(JwtClaimsAttr, logReq.JwtClaims)
+	}
 
 	event.SetSource(logReq.SourceUri.String())
 	event.SetDataContentType(logReq.ContentType)
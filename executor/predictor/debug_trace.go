@@ -0,0 +1,237 @@
+package predictor
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	protoV1 "github.com/golang/protobuf/proto"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const (
+	// Key of the trace in seldon meta tags and v2 parameters
+	DebugTraceKey = "debug_trace"
+	// Key of the trace in tensorflow responses
+	DebugTraceTensorflowKey = "debug"
+
+	TraceTransformInput  = "transform-input"
+	TracePredict         = "predict"
+	TraceRoute           = "route"
+	TraceAggregate       = "aggregate"
+	TraceTransformOutput = "transform-output"
+)
+
+// Protocol of the responses traces are added to, or empty if tracing is disabled
+var debugTraceProtocol string
+
+// EnableDebugTrace allows requests to ask for a trace of the graph in responses of the given protocol.
+func EnableDebugTrace(protocol string) {
+	debugTraceProtocol = protocol
+}
+
+// TraceStep is a call made to a graph node while handling a request.
+type TraceStep struct {
+	Node   string          `json:"node"`
+	Method string          `json:"method"`
+	Input  json.RawMessage `json:"input,omitempty"`
+	Output json.RawMessage `json:"output,omitempty"`
+	// Child chosen by a router
+	Route     *int    `json:"route,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+	// Whether the output was returned from a response cache rather than the node
+	Cached bool `json:"cached,omitempty"`
+}
+
+// debugTrace collects the steps of a request, in the order they completed.
+type debugTrace struct {
+	mutex sync.Mutex
+	steps []TraceStep
+}
+
+func (t *debugTrace) add(step TraceStep) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.steps = append(t.steps, step)
+}
+
+func (t *debugTrace) marshal() ([]byte, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.steps == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t.steps)
+}
+
+// debugTraceRequested returns whether tracing is enabled and the request asked for a trace.
+func (p *PredictorProcess) debugTraceRequested() bool {
+	if debugTraceProtocol == "" {
+		return false
+	}
+	requested, err := strconv.ParseBool(p.Meta.GetAsString(payload.SeldonDebugTraceHeader))
+	return err == nil && requested
+}
+
+// recordCall counts a failed call to a node and records the call if the request is being traced. Payloads are
+// encoded straight away as later nodes may modify them.
+func (p *PredictorProcess) recordCall(node *v1.PredictiveUnit, method string, start time.Time, input json.RawMessage, output payload.SeldonPayload, route *int, err error) {
+	if err != nil {
+		nodeErrors.add(node.Name)
+	}
+	if p.trace == nil {
+		return
+	}
+	step := TraceStep{
+		Node:      node.Name,
+		Method:    method,
+		Input:     input,
+		Route:     route,
+		LatencyMs: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		step.Error = err.Error()
+	} else if output != nil {
+		step.Output = traceValue(output)
+	}
+	p.trace.add(step)
+}
+
+// traceInput encodes the input of a call to a node, returning nil if the request isn't being traced.
+func (p *PredictorProcess) traceInput(msgs ...payload.SeldonPayload) json.RawMessage {
+	if p.trace == nil {
+		return nil
+	}
+	if len(msgs) == 1 {
+		return traceValue(msgs[0])
+	}
+	values := make([]json.RawMessage, len(msgs))
+	for i, msg := range msgs {
+		values[i] = traceValue(msg)
+	}
+	b, _ := json.Marshal(values)
+	return b
+}
+
+// traceValue encodes a payload as JSON, or as a JSON string holding base64 for payloads which aren't JSON.
+func traceValue(msg payload.SeldonPayload) json.RawMessage {
+	if msg == nil {
+		return json.RawMessage("null")
+	}
+	if m, ok := msg.GetPayload().(protoV1.Message); ok {
+		s, err := (&jsonpb.Marshaler{}).MarshalToString(m)
+		if err == nil {
+			return json.RawMessage(s)
+		}
+	}
+	data, err := payload.DecompressSeldonPayload(msg)
+	if err == nil && json.Valid(data) {
+		return data
+	}
+	b, _ := json.Marshal(data)
+	return b
+}
+
+// addDebugTrace adds the trace to a response of the protocol tracing was enabled for.
+func (p *PredictorProcess) addDebugTrace(msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+	trace, err := p.trace.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if msg.GetContentType() == payload.APPLICATION_TYPE_PROTOBUF {
+		return addDebugTraceProto(msg, trace)
+	}
+	if msg.GetContentEncoding() != "" {
+		return nil, errors.Errorf("can't add a trace to a response with content encoding %s", msg.GetContentEncoding())
+	}
+	data, err := msg.GetBytes()
+	if err != nil {
+		return nil, err
+	}
+	response := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, errors.Wrap(err, "can't add a trace to a response which isn't a JSON object")
+	}
+	switch debugTraceProtocol {
+	case api.ProtocolSeldon:
+		meta := map[string]json.RawMessage{}
+		tags := map[string]json.RawMessage{}
+		if err := unmarshalField(response, "meta", &meta); err != nil {
+			return nil, err
+		}
+		if err := unmarshalField(meta, "tags", &tags); err != nil {
+			return nil, err
+		}
+		tags[DebugTraceKey] = trace
+		if meta["tags"], err = json.Marshal(tags); err != nil {
+			return nil, err
+		}
+		if response["meta"], err = json.Marshal(meta); err != nil {
+			return nil, err
+		}
+	case api.ProtocolTensorflow:
+		response[DebugTraceTensorflowKey] = trace
+	case api.ProtocolV2, api.ProtocolKFServing:
+		parameters := map[string]json.RawMessage{}
+		if err := unmarshalField(response, "parameters", &parameters); err != nil {
+			return nil, err
+		}
+		parameters[DebugTraceKey] = trace
+		if response["parameters"], err = json.Marshal(parameters); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("can't add a trace to responses of protocol %s", debugTraceProtocol)
+	}
+	data, err = json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return &payload.BytesPayload{Msg: data, ContentType: msg.GetContentType()}, nil
+}
+
+// unmarshalField decodes a field of a JSON object if it is present and not null.
+func unmarshalField(object map[string]json.RawMessage, field string, v interface{}) error {
+	raw, ok := object[field]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	return errors.Wrapf(json.Unmarshal(raw, v), "invalid %s in response", field)
+}
+
+// addDebugTraceProto adds the trace to the meta tags of seldon messages and as a string parameter of v2 responses.
+func addDebugTraceProto(msg payload.SeldonPayload, trace []byte) (payload.SeldonPayload, error) {
+	switch response := msg.GetPayload().(type) {
+	case *proto.SeldonMessage:
+		value := &_struct.Value{}
+		if err := jsonpb.UnmarshalString(string(trace), value); err != nil {
+			return nil, err
+		}
+		if response.Meta == nil {
+			response.Meta = &proto.Meta{}
+		}
+		if response.Meta.Tags == nil {
+			response.Meta.Tags = map[string]*_struct.Value{}
+		}
+		response.Meta.Tags[DebugTraceKey] = value
+	case *inference.ModelInferResponse:
+		if response.Parameters == nil {
+			response.Parameters = map[string]*inference.InferParameter{}
+		}
+		response.Parameters[DebugTraceKey] = &inference.InferParameter{
+			ParameterChoice: &inference.InferParameter_StringParam{StringParam: string(trace)},
+		}
+	default:
+		return nil, errors.Errorf("can't add a trace to a %T response", response)
+	}
+	return msg, nil
+}
@@ -0,0 +1,140 @@
+package predictor
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func createTracedPredictorProcess(header string) *PredictorProcess {
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	meta := map[string][]string{}
+	if header != "" {
+		// gRPC metadata keys are lower case
+		meta["seldon-debug-trace"] = []string{header}
+	}
+	pp := NewPredictorProcess(ctx, &test.SeldonMessageTestClient{ChosenRoute: 1}, logf.Log.WithName("SeldonMessageRestClient"), url, "default", meta, "")
+	return &pp
+}
+
+func createTracedGraph() *v1.PredictiveUnit {
+	model := v1.MODEL
+	router := v1.ROUTER
+	return &v1.PredictiveUnit{
+		Name:     "router",
+		Type:     &router,
+		Endpoint: &v1.Endpoint{Type: v1.REST},
+		Children: []v1.PredictiveUnit{
+			{Name: "a", Type: &model, Endpoint: &v1.Endpoint{Type: v1.REST}},
+			{Name: "b", Type: &model, Endpoint: &v1.Endpoint{Type: v1.REST}},
+		},
+	}
+}
+
+func getTrace(g *GomegaWithT, response payload.SeldonPayload) []TraceStep {
+	tag, ok := response.GetPayload().(*proto.SeldonMessage).GetMeta().GetTags()[DebugTraceKey]
+	if !ok {
+		return nil
+	}
+	b, err := (&jsonpb.Marshaler{}).MarshalToString(tag)
+	g.Expect(err).Should(BeNil())
+	var steps []TraceStep
+	g.Expect(json.Unmarshal([]byte(b), &steps)).Should(BeNil())
+	return steps
+}
+
+func TestDebugTrace(t *testing.T) {
+	g := NewGomegaWithT(t)
+	EnableDebugTrace(api.ProtocolSeldon)
+	defer EnableDebugTrace("")
+
+	response, err := createTracedPredictorProcess("true").Predict(createTracedGraph(), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	steps := getTrace(g, response)
+	g.Expect(steps).Should(HaveLen(2))
+
+	g.Expect(steps[0].Node).Should(Equal("router"))
+	g.Expect(steps[0].Method).Should(Equal(TraceRoute))
+	g.Expect(*steps[0].Route).Should(Equal(1))
+	g.Expect(steps[0].Input).Should(MatchJSON(`{"data":{"ndarray":[1.1,2]}}`))
+	g.Expect(steps[0].Output).Should(BeNil())
+
+	g.Expect(steps[1].Node).Should(Equal("b"))
+	g.Expect(steps[1].Method).Should(Equal(TracePredict))
+	g.Expect(steps[1].Route).Should(BeNil())
+	g.Expect(steps[1].Input).Should(MatchJSON(`{"data":{"ndarray":[1.1,2]}}`))
+	g.Expect(steps[1].Output).Should(MatchJSON(`{"data":{"ndarray":[1.1,2]}}`))
+}
+
+func TestDebugTraceNotReturned(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// Disabled for the deployment
+	response, err := createTracedPredictorProcess("true").Predict(createTracedGraph(), createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(getTrace(g, response)).Should(BeNil())
+
+	EnableDebugTrace(api.ProtocolSeldon)
+	defer EnableDebugTrace("")
+	for _, header := range []string{"", "false", "yes please"} {
+		response, err := createTracedPredictorProcess(header).Predict(createTracedGraph(), createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+		g.Expect(getTrace(g, response)).Should(BeNil())
+	}
+}
+
+func TestAddDebugTraceJson(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer EnableDebugTrace("")
+
+	tests := []struct {
+		protocol string
+		response string
+		expected string
+	}{
+		{
+			protocol: api.ProtocolSeldon,
+			response: `{"data":{"ndarray":[1]}}`,
+			expected: `{"data":{"ndarray":[1]},"meta":{"tags":{"debug_trace":[{"node":"a","method":"predict","output":{"x":1},"latencyMs":0}]}}}`,
+		},
+		{
+			protocol: api.ProtocolSeldon,
+			response: `{"data":{"ndarray":[1]},"meta":{"puid":"1","tags":{"model":"a"}}}`,
+			expected: `{"data":{"ndarray":[1]},"meta":{"puid":"1","tags":{"model":"a","debug_trace":[{"node":"a","method":"predict","output":{"x":1},"latencyMs":0}]}}}`,
+		},
+		{
+			protocol: api.ProtocolTensorflow,
+			response: `{"predictions":[1]}`,
+			expected: `{"predictions":[1],"debug":[{"node":"a","method":"predict","output":{"x":1},"latencyMs":0}]}`,
+		},
+		{
+			protocol: api.ProtocolV2,
+			response: `{"outputs":[],"parameters":{"content_type":"np"}}`,
+			expected: `{"outputs":[],"parameters":{"content_type":"np","debug_trace":[{"node":"a","method":"predict","output":{"x":1},"latencyMs":0}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		EnableDebugTrace(tt.protocol)
+		p := createTracedPredictorProcess("true")
+		p.trace = &debugTrace{}
+		p.trace.add(TraceStep{Node: "a", Method: TracePredict, Output: json.RawMessage(`{"x":1}`)})
+
+		response, err := p.addDebugTrace(&payload.BytesPayload{Msg: []byte(tt.response), ContentType: "application/json"})
+		g.Expect(err).Should(BeNil())
+		g.Expect(response.GetContentType()).Should(Equal("application/json"))
+		b, err := response.GetBytes()
+		g.Expect(err).Should(BeNil())
+		g.Expect(b).Should(MatchJSON(tt.expected))
+	}
+}
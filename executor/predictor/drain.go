@@ -0,0 +1,114 @@
+package predictor
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrDraining is reported by readiness checks once the executor has started shutting down.
+var ErrDraining = errors.New("draining")
+
+var (
+	// 1 once the executor has started shutting down
+	draining int32
+	// Closed once the executor has started shutting down
+	drainStarted = make(chan struct{})
+	drainOnce    sync.Once
+	// Requests, streams and jobs being handled
+	inFlight = newInFlightTracker()
+	// How often in-flight requests are checked while waiting for them to finish
+	drainPollInterval = 100 * time.Millisecond
+)
+
+// Drain makes readiness checks fail so load balancers stop sending requests before the servers are shut down.
+func Drain() {
+	atomic.StoreInt32(&draining, 1)
+	drainOnce.Do(func() { close(drainStarted) })
+}
+
+// DrainStarted returns a channel which is closed once the executor has started shutting down, so long-lived
+// connections can be closed.
+func DrainStarted() <-chan struct{} {
+	return drainStarted
+}
+
+// Draining returns whether the executor has started shutting down.
+func Draining() bool {
+	return atomic.LoadInt32(&draining) == 1
+}
+
+// InFlightRequest is a request, stream or job being handled.
+type InFlightRequest struct {
+	// What is being handled, such as an HTTP service or gRPC method
+	Name  string
+	Puid  string
+	Start time.Time
+}
+
+type inFlightTracker struct {
+	mutex    sync.Mutex
+	next     uint64
+	requests map[uint64]InFlightRequest
+}
+
+func newInFlightTracker() *inFlightTracker {
+	return &inFlightTracker{requests: map[uint64]InFlightRequest{}}
+}
+
+// TrackRequest counts a request as in flight until the returned function is called.
+func TrackRequest(name string, puid string) func() {
+	return inFlight.add(InFlightRequest{Name: name, Puid: puid, Start: time.Now()})
+}
+
+func (t *inFlightTracker) add(request InFlightRequest) func() {
+	t.mutex.Lock()
+	id := t.next
+	t.next++
+	t.requests[id] = request
+	t.mutex.Unlock()
+	return func() {
+		t.mutex.Lock()
+		delete(t.requests, id)
+		t.mutex.Unlock()
+	}
+}
+
+func (t *inFlightTracker) list() []InFlightRequest {
+	t.mutex.Lock()
+	requests := make([]InFlightRequest, 0, len(t.requests))
+	for _, request := range t.requests {
+		requests = append(requests, request)
+	}
+	t.mutex.Unlock()
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Start.Before(requests[j].Start)
+	})
+	return requests
+}
+
+// InFlight returns the requests being handled, oldest first.
+func InFlight() []InFlightRequest {
+	return inFlight.list()
+}
+
+// WaitForInFlight waits until no requests are being handled, returning those still in flight if ctx is done first.
+func WaitForInFlight(ctx context.Context) []InFlightRequest {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		requests := inFlight.list()
+		if len(requests) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return requests
+		case <-ticker.C:
+		}
+	}
+}
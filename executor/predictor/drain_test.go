@@ -0,0 +1,58 @@
+package predictor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestDrainFailsReadiness(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer func() {
+		atomic.StoreInt32(&draining, 0)
+		drainStarted = make(chan struct{})
+		drainOnce = sync.Once{}
+	}()
+	model := v1.MODEL
+	graph := &v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: &v1.Endpoint{Type: v1.REST}}
+	g.Expect(Ready(api.ProtocolSeldon, graph, false)).To(BeNil())
+
+	Drain()
+	g.Expect(Draining()).To(BeTrue())
+	g.Expect(DrainStarted()).To(BeClosed())
+	g.Expect(Ready(api.ProtocolSeldon, graph, false)).To(Equal(ErrDraining))
+}
+
+func TestWaitForInFlight(t *testing.T) {
+	g := NewGomegaWithT(t)
+	previous := drainPollInterval
+	drainPollInterval = time.Millisecond
+	defer func() { drainPollInterval = previous }()
+
+	g.Expect(WaitForInFlight(context.Background())).To(BeEmpty())
+
+	doneFirst := TrackRequest("predictions", "puid-1")
+	doneSecond := TrackRequest("/seldon.protos.Seldon/Predict", "puid-2")
+	g.Expect(InFlight()).To(HaveLen(2))
+	g.Expect(InFlight()[0].Puid).To(Equal("puid-1"))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		doneFirst()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	cutOff := WaitForInFlight(ctx)
+	g.Expect(cutOff).To(HaveLen(1))
+	g.Expect(cutOff[0].Name).To(Equal("/seldon.protos.Seldon/Predict"))
+	g.Expect(cutOff[0].Puid).To(Equal("puid-2"))
+
+	doneSecond()
+	g.Expect(WaitForInFlight(context.Background())).To(BeEmpty())
+}
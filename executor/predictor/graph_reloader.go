@@ -0,0 +1,280 @@
+package predictor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/cache"
+	"github.com/seldonio/seldon-core/executor/api/metric"
+	"github.com/seldonio/seldon-core/executor/api/util"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const (
+	GraphReloadLoaded   = "loaded"
+	GraphReloadRejected = "rejected"
+)
+
+// Swaps in the predictor spec reloaded from disk for new requests if set
+var graphReloader *GraphReloader
+
+// SetGraphReloader makes all servers send new requests through the graph most recently loaded by the reloader.
+func SetGraphReloader(r *GraphReloader) {
+	graphReloader = r
+}
+
+// ActiveSpec returns the predictor spec new requests should be sent through, which is the most recently reloaded
+// spec if the graph is being hot reloaded and spec otherwise. Requests should call it once and keep the result so
+// they finish on the graph they started with.
+func ActiveSpec(spec *v1.PredictorSpec) *v1.PredictorSpec {
+	if graphReloader == nil {
+		return spec
+	}
+	return graphReloader.Spec()
+}
+
+// GraphVersion returns a short hash identifying the contents of a predictor spec.
+func GraphVersion(spec *v1.PredictorSpec) string {
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// ValidateProtocols checks the executor can call each node of a graph served with protocol over transport in the
+// node's own protocol. Payloads are only converted between nodes speaking different protocols over REST.
+func ValidateProtocols(spec *v1.PredictorSpec, transport string, protocol string) error {
+	for _, pu := range v1.GetPredictiveUnitList(&spec.Graph) {
+		if pu.Protocol == "" {
+			continue
+		}
+		if !api.IsKnownProtocol(string(pu.Protocol)) {
+			return fmt.Errorf("graph node %s uses unknown protocol %s", pu.Name, pu.Protocol)
+		}
+		if transport == api.TransportGrpc && !api.IsSameProtocol(string(pu.Protocol), protocol) {
+			return fmt.Errorf("graph node %s uses protocol %s which differs from %s and is not supported with the grpc transport", pu.Name, pu.Protocol, protocol)
+		}
+	}
+	return nil
+}
+
+// ValidateGraph checks a reloaded predictor spec can replace the current one without restarting the executor
+// serving protocol over transport.
+func ValidateGraph(current *v1.PredictorSpec, next *v1.PredictorSpec, transport string, protocol string) error {
+	if next.Name != current.Name {
+		return fmt.Errorf("predictor name %s does not match %s", next.Name, current.Name)
+	}
+	if next.Graph.Name == "" {
+		return fmt.Errorf("graph is empty")
+	}
+	seen := map[string]bool{}
+	for _, pu := range v1.GetPredictiveUnitList(&next.Graph) {
+		if pu.Name == "" {
+			return fmt.Errorf("graph node has no name")
+		}
+		if seen[pu.Name] {
+			return fmt.Errorf("graph node %s is not unique", pu.Name)
+		}
+		seen[pu.Name] = true
+
+		if pu.Implementation != nil && *pu.Implementation == v1.RANDOM_ABTEST {
+			if len(pu.Children) != 2 {
+				return fmt.Errorf("graph node %s is a %s and must have 2 children", pu.Name, v1.RANDOM_ABTEST)
+			}
+			for _, param := range pu.Parameters {
+				if param.Name == "ratioA" {
+					ratioA, err := strconv.ParseFloat(param.Value, 32)
+					if err != nil || ratioA < 0 || ratioA > 1 {
+						return fmt.Errorf("graph node %s has invalid ratioA %s", pu.Name, param.Value)
+					}
+				}
+			}
+		} else if pu.Endpoint == nil || (pu.Endpoint.ServiceHost == "" && pu.Endpoint.UnixSocket == "") {
+			return fmt.Errorf("graph node %s has no endpoint", pu.Name)
+		}
+
+		// Protocols, connections to nodes over TLS or unix sockets and per-node concurrency limits are checked
+		// and set up when the executor starts
+		if old := v1.GetPredictiveUnit(&current.Graph, pu.Name); old != nil {
+			if old.Protocol != pu.Protocol {
+				return fmt.Errorf("graph node %s changes its protocol which requires a restart", pu.Name)
+			}
+			if !reflect.DeepEqual(old.MaxConcurrency, pu.MaxConcurrency) || !reflect.DeepEqual(old.MaxQueueLength, pu.MaxQueueLength) {
+				return fmt.Errorf("graph node %s changes its concurrency limits which requires a restart", pu.Name)
+			}
+			if old.Endpoint != nil && pu.Endpoint != nil && (old.Endpoint.UnixSocket != pu.Endpoint.UnixSocket || !reflect.DeepEqual(old.Endpoint.TLS, pu.Endpoint.TLS)) {
+				return fmt.Errorf("graph node %s changes its connection type which requires a restart", pu.Name)
+			}
+		} else if pu.Endpoint != nil && pu.Endpoint.TLS != nil {
+			return fmt.Errorf("graph node %s is added with TLS which requires a restart", pu.Name)
+		} else if pu.Endpoint != nil && pu.Endpoint.UnixSocket != "" {
+			return fmt.Errorf("graph node %s is added with a unix socket which requires a restart", pu.Name)
+		} else if pu.MaxConcurrency != nil {
+			return fmt.Errorf("graph node %s is added with a concurrency limit which requires a restart", pu.Name)
+		}
+	}
+	return ValidateProtocols(next, transport, protocol)
+}
+
+type activeGraph struct {
+	spec    *v1.PredictorSpec
+	version string
+	// Response caches of this version of the graph, so responses of other versions aren't returned
+	caches *cache.Caches
+}
+
+// GraphReloader holds the predictor spec loaded from a file and swaps in a new spec whenever the file changes,
+// so a graph mounted from a ConfigMap can be updated without restarting. Invalid updates are rejected and the
+// previous spec is kept.
+type GraphReloader struct {
+	predictorName  string
+	filename       string
+	deploymentName string
+	transport      string
+	protocol       string
+	log            logr.Logger
+	mu             sync.RWMutex
+	active         activeGraph
+	watcher        *fsnotify.Watcher
+	versionInfo    *prometheus.GaugeVec
+	reloads        *prometheus.CounterVec
+}
+
+// NewGraphReloader starts watching the directory containing filename, using spec until the file changes. Reloaded
+// graphs are checked against the transport and protocol the executor serves.
+func NewGraphReloader(predictorName string, filename string, spec *v1.PredictorSpec, deploymentName string, transport string, protocol string, log logr.Logger) (*GraphReloader, error) {
+	r := &GraphReloader{
+		predictorName:  predictorName,
+		filename:       filename,
+		deploymentName: deploymentName,
+		transport:      transport,
+		protocol:       protocol,
+		log:            log.WithName("GraphReloader"),
+	}
+	r.versionInfo, r.reloads = newGraphMetrics(deploymentName)
+	version := GraphVersion(spec)
+	caches, err := cache.NewCaches(spec, version, deploymentName)
+	if err != nil {
+		return nil, err
+	}
+	r.swap(spec, version, caches)
+
+	watcher, err := util.WatchFiles([]string{filename}, "graph", r.Reload, r.log)
+	if err != nil {
+		return nil, err
+	}
+	r.watcher = watcher
+	return r, nil
+}
+
+func newGraphMetrics(deploymentName string) (*prometheus.GaugeVec, *prometheus.CounterVec) {
+	versionInfo := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:        metric.GraphVersionMetricName,
+			Help:        "Version of the graph new requests are sent through, set to 1 for the active version",
+			ConstLabels: prometheus.Labels{metric.DeploymentNameMetric: deploymentName},
+		},
+		[]string{metric.GraphVersionMetric},
+	)
+	if err := prometheus.Register(versionInfo); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			versionInfo = e.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+	reloads := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        metric.GraphReloadsMetricName,
+			Help:        "Number of times the graph was reloaded from disk",
+			ConstLabels: prometheus.Labels{metric.DeploymentNameMetric: deploymentName},
+		},
+		[]string{metric.GraphReloadResultMetric},
+	)
+	if err := prometheus.Register(reloads); err != nil {
+		if e, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			reloads = e.ExistingCollector.(*prometheus.CounterVec)
+		}
+	}
+	return versionInfo, reloads
+}
+
+func (r *GraphReloader) swap(spec *v1.PredictorSpec, version string, caches *cache.Caches) {
+	r.mu.Lock()
+	previous := r.active.version
+	r.active = activeGraph{spec: spec, version: version, caches: caches}
+	r.mu.Unlock()
+
+	if previous != "" {
+		r.versionInfo.DeleteLabelValues(previous)
+	}
+	r.versionInfo.WithLabelValues(version).Set(1)
+}
+
+// Reload loads the predictor spec from disk. If it is invalid or unchanged the previous spec is kept.
+func (r *GraphReloader) Reload() error {
+	spec, err := getPredictorFromFile(r.predictorName, r.filename)
+	if err != nil {
+		r.reloads.WithLabelValues(GraphReloadRejected).Inc()
+		return errors.Wrapf(err, "failed to load graph %s", r.filename)
+	}
+	current := r.Spec()
+	if err := ValidateGraph(current, spec, r.transport, r.protocol); err != nil {
+		r.reloads.WithLabelValues(GraphReloadRejected).Inc()
+		return errors.Wrapf(err, "invalid graph %s", r.filename)
+	}
+	version := GraphVersion(spec)
+	if version == r.Version() {
+		return nil
+	}
+	// Each version of the graph starts with empty caches
+	caches, err := cache.NewCaches(spec, version, r.deploymentName)
+	if err != nil {
+		r.reloads.WithLabelValues(GraphReloadRejected).Inc()
+		return errors.Wrapf(err, "invalid graph %s", r.filename)
+	}
+	r.swap(spec, version, caches)
+	r.reloads.WithLabelValues(GraphReloadLoaded).Inc()
+	r.log.Info("Loaded graph", "path", r.filename, "version", version)
+	return nil
+}
+
+// Spec returns the predictor spec new requests should be sent through.
+func (r *GraphReloader) Spec() *v1.PredictorSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active.spec
+}
+
+// Version returns the version of the active predictor spec.
+func (r *GraphReloader) Version() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active.version
+}
+
+// Caches returns the response caches of the active graph for a request sent through the graph whose root is root,
+// or nil if the request is still on a graph which has been replaced so its responses aren't cached for the new one.
+func (r *GraphReloader) Caches(root *v1.PredictiveUnit) *cache.Caches {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if root != &r.active.spec.Graph {
+		return nil
+	}
+	return r.active.caches
+}
+
+// Close stops watching for changes.
+func (r *GraphReloader) Close() error {
+	return r.watcher.Close()
+}
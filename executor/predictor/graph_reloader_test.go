@@ -0,0 +1,207 @@
+package predictor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/seldonio/seldon-core/executor/api"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const graphTemplate = `apiVersion: machinelearning.seldon.io/v1
+kind: SeldonDeployment
+metadata:
+  name: mymodel
+spec:
+  predictors:
+  - name: p1
+    graph:
+      name: %s
+      type: MODEL
+      endpoint:
+        service_host: localhost
+        service_port: 9000
+        httpPort: 9000
+`
+
+func writeGraph(g *GomegaWithT, dir string, contents string) {
+	// Write to a temporary file and rename so the reloader never sees a partially written graph
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "graph.yaml.tmp"), []byte(contents), 0600)).To(BeNil())
+	g.Expect(os.Rename(filepath.Join(dir, "graph.yaml.tmp"), filepath.Join(dir, "graph.yaml"))).To(BeNil())
+}
+
+func graphName() string {
+	return ActiveSpec(nil).Graph.Name
+}
+
+func TestGraphReloader(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "graph.yaml")
+	writeGraph(g, dir, fmt.Sprintf(graphTemplate, "model-a"))
+
+	spec, err := GetPredictor("p1", filename, "", "", nil)
+	g.Expect(err).To(BeNil())
+	r, err := NewGraphReloader("p1", filename, spec, "reload", api.TransportRest, api.ProtocolSeldon, logf.Log)
+	g.Expect(err).To(BeNil())
+	defer r.Close()
+	SetGraphReloader(r)
+	defer SetGraphReloader(nil)
+
+	initial := r.Version()
+	g.Expect(initial).To(Equal(GraphVersion(spec)))
+	g.Expect(testutil.ToFloat64(r.versionInfo.WithLabelValues(initial))).To(Equal(1.0))
+
+	// In-flight requests keep the spec they started with
+	inflight := ActiveSpec(nil)
+
+	writeGraph(g, dir, fmt.Sprintf(graphTemplate, "model-b"))
+	g.Eventually(graphName, 5*time.Second, 10*time.Millisecond).Should(Equal("model-b"))
+	g.Expect(inflight.Graph.Name).To(Equal("model-a"))
+	g.Expect(r.Version()).ToNot(Equal(initial))
+	g.Expect(testutil.ToFloat64(r.versionInfo.WithLabelValues(r.Version()))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(r.reloads.WithLabelValues(GraphReloadLoaded))).To(BeNumerically(">=", 1))
+
+	// Invalid graphs are rejected and the previous one kept
+	rejected := testutil.ToFloat64(r.reloads.WithLabelValues(GraphReloadRejected))
+	writeGraph(g, dir, "not: [valid")
+	g.Eventually(func() float64 {
+		return testutil.ToFloat64(r.reloads.WithLabelValues(GraphReloadRejected))
+	}, 5*time.Second, 10*time.Millisecond).Should(BeNumerically(">", rejected))
+	g.Expect(graphName()).To(Equal("model-b"))
+}
+
+func TestValidateGraph(t *testing.T) {
+	g := NewGomegaWithT(t)
+	model := v1.MODEL
+	abtest := v1.RANDOM_ABTEST
+	maxConcurrency := int32(2)
+	endpoint := &v1.Endpoint{ServiceHost: "localhost", HttpPort: 9000}
+	current := &v1.PredictorSpec{
+		Name:  "p1",
+		Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint},
+	}
+
+	tests := []struct {
+		name      string
+		next      v1.PredictorSpec
+		transport string
+		valid     bool
+	}{
+		{
+			name:  "parameters changed",
+			next:  v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint, Parameters: []v1.Parameter{{Name: "a", Value: "1"}}}},
+			valid: true,
+		},
+		{
+			name: "predictor renamed",
+			next: v1.PredictorSpec{Name: "p2", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint}},
+		},
+		{
+			name: "empty graph",
+			next: v1.PredictorSpec{Name: "p1"},
+		},
+		{
+			name: "no endpoint",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model}},
+		},
+		{
+			name: "duplicate node",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint, Children: []v1.PredictiveUnit{{Name: "model", Type: &model, Endpoint: endpoint}}}},
+		},
+		{
+			name: "protocol changed",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint, Protocol: v1.ProtocolTensorflow}},
+		},
+		{
+			name: "unix socket added",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: &v1.Endpoint{ServiceHost: "localhost", UnixSocket: "model.sock"}}},
+		},
+		{
+			name: "tls changed",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: &v1.Endpoint{ServiceHost: "localhost", HttpPort: 9000, TLS: &v1.EndpointTLS{}}}},
+		},
+		{
+			name: "node added",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: endpoint}}}},
+			valid: true,
+		},
+		{
+			name: "node added with tls",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: &v1.Endpoint{ServiceHost: "localhost", HttpPort: 9000, TLS: &v1.EndpointTLS{}}}}}},
+		},
+		{
+			name: "concurrency limit changed",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint, MaxConcurrency: &maxConcurrency}},
+		},
+		{
+			name: "node added with concurrency limit",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: endpoint, MaxConcurrency: &maxConcurrency}}}},
+		},
+		{
+			name: "node added with unix socket",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: &v1.Endpoint{ServiceHost: "localhost", UnixSocket: "other.sock"}}}}},
+		},
+		{
+			name: "node added with protocol",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: endpoint, Protocol: v1.ProtocolV2}}}},
+			transport: api.TransportRest,
+			valid:     true,
+		},
+		{
+			name: "node added with protocol over grpc",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: endpoint, Protocol: v1.ProtocolV2}}}},
+			transport: api.TransportGrpc,
+		},
+		{
+			name: "node added with same protocol over grpc",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: endpoint, Protocol: v1.ProtocolSeldon}}}},
+			transport: api.TransportGrpc,
+			valid:     true,
+		},
+		{
+			name: "node added with unknown protocol",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "model", Type: &model, Endpoint: endpoint,
+				Children: []v1.PredictiveUnit{{Name: "other", Type: &model, Endpoint: endpoint, Protocol: "foo"}}}},
+			transport: api.TransportRest,
+		},
+		{
+			name: "abtest",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "ab", Implementation: &abtest, Parameters: []v1.Parameter{{Name: "ratioA", Value: "0.2"}},
+				Children: []v1.PredictiveUnit{{Name: "a", Type: &model, Endpoint: endpoint}, {Name: "b", Type: &model, Endpoint: endpoint}}}},
+			valid: true,
+		},
+		{
+			name: "abtest invalid ratio",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "ab", Implementation: &abtest, Parameters: []v1.Parameter{{Name: "ratioA", Value: "2"}},
+				Children: []v1.PredictiveUnit{{Name: "a", Type: &model, Endpoint: endpoint}, {Name: "b", Type: &model, Endpoint: endpoint}}}},
+		},
+		{
+			name: "abtest one child",
+			next: v1.PredictorSpec{Name: "p1", Graph: v1.PredictiveUnit{Name: "ab", Implementation: &abtest,
+				Children: []v1.PredictiveUnit{{Name: "a", Type: &model, Endpoint: endpoint}}}},
+		},
+	}
+	for _, test := range tests {
+		err := ValidateGraph(current, &test.next, test.transport, api.ProtocolSeldon)
+		if test.valid {
+			g.Expect(err).To(BeNil(), test.name)
+		} else {
+			g.Expect(err).ToNot(BeNil(), test.name)
+		}
+	}
+}
@@ -12,6 +12,7 @@ type GraphMetadata struct {
 	Models       map[string]payload.ModelMetadata `json:"models"`
 	GraphInputs  interface{}                      `json:"graphinputs"`
 	GraphOutputs interface{}                      `json:"graphoutputs"`
+	Version      string                           `json:"version,omitempty"`
 }
 
 type MetadataTensor struct {
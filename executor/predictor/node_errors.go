@@ -0,0 +1,88 @@
+package predictor
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Period over which recent errors are counted
+	RecentErrorsWindow = 5 * time.Minute
+	errorBucketSize    = time.Minute
+	errorBuckets       = int(RecentErrorsWindow / errorBucketSize)
+)
+
+// Counts the errors returned by calls to each graph node
+var nodeErrors = newErrorCounter(time.Now)
+
+// NodeErrorCount is the number of errors returned by calls to a graph node.
+type NodeErrorCount struct {
+	// Errors in the last RecentErrorsWindow
+	Recent int64 `json:"recent"`
+	// Errors since the executor started
+	Total int64 `json:"total"`
+}
+
+// NodeErrors returns the errors returned by calls to each graph node which has failed since the executor started.
+func NodeErrors() map[string]NodeErrorCount {
+	return nodeErrors.counts()
+}
+
+// nodeErrorBuckets counts a node's errors in one minute buckets, reused as the window moves on.
+type nodeErrorBuckets struct {
+	total   int64
+	counts  [errorBuckets]int64
+	minutes [errorBuckets]int64
+}
+
+type errorCounter struct {
+	mutex sync.Mutex
+	nodes map[string]*nodeErrorBuckets
+	now   func() time.Time
+}
+
+func newErrorCounter(now func() time.Time) *errorCounter {
+	return &errorCounter{
+		nodes: map[string]*nodeErrorBuckets{},
+		now:   now,
+	}
+}
+
+func (c *errorCounter) minute() int64 {
+	return c.now().UnixNano() / int64(errorBucketSize)
+}
+
+func (c *errorCounter) add(node string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	b, ok := c.nodes[node]
+	if !ok {
+		b = &nodeErrorBuckets{}
+		c.nodes[node] = b
+	}
+	minute := c.minute()
+	i := int(minute % int64(errorBuckets))
+	if b.minutes[i] != minute {
+		b.minutes[i] = minute
+		b.counts[i] = 0
+	}
+	b.counts[i]++
+	b.total++
+}
+
+func (c *errorCounter) counts() map[string]NodeErrorCount {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	minute := c.minute()
+	counts := make(map[string]NodeErrorCount, len(c.nodes))
+	for node, b := range c.nodes {
+		count := NodeErrorCount{Total: b.total}
+		for i := range b.counts {
+			if minute-b.minutes[i] < int64(errorBuckets) {
+				count.Recent += b.counts[i]
+			}
+		}
+		counts[node] = count
+	}
+	return counts
+}
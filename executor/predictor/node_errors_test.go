@@ -0,0 +1,53 @@
+package predictor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+func TestErrorCounter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Unix(1000*60, 0)
+	c := newErrorCounter(func() time.Time { return now })
+
+	c.add("a")
+	c.add("a")
+	now = now.Add(2 * time.Minute)
+	c.add("a")
+	c.add("b")
+	g.Expect(c.counts()).To(Equal(map[string]NodeErrorCount{
+		"a": {Recent: 3, Total: 3},
+		"b": {Recent: 1, Total: 1},
+	}))
+
+	// The first errors leave the window
+	now = now.Add(4 * time.Minute)
+	g.Expect(c.counts()["a"]).To(Equal(NodeErrorCount{Recent: 1, Total: 3}))
+
+	// Buckets are reused once their minute has passed
+	c.add("a")
+	now = now.Add(10 * time.Minute)
+	c.add("a")
+	g.Expect(c.counts()["a"]).To(Equal(NodeErrorCount{Recent: 1, Total: 5}))
+}
+
+func TestNodeErrorsCounted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	nodeErrors = newErrorCounter(time.Now)
+	defer func() { nodeErrors = newErrorCounter(time.Now) }()
+
+	model := v1.MODEL
+	graph := &v1.PredictiveUnit{
+		Name:     "failing",
+		Type:     &model,
+		Endpoint: &v1.Endpoint{Type: v1.REST},
+	}
+	method := v1.TRANSFORM_INPUT
+	_, err := createPredictorProcessWithError(t, &method, errors.New("boom"), nil).Predict(graph, createPredictPayload(g))
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(NodeErrors()).To(Equal(map[string]NodeErrorCount{"failing": {Recent: 1, Total: 1}}))
+}
@@ -6,12 +6,17 @@ import (
 	"net/url"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	guuid "github.com/google/uuid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/seldonio/seldon-core/executor/api/cache"
 	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/concurrency"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/tracing"
 	"github.com/seldonio/seldon-core/executor/api/util"
 
 	payloadLogger "github.com/seldonio/seldon-core/executor/logger"
@@ -27,8 +32,22 @@ const (
 var (
 	envRequestLoggerDefaultEndpoint = os.Getenv(ENV_REQUEST_LOGGER_DEFAULT_ENDPOINT)
 	envEnableRoutingInjection       = len(os.Getenv(ENV_ENABLE_ROUTING_INJECTION)) != 0
+	// Bounds the number of requests sent through the graph at once if set
+	concurrencyLimiter *concurrency.Limiter
+	// Bound the number of calls made to each graph node at once
+	bulkheads concurrency.Bulkheads
 )
 
+// SetConcurrencyLimiter limits the number of requests sent through the graph at once by all servers.
+func SetConcurrencyLimiter(limiter *concurrency.Limiter) {
+	concurrencyLimiter = limiter
+}
+
+// SetBulkheads limits the number of calls made to graph nodes at once by all servers.
+func SetBulkheads(b concurrency.Bulkheads) {
+	bulkheads = b
+}
+
 // Routing-related constants.
 // Ref: https://github.com/SeldonIO/seldon-core/blob/master/doc/source/analytics/routers.md
 const (
@@ -49,9 +68,20 @@ type PredictorProcess struct {
 	Routing           map[string]int32
 	RoutingMutex      *sync.RWMutex
 	ModelNameOverride string
+	// Steps of the request if a debug trace was requested
+	trace *debugTrace
+	// Response caches of the graph the request is sent through
+	caches *cache.Caches
 }
 
+// NewPredictorProcess creates a process for a request, whose log entries carry the request's PUID and trace id.
 func NewPredictorProcess(context context.Context, client client.SeldonApiClient, log logr.Logger, serverUrl *url.URL, namespace string, meta map[string][]string, modelNameOverride string) PredictorProcess {
+	if puid, ok := context.Value(payload.SeldonPUIDHeader).(string); ok {
+		log = log.WithValues("puid", puid)
+	}
+	if traceID := tracing.TraceID(opentracing.SpanFromContext(context)); traceID != "" {
+		log = log.WithValues("traceId", traceID)
+	}
 	return PredictorProcess{
 		Ctx:               context,
 		Client:            client,
@@ -92,6 +122,17 @@ func (p *PredictorProcess) getModelName(node *v1.PredictiveUnit) string {
 	return modelName
 }
 
+// nodeContext returns the context of calls to a node, which names the node as its model name may be overridden.
+func (p *PredictorProcess) nodeContext(node *v1.PredictiveUnit) context.Context {
+	return client.WithNodeName(p.Ctx, node.Name)
+}
+
+// acquireBulkhead waits for a slot to call a node, failing fast with a concurrency.BulkheadFullError if
+// its bulkhead is full.
+func (p *PredictorProcess) acquireBulkhead(node *v1.PredictiveUnit) (func(), error) {
+	return bulkheads.Acquire(p.Ctx, node.Name)
+}
+
 func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.SeldonPayload, puid string) (tmsg payload.SeldonPayload, err error) {
 	callModel := false
 	callTransformInput := false
@@ -110,13 +151,21 @@ func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.S
 	modelName := p.getModelName(node)
 
 	if callModel || callTransformInput {
-		msg, err := p.Client.Chain(p.Ctx, modelName, msg)
+		msg, err := p.Client.Chain(p.nodeContext(node), modelName, msg)
 		if err != nil {
 			return nil, err
 		}
 
+		method := TracePredict
+		if callTransformInput {
+			method = TraceTransformInput
+		}
+		nodeCache := p.caches.Node(node.Name)
+		key, cached := p.lookupCache(nodeCache, node, method, msg)
+		skipLogging := cached != nil && nodeCache.SkipLogging
+
 		//Log Request
-		if node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
+		if !skipLogging && node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
 			err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceRequest, msg, puid)
 			if err != nil {
 				return nil, err
@@ -127,12 +176,28 @@ func (p *PredictorProcess) transformInput(node *v1.PredictiveUnit, msg payload.S
 		p.Routing[node.Name] = -1
 		p.RoutingMutex.Unlock()
 
-		if callTransformInput {
-			tmsg, err = p.Client.TransformInput(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		if cached != nil {
+			tmsg = cached
+			p.recordCachedCall(node, method, p.traceInput(msg), tmsg)
 		} else {
-			tmsg, err = p.Client.Predict(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+			release, err := p.acquireBulkhead(node)
+			if err != nil {
+				return nil, err
+			}
+			start, input := time.Now(), p.traceInput(msg)
+			if callTransformInput {
+				tmsg, err = p.Client.TransformInput(p.nodeContext(node), modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+			} else {
+				tmsg, err = p.Client.Predict(p.nodeContext(node), modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+			}
+			p.recordCall(node, method, start, input, tmsg, nil, err)
+			release()
+			if err != nil {
+				return tmsg, err
+			}
+			p.storeCache(nodeCache, key, tmsg, err)
 		}
-		if tmsg != nil && err == nil {
+		if tmsg != nil && !skipLogging {
 			// Log Response
 			if node.Logger != nil && (node.Logger.Mode == v1.LogResponse || node.Logger.Mode == v1.LogAll) {
 				err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceResponse, tmsg, puid)
@@ -162,21 +227,40 @@ func (p *PredictorProcess) transformOutput(node *v1.PredictiveUnit, msg payload.
 	modelName := p.getModelName(node)
 
 	if callClient {
-		msg, err := p.Client.Chain(p.Ctx, modelName, msg)
+		msg, err := p.Client.Chain(p.nodeContext(node), modelName, msg)
 		if err != nil {
 			return nil, err
 		}
 
+		nodeCache := p.caches.Node(node.Name)
+		key, tmsg := p.lookupCache(nodeCache, node, TraceTransformOutput, msg)
+		skipLogging := tmsg != nil && nodeCache.SkipLogging
+
 		//Log Request
-		if node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
+		if !skipLogging && node.Logger != nil && (node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll) {
 			err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceRequest, msg, puid)
 			if err != nil {
 				return nil, err
 			}
 		}
 
-		tmsg, err := p.Client.TransformOutput(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
-		if tmsg != nil && err == nil {
+		if tmsg != nil {
+			p.recordCachedCall(node, TraceTransformOutput, p.traceInput(msg), tmsg)
+		} else {
+			release, err := p.acquireBulkhead(node)
+			if err != nil {
+				return nil, err
+			}
+			start, input := time.Now(), p.traceInput(msg)
+			tmsg, err = p.Client.TransformOutput(p.nodeContext(node), modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+			p.recordCall(node, TraceTransformOutput, start, input, tmsg, nil, err)
+			release()
+			if err != nil {
+				return tmsg, err
+			}
+			p.storeCache(nodeCache, key, tmsg, err)
+		}
+		if tmsg != nil && !skipLogging {
 			// Log Response
 			if node.Logger != nil && (node.Logger.Mode == v1.LogResponse || node.Logger.Mode == v1.LogAll) {
 				err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceResponse, tmsg, puid)
@@ -207,7 +291,12 @@ func (p *PredictorProcess) feedback(node *v1.PredictiveUnit, msg payload.SeldonP
 	modelName := p.getModelName(node)
 
 	if callClient {
-		return p.Client.Feedback(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		release, err := p.acquireBulkhead(node)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return p.Client.Feedback(p.nodeContext(node), modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
 	} else {
 		return msg, nil
 	}
@@ -237,9 +326,20 @@ func (p *PredictorProcess) route(node *v1.PredictiveUnit, msg payload.SeldonPayl
 	modelName := p.getModelName(node)
 
 	if callClient {
-		return p.Client.Route(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		release, err := p.acquireBulkhead(node)
+		if err != nil {
+			return -1, err
+		}
+		defer release()
+		start, input := time.Now(), p.traceInput(msg)
+		route, err := p.Client.Route(p.nodeContext(node), modelName, node.Endpoint.ServiceHost, p.getPort(node), msg, p.Meta.Meta)
+		p.recordCall(node, TraceRoute, start, input, nil, &route, err)
+		return route, err
 	} else if node.Implementation != nil && *node.Implementation == v1.RANDOM_ABTEST {
-		return p.abTestRouter(node)
+		start := time.Now()
+		route, err := p.abTestRouter(node)
+		p.recordCall(node, TraceRoute, start, nil, nil, &route, err)
+		return route, err
 	} else {
 		return -1, nil
 	}
@@ -270,7 +370,14 @@ func (p *PredictorProcess) aggregate(node *v1.PredictiveUnit, cmsg []payload.Sel
 		p.RoutingMutex.Lock()
 		p.Routing[node.Name] = -1
 		p.RoutingMutex.Unlock()
-		tmsg, err := p.Client.Combine(p.Ctx, modelName, node.Endpoint.ServiceHost, p.getPort(node), cmsg, p.Meta.Meta)
+		release, err := p.acquireBulkhead(node)
+		if err != nil {
+			return nil, err
+		}
+		start, input := time.Now(), p.traceInput(cmsg...)
+		tmsg, err := p.Client.Combine(p.nodeContext(node), modelName, node.Endpoint.ServiceHost, p.getPort(node), cmsg, p.Meta.Meta)
+		p.recordCall(node, TraceAggregate, start, input, tmsg, nil, err)
+		release()
 		if tmsg != nil && err == nil {
 			// Log Response
 			if node.Logger != nil && (node.Logger.Mode == v1.LogResponse || node.Logger.Mode == v1.LogAll) {
@@ -307,7 +414,7 @@ func (p *PredictorProcess) predictChildren(node *v1.PredictiveUnit, msg payload.
 			for i, nodeChild := range node.Children {
 				wg.Add(1)
 				go func(i int, nodeChild v1.PredictiveUnit, msg payload.SeldonPayload) {
-					cmsgs[i], errs[i] = p.Predict(&nodeChild, msg)
+					cmsgs[i], errs[i] = p.predictNode(&nodeChild, msg)
 					wg.Done()
 				}(i, nodeChild, msg)
 			}
@@ -316,10 +423,13 @@ func (p *PredictorProcess) predictChildren(node *v1.PredictiveUnit, msg payload.
 			p.Routing[node.Name] = -1
 			p.RoutingMutex.Unlock()
 			for i, err := range errs {
-				if err != nil {
+				if err != nil && !concurrency.IsBulkheadFull(err) {
 					return cmsgs[i], err
 				}
 			}
+			if cmsgs, err = answeredChildren(cmsgs, errs); err != nil {
+				return nil, err
+			}
 		} else if route == routeToNoChildren { // Returns msg as is.
 			//Abort and return request
 			p.RoutingMutex.Lock()
@@ -328,7 +438,7 @@ func (p *PredictorProcess) predictChildren(node *v1.PredictiveUnit, msg payload.
 			return msg, nil
 		} else { // Calls SeldonApiClient.Predict.
 			cmsgs = make([]payload.SeldonPayload, 1)
-			cmsgs[0], err = p.Predict(&node.Children[route], msg)
+			cmsgs[0], err = p.predictNode(&node.Children[route], msg)
 			p.RoutingMutex.Lock()
 			p.Routing[node.Name] = int32(route)
 			p.RoutingMutex.Unlock()
@@ -353,6 +463,21 @@ func (p *PredictorProcess) predictChildren(node *v1.PredictiveUnit, msg payload.
 	}
 }
 
+// answeredChildren returns the responses of the children a request was sent to, leaving out the children whose
+// bulkhead was full so the responses of their siblings can still be combined. It fails if no child answered.
+func answeredChildren(cmsgs []payload.SeldonPayload, errs []error) ([]payload.SeldonPayload, error) {
+	answered := make([]payload.SeldonPayload, 0, len(cmsgs))
+	for i, err := range errs {
+		if err == nil {
+			answered = append(answered, cmsgs[i])
+		}
+	}
+	if len(answered) == 0 {
+		return nil, errs[0]
+	}
+	return answered, nil
+}
+
 func (p *PredictorProcess) feedbackChildren(node *v1.PredictiveUnit, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
 	if node.Children != nil && len(node.Children) > 0 {
 
@@ -374,10 +499,13 @@ func (p *PredictorProcess) feedbackChildren(node *v1.PredictiveUnit, msg payload
 			}
 			wg.Wait()
 			for i, err := range errs {
-				if err != nil {
+				if err != nil && !concurrency.IsBulkheadFull(err) {
 					return cmsgs[i], err
 				}
 			}
+			if cmsgs, err = answeredChildren(cmsgs, errs); err != nil {
+				return nil, err
+			}
 		} else {
 			cmsgs = make([]payload.SeldonPayload, 1)
 			cmsgs[0], err = p.Feedback(&node.Children[route], msg)
@@ -426,6 +554,8 @@ func (p *PredictorProcess) logPayload(nodeName string, logger *v1.Logger, reqTyp
 			SourceUri:       p.ServerUrl,
 			ModelId:         nodeName,
 			RequestId:       puid,
+			ClientIdentity:  p.Meta.GetAsString(payload.SeldonClientIdentityHeader),
+			JwtClaims:       p.Meta.GetAsString(payload.SeldonJwtClaimsHeader),
 		})
 		if err != nil {
 			p.Log.Error(err, "failed to log request")
@@ -442,7 +572,44 @@ func (p *PredictorProcess) getPUIDHeader() (string, error) {
 	return "", fmt.Errorf(NilPUIDError)
 }
 
-func (p *PredictorProcess) Predict(node *v1.PredictiveUnit, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
+// Predict sends a request through the graph, waiting for a request slot first if the concurrency is limited.
+// The response includes a trace of the calls made to each node if the request asked for one and tracing is enabled.
+func (p *PredictorProcess) Predict(node *v1.PredictiveUnit, msg payload.SeldonPayload) (response payload.SeldonPayload, err error) {
+	p.caches = requestCaches(p.Ctx, node)
+	graphCache := p.caches.GraphCache()
+	key, cached := p.lookupCache(graphCache, node, TracePredict, msg)
+	if cached == nil && concurrencyLimiter != nil {
+		var release func(error)
+		// Assigned to the named result so the limiter is released with the request's error
+		release, err = concurrencyLimiter.Acquire(p.Ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			release(err)
+		}()
+	}
+	if p.debugTraceRequested() {
+		p.trace = &debugTrace{}
+	}
+	if cached != nil {
+		response, err = p.cachedGraphResponse(node, graphCache, msg, cached)
+	} else {
+		response, err = p.predictNode(node, msg)
+		p.storeCache(graphCache, key, response, err)
+	}
+	if p.trace == nil || err != nil || response == nil {
+		return response, err
+	}
+	traced, traceErr := p.addDebugTrace(response)
+	if traceErr != nil {
+		p.Log.Error(traceErr, "Failed to add debug trace to response")
+		return response, nil
+	}
+	return traced, nil
+}
+
+func (p *PredictorProcess) predictNode(node *v1.PredictiveUnit, msg payload.SeldonPayload) (payload.SeldonPayload, error) {
 	puid, err := p.getPUIDHeader()
 	if err != nil {
 		return nil, err
@@ -471,7 +638,7 @@ func (p *PredictorProcess) Status(node *v1.PredictiveUnit, modelName string, msg
 	if nodeModel := v1.GetPredictiveUnit(node, modelName); nodeModel == nil {
 		return nil, fmt.Errorf("Failed to find model %s", modelName)
 	} else {
-		return p.Client.Status(p.Ctx, modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
+		return p.Client.Status(p.nodeContext(nodeModel), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
 	}
 }
 
@@ -479,7 +646,7 @@ func (p *PredictorProcess) Metadata(node *v1.PredictiveUnit, modelName string, m
 	if nodeModel := v1.GetPredictiveUnit(node, modelName); nodeModel == nil {
 		return nil, fmt.Errorf("Failed to find model %s", modelName)
 	} else {
-		return p.Client.Metadata(p.Ctx, modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
+		return p.Client.Metadata(p.nodeContext(nodeModel), modelName, nodeModel.Endpoint.ServiceHost, p.getPort(nodeModel), msg, p.Meta.Meta)
 	}
 }
 
@@ -497,6 +664,10 @@ func (p *PredictorProcess) GraphMetadata(spec *v1.PredictorSpec) (*GraphMetadata
 	inputNodeMeta, outputNodeMeta := output.getEdgeNodes(&spec.Graph)
 	output.GraphInputs = inputNodeMeta.Inputs
 	output.GraphOutputs = outputNodeMeta.Outputs
+	// Identify which graph answered when it is being hot reloaded
+	if graphReloader != nil {
+		output.Version = GraphVersion(spec)
+	}
 
 	return output, nil
 }
@@ -523,7 +694,7 @@ func (p *PredictorProcess) Feedback(node *v1.PredictiveUnit, msg payload.SeldonP
 }
 
 func (p *PredictorProcess) ModelMetadataMap(node *v1.PredictiveUnit) (map[string]payload.ModelMetadata, error) {
-	resPayload, err := p.Client.ModelMetadata(p.Ctx, node.Name, node.Endpoint.ServiceHost, p.getPort(node), nil, p.Meta.Meta)
+	resPayload, err := p.Client.ModelMetadata(p.nodeContext(node), node.Name, node.Endpoint.ServiceHost, p.getPort(node), nil, p.Meta.Meta)
 	if err != nil {
 		return nil, err
 	}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/jsonpb"
 	. "github.com/onsi/gomega"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/concurrency"
 	"github.com/seldonio/seldon-core/executor/api/grpc"
 	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
 	"github.com/seldonio/seldon-core/executor/api/payload"
@@ -696,3 +701,176 @@ func TestModelWithLogRequestsForRouter(t *testing.T) {
 	g.Eventually(func() bool { return logged }).Should(Equal(true))
 	g.Expect(logMessagesReceived).To(Equal(2))
 }
+
+func TestConcurrencyLimitLoweredByTimeouts(t *testing.T) {
+	g := NewGomegaWithT(t)
+	limiter := concurrency.NewLimiter(10, 0, 0, time.Hour, "test")
+	SetConcurrencyLimiter(limiter)
+	defer SetConcurrencyLimiter(nil)
+
+	model := v1.MODEL
+	graph := &v1.PredictiveUnit{Type: &model, Endpoint: &v1.Endpoint{Type: v1.REST}}
+	method := v1.TRANSFORM_INPUT
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	pp := NewPredictorProcess(ctx, &test.SeldonMessageTestClient{ErrMethod: &method, Err: context.DeadlineExceeded}, logf.Log, url, "default", map[string][]string{}, "")
+
+	_, err := pp.Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(MatchError(context.DeadlineExceeded))
+	g.Expect(limiter.Limit()).Should(Equal(9))
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	g := NewGomegaWithT(t)
+	limiter := concurrency.NewLimiter(1, 0, 0, 0, "test")
+	SetConcurrencyLimiter(limiter)
+	defer SetConcurrencyLimiter(nil)
+
+	model := v1.MODEL
+	graph := &v1.PredictiveUnit{
+		Type:     &model,
+		Endpoint: &v1.Endpoint{Type: v1.REST},
+		Children: []v1.PredictiveUnit{
+			{
+				Type:     &model,
+				Endpoint: &v1.Endpoint{Type: v1.REST},
+			},
+		},
+	}
+
+	// Requests only take a single slot however many nodes the graph has
+	_, err := createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	g.Expect(Ready(api.ProtocolSeldon, graph, false)).Should(BeNil())
+
+	release, err := limiter.Acquire(context.TODO())
+	g.Expect(err).Should(BeNil())
+	_, err = createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(Equal(&concurrency.OverloadedError{Reason: concurrency.ReasonQueueFull}))
+	g.Expect(Ready(api.ProtocolSeldon, graph, false)).ShouldNot(BeNil())
+	release(nil)
+}
+
+func TestBulkheads(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	model := v1.MODEL
+	combiner := v1.COMBINER
+	maxConcurrency := int32(1)
+	graph := &v1.PredictiveUnit{
+		Name:     "combiner",
+		Type:     &combiner,
+		Endpoint: &v1.Endpoint{Type: v1.REST},
+		Children: []v1.PredictiveUnit{
+			{
+				Name:           "slow",
+				Type:           &model,
+				Endpoint:       &v1.Endpoint{Type: v1.REST},
+				MaxConcurrency: &maxConcurrency,
+			},
+			{
+				Name:           "fast",
+				Type:           &model,
+				Endpoint:       &v1.Endpoint{Type: v1.REST},
+				MaxConcurrency: &maxConcurrency,
+			},
+		},
+	}
+	b := concurrency.NewBulkheads(graph, "test")
+	g.Expect(b).To(HaveLen(2))
+	SetBulkheads(b)
+	defer SetBulkheads(nil)
+
+	_, err := createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	// Calls to other nodes aren't affected when a node's bulkhead is full
+	release, err := b.Acquire(context.TODO(), "slow")
+	g.Expect(err).Should(BeNil())
+	_, err = createPredictorProcess(t).Predict(&graph.Children[1], createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	// The combiner combines the responses of the children whose bulkheads weren't full
+	_, err = createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+
+	// The request fails if every child's bulkhead is full
+	releaseFast, err := b.Acquire(context.TODO(), "fast")
+	g.Expect(err).Should(BeNil())
+	_, err = createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(Equal(&concurrency.BulkheadFullError{Node: "slow"}))
+	g.Expect(concurrency.IsBulkheadFull(err)).Should(BeTrue())
+	releaseFast()
+	release()
+
+	_, err = createPredictorProcess(t).Predict(graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+}
+
+func TestReadyUnixSocket(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	socket := filepath.Join(t.TempDir(), "model.sock")
+	graph := &v1.PredictiveUnit{
+		Name:     "model",
+		Endpoint: &v1.Endpoint{UnixSocket: socket, ServiceHost: "localhost", ServicePort: 1},
+	}
+	g.Expect(ReadyTCP(graph)).ShouldNot(BeNil())
+
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).Should(BeNil())
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})}
+	go server.Serve(lis)
+	defer server.Close()
+
+	g.Expect(ReadyTCP(graph)).Should(BeNil())
+	g.Expect(ReadyHealth(graph, "/api/v1.0/health/status")).Should(BeNil())
+}
+
+func TestReadyHealthUnixSocketOnly(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	socket := filepath.Join(t.TempDir(), "socket-only.sock")
+	graph := &v1.PredictiveUnit{
+		Name:     "socket-only",
+		Endpoint: &v1.Endpoint{UnixSocket: socket},
+	}
+	g.Expect(ReadyHealth(graph, "/api/v1.0/health/status")).ShouldNot(BeNil())
+
+	lis, err := net.Listen("unix", socket)
+	g.Expect(err).Should(BeNil())
+	status := http.StatusServiceUnavailable
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})}
+	go server.Serve(lis)
+	defer server.Close()
+
+	g.Expect(ReadyHealth(graph, "/api/v1.0/health/status")).ShouldNot(BeNil())
+	status = http.StatusOK
+	g.Expect(ReadyHealth(graph, "/api/v1.0/health/status")).Should(BeNil())
+}
+
+func TestReadyHealthTLS(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	serverUrl, err := url.Parse(server.URL)
+	g.Expect(err).Should(BeNil())
+	port, err := strconv.Atoi(serverUrl.Port())
+	g.Expect(err).Should(BeNil())
+
+	graph := &v1.PredictiveUnit{
+		Name:     "tls-model",
+		Endpoint: &v1.Endpoint{ServiceHost: serverUrl.Hostname(), ServicePort: int32(port), TLS: &v1.EndpointTLS{InsecureSkipVerify: true}},
+	}
+	g.Expect(ReadyHealth(graph, "/api/v1.0/health/status")).Should(BeNil())
+
+	graph.Endpoint.TLS = nil
+	g.Expect(ReadyHealth(graph, "/api/v1.0/health/status")).ShouldNot(BeNil())
+}
@@ -1,16 +1,44 @@
 package predictor
 
 import (
+	"context"
 	"fmt"
 	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/cert"
 	"github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"net"
 	"net/http"
 	"net/url"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"strconv"
+	"sync"
+)
+
+// Clients for the health checks of nodes whose endpoints use TLS or unix sockets, by node and endpoint
+var (
+	healthClientsMu sync.Mutex
+	healthClients   = map[string]*http.Client{}
 )
 
 func Ready(protocol string, node *v1.PredictiveUnit, fullHealthCheck bool) error {
+	// Report not ready once shutting down so no new requests are sent while in-flight ones finish
+	if Draining() {
+		return ErrDraining
+	}
+	// Report not ready while shedding load so load balancers back off
+	if concurrencyLimiter != nil && concurrencyLimiter.Overloaded() {
+		return fmt.Errorf("executor is overloaded")
+	}
+	if warmer != nil {
+		if err := warmer.Err(); err != nil {
+			return err
+		}
+	}
+	return graphReady(protocol, node, fullHealthCheck)
+}
+
+// graphReady checks all the nodes of a graph are ready.
+func graphReady(protocol string, node *v1.PredictiveUnit, fullHealthCheck bool) error {
 	if !fullHealthCheck {
 		return ReadyTCP(node)
 	}
@@ -26,6 +54,23 @@ func Ready(protocol string, node *v1.PredictiveUnit, fullHealthCheck bool) error
 	}
 }
 
+// NodeReady checks a single graph node, without its children, is ready.
+func NodeReady(protocol string, node *v1.PredictiveUnit, fullHealthCheck bool) error {
+	if !fullHealthCheck {
+		return nodeReadyTCP(node)
+	}
+	switch protocol {
+	case api.ProtocolSeldon:
+		return nodeReadyHealth(node, "/api/v1.0/health/status")
+	case api.ProtocolTensorflow:
+		return nodeReadyTCP(node)
+	case api.ProtocolV2, api.ProtocolKFServing:
+		return nodeReadyHealth(node, "/v2/health/ready")
+	default:
+		return fmt.Errorf("Unknown protocol for health check: %s", protocol)
+	}
+}
+
 func ReadyTCP(node *v1.PredictiveUnit) error {
 	for _, child := range node.Children {
 		err := ReadyTCP(&child)
@@ -33,6 +78,17 @@ func ReadyTCP(node *v1.PredictiveUnit) error {
 			return err
 		}
 	}
+	return nodeReadyTCP(node)
+}
+
+func nodeReadyTCP(node *v1.PredictiveUnit) error {
+	if unixSocket := node.Endpoint.GetUnixSocketPath(); unixSocket != "" {
+		c, err := net.Dial("unix", unixSocket)
+		if err != nil {
+			return err
+		}
+		return c.Close()
+	}
 	if node.Endpoint != nil && node.Endpoint.ServiceHost != "" && node.Endpoint.ServicePort > 0 {
 		c, err := net.Dial("tcp", fmt.Sprintf("%s:%d", node.Endpoint.ServiceHost, node.Endpoint.ServicePort))
 		if err != nil {
@@ -53,22 +109,73 @@ func ReadyHealth(node *v1.PredictiveUnit, healthPath string) error {
 			return err
 		}
 	}
-	if node.Endpoint != nil && node.Endpoint.ServiceHost != "" && node.Endpoint.ServicePort > 0 {
-		urlHealth := &url.URL{
-			Scheme: "http",
-			Host:   net.JoinHostPort(node.Endpoint.ServiceHost, strconv.Itoa(int(node.Endpoint.ServicePort))),
-			Path:   healthPath,
-		}
-		res, err := http.Get(urlHealth.String())
-		if err != nil {
-			return err
-		} else {
-			if res.StatusCode != http.StatusOK {
-				return fmt.Errorf("Bad status from %s:%d", node.Endpoint.ServiceHost, node.Endpoint.ServicePort)
-			}
-			return nil
-		}
+	return nodeReadyHealth(node, healthPath)
+}
+
+func nodeReadyHealth(node *v1.PredictiveUnit, healthPath string) error {
+	var host string
+	if unixSocket := node.Endpoint.GetUnixSocketPath(); unixSocket != "" {
+		// The health client dials the socket, so the host is only a placeholder
+		host = "localhost"
+	} else if node.Endpoint != nil && node.Endpoint.ServiceHost != "" && node.Endpoint.ServicePort > 0 {
+		host = net.JoinHostPort(node.Endpoint.ServiceHost, strconv.Itoa(int(node.Endpoint.ServicePort)))
 	} else {
 		return nil
 	}
+	urlHealth := &url.URL{
+		Scheme: "http",
+		Host:   host,
+		Path:   healthPath,
+	}
+	if node.Endpoint.TLS != nil {
+		urlHealth.Scheme = "https"
+	}
+	client, err := healthClient(node)
+	if err != nil {
+		return err
+	}
+	res, err := client.Get(urlHealth.String())
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Bad status from node %s: %d", node.Name, res.StatusCode)
+	}
+	return nil
+}
+
+// healthClient returns the client for a node's health checks, which uses the node's TLS configuration and
+// unix socket like the REST client does.
+func healthClient(node *v1.PredictiveUnit) (*http.Client, error) {
+	unixSocket := node.Endpoint.GetUnixSocketPath()
+	if node.Endpoint.TLS == nil && unixSocket == "" {
+		return http.DefaultClient, nil
+	}
+	key := node.Name + "|" + unixSocket
+	if node.Endpoint.TLS != nil {
+		key += fmt.Sprintf("|%+v", *node.Endpoint.TLS)
+	}
+	healthClientsMu.Lock()
+	defer healthClientsMu.Unlock()
+	if client, ok := healthClients[key]; ok {
+		return client, nil
+	}
+	transport := &http.Transport{DisableKeepAlives: true}
+	if node.Endpoint.TLS != nil {
+		tlsConfig, err := cert.NewClientTLSConfig(node.Endpoint.TLS, logf.Log.WithName("ReadyChecker"))
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if unixSocket != "" {
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", unixSocket)
+		}
+	}
+	client := &http.Client{Transport: transport}
+	healthClients[key] = client
+	return client, nil
 }
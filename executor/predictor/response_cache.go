@@ -0,0 +1,105 @@
+package predictor
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/seldonio/seldon-core/executor/api/cache"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	payloadLogger "github.com/seldonio/seldon-core/executor/logger"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+// Caches the responses of the graph, or of its nodes, to payloads sent before if set
+var caches *cache.Caches
+
+// SetCaches returns cached responses of the graph and its nodes to requests of all servers. The caches of a hot
+// reloaded graph are created by the reloader for each version of the graph instead.
+func SetCaches(c *cache.Caches) {
+	caches = c
+}
+
+// skipCacheKey marks the context of requests which must reach the models, such as warm-up requests.
+type skipCacheKey struct{}
+
+// withoutCache returns a context whose requests are neither answered from nor stored in the response caches.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+// requestCaches returns the caches of the graph whose root is root, which are those of the version of the graph
+// the request started on if it is hot reloaded. It returns nil if the request skips the caches.
+func requestCaches(ctx context.Context, root *v1.PredictiveUnit) *cache.Caches {
+	if skip, _ := ctx.Value(skipCacheKey{}).(bool); skip {
+		return nil
+	}
+	if graphReloader != nil {
+		return graphReloader.Caches(root)
+	}
+	return caches
+}
+
+// lookupCache returns the cached response of node to a payload if c is set, with the key to cache the response under
+// if there was none. The key is scoped by the model name the node is called with, which the request may override.
+func (p *PredictorProcess) lookupCache(c *cache.Cache, node *v1.PredictiveUnit, method string, msg payload.SeldonPayload) (string, payload.SeldonPayload) {
+	if c == nil {
+		return "", nil
+	}
+	key, err := c.Key(msg, method, p.getModelName(node))
+	if err != nil {
+		p.Log.Error(err, "Failed to create cache key")
+		return "", nil
+	}
+	if cached, ok := c.Get(key, msg); ok {
+		return key, cached
+	}
+	return key, nil
+}
+
+// storeCache caches a successful response under the key returned by lookupCache.
+func (p *PredictorProcess) storeCache(c *cache.Cache, key string, msg payload.SeldonPayload, err error) {
+	if c == nil || key == "" || err != nil || msg == nil {
+		return
+	}
+	if err := c.Set(key, msg); err != nil {
+		p.Log.Error(err, "Failed to cache response")
+	}
+}
+
+// recordCachedCall adds a call answered from the cache to the debug trace.
+func (p *PredictorProcess) recordCachedCall(node *v1.PredictiveUnit, method string, input json.RawMessage, output payload.SeldonPayload) {
+	if p.trace == nil {
+		return
+	}
+	p.trace.add(TraceStep{
+		Node:   node.Name,
+		Method: method,
+		Input:  input,
+		Output: traceValue(output),
+		Cached: true,
+	})
+}
+
+// cachedGraphResponse returns a response of the whole graph from the cache, logging it as the response of the
+// graph's root node unless the cache skips logging.
+func (p *PredictorProcess) cachedGraphResponse(node *v1.PredictiveUnit, c *cache.Cache, msg payload.SeldonPayload, cached payload.SeldonPayload) (payload.SeldonPayload, error) {
+	p.recordCachedCall(node, TracePredict, p.traceInput(msg), cached)
+	if c.SkipLogging || node.Logger == nil {
+		return cached, nil
+	}
+	puid, err := p.getPUIDHeader()
+	if err != nil {
+		return nil, err
+	}
+	if node.Logger.Mode == v1.LogRequest || node.Logger.Mode == v1.LogAll {
+		if err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceRequest, msg, puid); err != nil {
+			return nil, err
+		}
+	}
+	if node.Logger.Mode == v1.LogResponse || node.Logger.Mode == v1.LogAll {
+		if err := p.logPayload(node.Name, node.Logger, payloadLogger.InferenceResponse, cached, puid); err != nil {
+			return nil, err
+		}
+	}
+	return cached, nil
+}
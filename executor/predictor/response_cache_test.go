@@ -0,0 +1,193 @@
+package predictor
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/cache"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// countingClient counts the predictions it is asked for.
+type countingClient struct {
+	test.SeldonMessageTestClient
+	predictions *int
+}
+
+func (c countingClient) Predict(ctx context.Context, modelName string, host string, port int32, msg payload.SeldonPayload, meta map[string][]string) (payload.SeldonPayload, error) {
+	*c.predictions++
+	return c.SeldonMessageTestClient.Predict(ctx, modelName, host, port, msg, meta)
+}
+
+func createCachedPredictorProcess(predictions *int, meta map[string][]string) *PredictorProcess {
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	pp := NewPredictorProcess(ctx, countingClient{predictions: predictions}, logf.Log.WithName("SeldonMessageRestClient"), url, "default", meta, "")
+	return &pp
+}
+
+func createCachedPredictor(graphCache *v1.CacheSpec, nodeCache *v1.CacheSpec) *v1.PredictorSpec {
+	model := v1.MODEL
+	return &v1.PredictorSpec{
+		Cache: graphCache,
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Type:     &model,
+			Endpoint: &v1.Endpoint{ServiceHost: "foo", ServicePort: 9000, Type: v1.REST},
+			Cache:    nodeCache,
+		},
+	}
+}
+
+func TestNodeCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	spec := createCachedPredictor(nil, &v1.CacheSpec{TTL: "1m"})
+	caches, err := cache.NewCaches(spec, GraphVersion(spec), "mydep")
+	g.Expect(err).Should(BeNil())
+	SetCaches(caches)
+	defer SetCaches(nil)
+
+	predictions := 0
+	for i := 0; i < 3; i++ {
+		response, err := createCachedPredictorProcess(&predictions, nil).Predict(&spec.Graph, createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+		smRes := response.GetPayload().(*proto.SeldonMessage)
+		g.Expect(smRes.GetData().GetNdarray().Values[0].GetNumberValue()).Should(Equal(1.1))
+	}
+	g.Expect(predictions).Should(Equal(1))
+
+	// A different payload isn't answered from the cache
+	_, err = createCachedPredictorProcess(&predictions, nil).Predict(&spec.Graph, &payload.ProtoPayload{Msg: &proto.SeldonMessage{DataOneof: &proto.SeldonMessage_StrData{StrData: "other"}}})
+	g.Expect(err).Should(BeNil())
+	g.Expect(predictions).Should(Equal(2))
+}
+
+func TestGraphCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	spec := createCachedPredictor(&v1.CacheSpec{TTL: "1m"}, nil)
+	caches, err := cache.NewCaches(spec, GraphVersion(spec), "mydep")
+	g.Expect(err).Should(BeNil())
+	SetCaches(caches)
+	defer SetCaches(nil)
+
+	predictions := 0
+	for i := 0; i < 2; i++ {
+		_, err := createCachedPredictorProcess(&predictions, nil).Predict(&spec.Graph, createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+	}
+	g.Expect(predictions).Should(Equal(1))
+}
+
+func TestCacheKeyedByModelName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	spec := createCachedPredictor(&v1.CacheSpec{TTL: "1m"}, &v1.CacheSpec{TTL: "1m"})
+	caches, err := cache.NewCaches(spec, GraphVersion(spec), "mydep")
+	g.Expect(err).Should(BeNil())
+	SetCaches(caches)
+	defer SetCaches(nil)
+
+	predictions := 0
+	url, _ := url.Parse(testSourceUrl)
+	ctx := context.WithValue(context.TODO(), payload.SeldonPUIDHeader, testSeldonPuid)
+	for _, modelName := range []string{"model-a", "model-b", "model-a"} {
+		pp := NewPredictorProcess(ctx, countingClient{predictions: &predictions}, logf.Log.WithName("SeldonMessageRestClient"), url, "default", nil, modelName)
+		_, err := pp.Predict(&spec.Graph, createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+	}
+	// The same payload sent to another model isn't answered with the first model's response
+	g.Expect(predictions).Should(Equal(2))
+}
+
+func TestCachedCallTraced(t *testing.T) {
+	g := NewGomegaWithT(t)
+	EnableDebugTrace(api.ProtocolSeldon)
+	defer EnableDebugTrace("")
+	spec := createCachedPredictor(nil, &v1.CacheSpec{TTL: "1m"})
+	caches, err := cache.NewCaches(spec, GraphVersion(spec), "mydep")
+	g.Expect(err).Should(BeNil())
+	SetCaches(caches)
+	defer SetCaches(nil)
+
+	predictions := 0
+	meta := map[string][]string{"seldon-debug-trace": {"true"}}
+	response, err := createCachedPredictorProcess(&predictions, meta).Predict(&spec.Graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	steps := getTrace(g, response)
+	g.Expect(steps).Should(HaveLen(1))
+	g.Expect(steps[0].Cached).Should(BeFalse())
+
+	response, err = createCachedPredictorProcess(&predictions, meta).Predict(&spec.Graph, createPredictPayload(g))
+	g.Expect(err).Should(BeNil())
+	steps = getTrace(g, response)
+	g.Expect(steps).Should(HaveLen(1))
+	g.Expect(steps[0].Node).Should(Equal("model"))
+	g.Expect(steps[0].Cached).Should(BeTrue())
+	g.Expect(steps[0].Output).Should(MatchJSON(`{"data":{"ndarray":[1.1,2]}}`))
+	g.Expect(predictions).Should(Equal(1))
+}
+
+const cachedGraphTemplate = `apiVersion: machinelearning.seldon.io/v1
+kind: SeldonDeployment
+metadata:
+  name: mymodel
+spec:
+  predictors:
+  - name: p1
+    graph:
+      name: model
+      type: MODEL
+      cache:
+        ttl: 1m
+      endpoint:
+        service_host: localhost
+        service_port: %d
+`
+
+func TestCacheReloadedGraph(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "graph.yaml")
+	writeGraph(g, dir, fmt.Sprintf(cachedGraphTemplate, 9000))
+	spec, err := GetPredictor("p1", filename, "", "", nil)
+	g.Expect(err).To(BeNil())
+	r, err := NewGraphReloader("p1", filename, spec, "reload", api.TransportRest, api.ProtocolSeldon, logf.Log)
+	g.Expect(err).To(BeNil())
+	defer r.Close()
+	SetGraphReloader(r)
+	defer SetGraphReloader(nil)
+
+	predictions := 0
+	predict := func(spec *v1.PredictorSpec) {
+		_, err := createCachedPredictorProcess(&predictions, nil).Predict(&spec.Graph, createPredictPayload(g))
+		g.Expect(err).Should(BeNil())
+	}
+	previous := ActiveSpec(nil)
+	predict(previous)
+	predict(ActiveSpec(nil))
+	g.Expect(predictions).Should(Equal(1))
+
+	initial := r.Version()
+	writeGraph(g, dir, fmt.Sprintf(cachedGraphTemplate, 9001))
+	g.Eventually(r.Version, 5*time.Second, 10*time.Millisecond).ShouldNot(Equal(initial))
+
+	// Responses of the previous graph aren't returned for the new one
+	predict(ActiveSpec(nil))
+	g.Expect(predictions).Should(Equal(2))
+	predict(ActiveSpec(nil))
+	g.Expect(predictions).Should(Equal(2))
+
+	// Requests still on the previous graph aren't cached
+	predict(previous)
+	predict(previous)
+	g.Expect(predictions).Should(Equal(4))
+}
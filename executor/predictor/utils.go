@@ -58,8 +58,9 @@ func getPredictorFromFile(predictorName string, filename string) (*v1.PredictorS
 		if err != nil {
 			return nil, err
 		}
+		// Without a name the first predictor is used, which is convenient when running locally
 		for _, predictor := range sdep.Spec.Predictors {
-			if predictor.Name == predictorName {
+			if predictor.Name == predictorName || predictorName == "" {
 				return &predictor, nil
 			}
 		}
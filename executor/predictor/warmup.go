@@ -0,0 +1,239 @@
+package predictor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/protobuf/jsonpb"
+	protoV1 "github.com/golang/protobuf/proto"
+	guuid "github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/client"
+	"github.com/seldonio/seldon-core/executor/api/grpc/kfserving/inference"
+	"github.com/seldonio/seldon-core/executor/api/grpc/seldon/proto"
+	"github.com/seldonio/seldon-core/executor/api/payload"
+	"github.com/seldonio/seldon-core/executor/proto/tensorflow/serving"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+)
+
+const (
+	DefaultWarmupRepeat      = 1
+	DefaultWarmupMaxAttempts = 3
+)
+
+// ErrWarmingUp is reported by readiness checks until warm-up has succeeded.
+var ErrWarmingUp = errors.New("warming up")
+
+var (
+	// Time waited between checks that the graph is ready to be warmed up, and between failed attempts
+	warmupRetryDelay = 5 * time.Second
+	// Holds readiness until the graph has been warmed up if set
+	warmer *Warmer
+)
+
+// SetWarmer makes readiness checks fail until the warmer has warmed up the graph.
+func SetWarmer(w *Warmer) {
+	warmer = w
+}
+
+// WarmupError is reported by readiness checks once warm-up has failed too many times.
+type WarmupError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *WarmupError) Error() string {
+	return fmt.Sprintf("warm-up failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Warmer sends the predictor's warm-up requests through the graph, so models which initialise lazily have done so
+// before the executor reports ready.
+type Warmer struct {
+	predictor        *v1.PredictorSpec
+	client           client.SeldonApiClient
+	protocol         string
+	fullHealthChecks bool
+	payloads         [][]byte
+	repeat           int
+	maxAttempts      int
+	log              logr.Logger
+
+	mutex sync.RWMutex
+	err   error
+}
+
+// NewWarmer creates a warmer for the predictor's warm-up spec, returning nil if it has none. Payloads are sent with
+// client, which should be the client used by the transport the executor serves.
+func NewWarmer(spec *v1.PredictorSpec, client client.SeldonApiClient, protocol string, fullHealthChecks bool, log logr.Logger) (*Warmer, error) {
+	if spec.Warmup == nil {
+		return nil, nil
+	}
+	payloads := make([][]byte, 0, len(spec.Warmup.Payloads))
+	for _, p := range spec.Warmup.Payloads {
+		payloads = append(payloads, []byte(p))
+	}
+	if spec.Warmup.File != "" {
+		filePayloads, err := readWarmupFile(spec.Warmup.File)
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, filePayloads...)
+	}
+	if len(payloads) == 0 {
+		return nil, errors.New("warm-up has no payloads")
+	}
+	w := &Warmer{
+		predictor:        spec,
+		client:           client,
+		protocol:         protocol,
+		fullHealthChecks: fullHealthChecks,
+		payloads:         payloads,
+		repeat:           DefaultWarmupRepeat,
+		maxAttempts:      DefaultWarmupMaxAttempts,
+		log:              log.WithName("Warmer"),
+		err:              ErrWarmingUp,
+	}
+	if spec.Warmup.Repeat != nil {
+		w.repeat = int(*spec.Warmup.Repeat)
+	}
+	if spec.Warmup.MaxAttempts != nil {
+		w.maxAttempts = int(*spec.Warmup.MaxAttempts)
+	}
+	// Payloads are checked up front as sending them again won't fix them
+	for i, p := range payloads {
+		if _, err := w.toPayload(p); err != nil {
+			return nil, errors.Wrapf(err, "invalid warm-up payload %d", i)
+		}
+	}
+	return w, nil
+}
+
+// readWarmupFile reads a JSON payload from each line of a file, skipping blank lines.
+func readWarmupFile(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open warm-up file")
+	}
+	defer file.Close()
+	var payloads [][]byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 {
+			payloads = append(payloads, append([]byte(nil), line...))
+		}
+	}
+	return payloads, errors.Wrap(scanner.Err(), "failed to read warm-up file")
+}
+
+// toPayload converts a JSON payload to the type the client sends.
+func (w *Warmer) toPayload(b []byte) (payload.SeldonPayload, error) {
+	if !w.client.IsGrpc() {
+		return &payload.BytesPayload{Msg: b, ContentType: "application/json"}, nil
+	}
+	var msg protoV1.Message
+	switch w.protocol {
+	case api.ProtocolSeldon:
+		msg = &proto.SeldonMessage{}
+	case api.ProtocolTensorflow:
+		msg = &serving.PredictRequest{}
+	case api.ProtocolV2, api.ProtocolKFServing:
+		msg = &inference.ModelInferRequest{}
+	default:
+		return nil, errors.Errorf("unknown protocol %s", w.protocol)
+	}
+	if err := jsonpb.UnmarshalString(string(b), msg); err != nil {
+		return nil, err
+	}
+	return &payload.ProtoPayload{Msg: msg}, nil
+}
+
+// Err returns nil once the graph has been warmed up, ErrWarmingUp until then and a *WarmupError if it failed.
+func (w *Warmer) Err() error {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.err
+}
+
+func (w *Warmer) setErr(err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.err = err
+}
+
+// Run waits for the graph's nodes to be ready and then sends the warm-up requests, retrying up to the maximum
+// number of attempts if any fail.
+func (w *Warmer) Run(ctx context.Context) {
+	for attempt := 1; ; attempt++ {
+		if err := w.waitForNodes(ctx); err != nil {
+			return
+		}
+		start := time.Now()
+		err := w.warmup(ctx)
+		if err == nil {
+			w.log.Info("Warmed up graph", "requests", len(w.payloads)*w.repeat, "duration", time.Since(start).String())
+			w.setErr(nil)
+			return
+		}
+		w.log.Error(err, "Warm-up failed", "attempt", attempt, "maxAttempts", w.maxAttempts)
+		if attempt >= w.maxAttempts {
+			w.setErr(&WarmupError{Attempts: attempt, Err: err})
+			return
+		}
+		select {
+		case <-time.After(warmupRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// waitForNodes waits until the graph's nodes pass their readiness checks.
+func (w *Warmer) waitForNodes(ctx context.Context) error {
+	for {
+		err := graphReady(w.protocol, &ActiveSpec(w.predictor).Graph, w.fullHealthChecks)
+		if err == nil {
+			return nil
+		}
+		w.log.V(1).Info("Waiting for graph to be ready to warm up", "reason", err.Error())
+		select {
+		case <-time.After(warmupRetryDelay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// warmup sends each payload through the graph the configured number of times, stopping at the first error. The
+// requests skip the response caches so every one of them reaches the models.
+func (w *Warmer) warmup(ctx context.Context) error {
+	serverUrl, _ := url.Parse("http://localhost")
+	ctx = withoutCache(ctx)
+	for i, p := range w.payloads {
+		for n := 0; n < w.repeat; n++ {
+			msg, err := w.toPayload(p)
+			if err != nil {
+				return err
+			}
+			puid := guuid.New().String()
+			meta := map[string][]string{
+				payload.SeldonPUIDHeader:        {puid},
+				payload.SeldonSkipLoggingHeader: {"true"},
+			}
+			process := NewPredictorProcess(context.WithValue(ctx, payload.SeldonPUIDHeader, puid), w.client, w.log, serverUrl, "", meta, "")
+			if _, err := process.Predict(&ActiveSpec(w.predictor).Graph, msg); err != nil {
+				return errors.Wrapf(err, "warm-up payload %d", i)
+			}
+		}
+	}
+	return nil
+}
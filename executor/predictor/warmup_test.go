@@ -0,0 +1,128 @@
+package predictor
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/seldonio/seldon-core/executor/api"
+	"github.com/seldonio/seldon-core/executor/api/cache"
+	"github.com/seldonio/seldon-core/executor/api/test"
+	v1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const testWarmupPayload = `{"data":{"ndarray":[[1.0,2.0]]}}`
+
+func createWarmupPredictor(warmup *v1.WarmupSpec) *v1.PredictorSpec {
+	model := v1.MODEL
+	return &v1.PredictorSpec{
+		Name: "p",
+		Graph: v1.PredictiveUnit{
+			Name:     "model",
+			Type:     &model,
+			Endpoint: &v1.Endpoint{Type: v1.GRPC},
+		},
+		Warmup: warmup,
+	}
+}
+
+func setWarmupRetryDelay(t *testing.T, delay time.Duration) {
+	previous := warmupRetryDelay
+	warmupRetryDelay = delay
+	t.Cleanup(func() { warmupRetryDelay = previous })
+}
+
+func TestWarmerNoSpec(t *testing.T) {
+	g := NewGomegaWithT(t)
+	w, err := NewWarmer(createWarmupPredictor(nil), &test.SeldonMessageTestClient{}, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(w).To(BeNil())
+}
+
+func TestWarmerSucceeds(t *testing.T) {
+	g := NewGomegaWithT(t)
+	repeat := int32(3)
+	spec := createWarmupPredictor(&v1.WarmupSpec{Payloads: []string{testWarmupPayload}, Repeat: &repeat})
+	w, err := NewWarmer(spec, &test.SeldonMessageTestClient{}, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(w.Err()).To(Equal(ErrWarmingUp))
+	g.Expect(w.repeat).To(Equal(3))
+
+	SetWarmer(w)
+	defer SetWarmer(nil)
+	g.Expect(Ready(api.ProtocolSeldon, &spec.Graph, false)).To(Equal(ErrWarmingUp))
+
+	w.Run(context.Background())
+	g.Expect(w.Err()).To(BeNil())
+	g.Expect(Ready(api.ProtocolSeldon, &spec.Graph, false)).To(BeNil())
+}
+
+func TestWarmerSkipsCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	repeat := int32(3)
+	spec := createWarmupPredictor(&v1.WarmupSpec{Payloads: []string{testWarmupPayload}, Repeat: &repeat})
+	spec.Cache = &v1.CacheSpec{TTL: "1m"}
+	spec.Graph.Cache = &v1.CacheSpec{TTL: "1m"}
+	caches, err := cache.NewCaches(spec, GraphVersion(spec), "mydep")
+	g.Expect(err).To(BeNil())
+	SetCaches(caches)
+	defer SetCaches(nil)
+
+	predictions := 0
+	w, err := NewWarmer(spec, countingClient{predictions: &predictions}, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).To(BeNil())
+	w.Run(context.Background())
+	g.Expect(w.Err()).To(BeNil())
+	g.Expect(predictions).To(Equal(3))
+}
+
+func TestWarmerFails(t *testing.T) {
+	g := NewGomegaWithT(t)
+	setWarmupRetryDelay(t, time.Millisecond)
+	maxAttempts := int32(2)
+	spec := createWarmupPredictor(&v1.WarmupSpec{Payloads: []string{testWarmupPayload}, MaxAttempts: &maxAttempts})
+	method := v1.TRANSFORM_INPUT
+	client := &test.SeldonMessageTestClient{ErrMethod: &method, Err: errors.New("model not loaded")}
+	w, err := NewWarmer(spec, client, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).To(BeNil())
+
+	SetWarmer(w)
+	defer SetWarmer(nil)
+	w.Run(context.Background())
+	var warmupErr *WarmupError
+	g.Expect(errors.As(w.Err(), &warmupErr)).To(BeTrue())
+	g.Expect(warmupErr.Attempts).To(Equal(2))
+	g.Expect(Ready(api.ProtocolSeldon, &spec.Graph, false).Error()).To(ContainSubstring("warm-up failed after 2 attempts"))
+}
+
+func TestWarmerFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := filepath.Join(t.TempDir(), "warmup.jsonl")
+	err := ioutil.WriteFile(path, []byte(testWarmupPayload+"\n\n"+testWarmupPayload+"\n"), 0644)
+	g.Expect(err).To(BeNil())
+	spec := createWarmupPredictor(&v1.WarmupSpec{Payloads: []string{testWarmupPayload}, File: path})
+	w, err := NewWarmer(spec, &test.SeldonMessageTestClient{}, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).To(BeNil())
+	g.Expect(w.payloads).To(HaveLen(3))
+}
+
+func TestWarmerMissingFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	spec := createWarmupPredictor(&v1.WarmupSpec{File: filepath.Join(os.TempDir(), "missing-warmup.jsonl")})
+	_, err := NewWarmer(spec, &test.SeldonMessageTestClient{}, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).ToNot(BeNil())
+}
+
+func TestWarmerInvalidPayload(t *testing.T) {
+	g := NewGomegaWithT(t)
+	spec := createWarmupPredictor(&v1.WarmupSpec{Payloads: []string{`{"data":`}})
+	_, err := NewWarmer(spec, &test.SeldonMessageTestClient{}, api.ProtocolSeldon, false, logf.Log)
+	g.Expect(err).ToNot(BeNil())
+	g.Expect(err.Error()).To(ContainSubstring("invalid warm-up payload 0"))
+}
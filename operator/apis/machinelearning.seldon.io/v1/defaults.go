@@ -268,11 +268,11 @@ func (r *SeldonDeploymentSpec) DefaultSeldonDeployment(mldepName string, namespa
 
 				r.setContainerPredictiveUnitDefaults(compSpecIdx, httpPortNum, grpcPortNum, &nextMetricsPortNum, mldepName, namespace, &p, pu, con)
 				//Only set image default for non tensorflow graphs
-				if r.Protocol != ProtocolTensorflow {
+				if r.GetPredictiveUnitProtocol(pu) != ProtocolTensorflow {
 					serverConfig := GetPrepackServerConfig(string(*pu.Implementation))
 					if serverConfig != nil {
 						if con.Image == "" {
-							con.Image = serverConfig.PrepackImageName(r.Protocol, pu)
+							con.Image = serverConfig.PrepackImageName(r.GetPredictiveUnitProtocol(pu), pu)
 						}
 					}
 				}
@@ -55,9 +55,16 @@ const (
 	OLD_PODINFO_VOLUME_NAME = "podinfo"
 	PODINFO_VOLUME_PATH     = "/etc/podinfo"
 
+	ENDPOINT_TLS_VOLUME_NAME_PREFIX = "seldon-endpoint-tls-"
+	ENDPOINT_TLS_VOLUME_PATH        = "/etc/seldon/endpoint-tls"
+
+	UNIX_SOCKET_VOLUME_NAME = "seldon-unix-sockets"
+	UNIX_SOCKET_VOLUME_PATH = "/var/run/seldon/sockets"
+
 	ENV_PREDICTIVE_UNIT_SERVICE_PORT         = "PREDICTIVE_UNIT_SERVICE_PORT"
 	ENV_PREDICTIVE_UNIT_HTTP_SERVICE_PORT    = "PREDICTIVE_UNIT_HTTP_SERVICE_PORT"
 	ENV_PREDICTIVE_UNIT_GRPC_SERVICE_PORT    = "PREDICTIVE_UNIT_GRPC_SERVICE_PORT"
+	ENV_PREDICTIVE_UNIT_UNIX_SOCKET          = "PREDICTIVE_UNIT_UNIX_SOCKET"
 	ENV_PREDICTIVE_UNIT_SERVICE_PORT_METRICS = "PREDICTIVE_UNIT_METRICS_SERVICE_PORT"
 	ENV_PREDICTIVE_UNIT_METRICS_ENDPOINT     = "PREDICTIVE_UNIT_METRICS_ENDPOINT"
 	ENV_PREDICTIVE_UNIT_METRICS_PORT_NAME    = "PREDICTIVE_UNIT_METRICS_PORT_NAME"
@@ -254,6 +261,33 @@ type PredictorSpec struct {
 	Shadow                  bool                    `json:"shadow,omitempty" protobuf:"bytes,11,opt,name=shadow"`
 	SSL                     *SSL                    `json:"ssl,omitempty" protobuf:"bytes,12,opt,name=ssl"`
 	ProgressDeadlineSeconds *int32                  `json:"progressDeadlineSeconds,omitempty" protobuf:"bytes,13,opt,name=progressDeadlineSeconds"`
+	// Requests the service orchestrator sends through the graph before reporting ready
+	Warmup *WarmupSpec `json:"warmup,omitempty" protobuf:"bytes,14,opt,name=warmup"`
+	// Caches the graph's responses to requests seen before, for graphs whose responses depend only on the request
+	Cache *CacheSpec `json:"cache,omitempty" protobuf:"bytes,15,opt,name=cache"`
+}
+
+// WarmupSpec describes requests sent through the graph to initialise models which load lazily before the
+// predictor serves traffic.
+type WarmupSpec struct {
+	// Request payloads in the deployment's protocol, as JSON
+	Payloads []string `json:"payloads,omitempty" protobuf:"bytes,1,opt,name=payloads"`
+	// Path of a file in the service orchestrator's container holding a JSON payload per line
+	File string `json:"file,omitempty" protobuf:"bytes,2,opt,name=file"`
+	// Number of times each payload is sent, 1 if not set
+	Repeat *int32 `json:"repeat,omitempty" protobuf:"int32,3,opt,name=repeat"`
+	// Number of times warm-up is attempted before the predictor reports it failed, 3 if not set
+	MaxAttempts *int32 `json:"maxAttempts,omitempty" protobuf:"int32,4,opt,name=maxAttempts"`
+}
+
+// CacheSpec configures the service orchestrator to return cached responses to requests it has seen before.
+type CacheSpec struct {
+	// How long responses are cached, such as 10m
+	TTL string `json:"ttl" protobuf:"bytes,1,opt,name=ttl"`
+	// Maximum number of responses cached, 10000 if not set
+	MaxEntries *int32 `json:"maxEntries,omitempty" protobuf:"int32,2,opt,name=maxEntries"`
+	// Whether payloads aren't sent to the request logger for responses returned from the cache
+	SkipLogging bool `json:"skipLogging,omitempty" protobuf:"varint,3,opt,name=skipLogging"`
 }
 
 type Protocol string
@@ -265,6 +299,15 @@ const (
 	ProtocolV2         Protocol = "v2"
 )
 
+// GetPredictiveUnitProtocol returns the protocol spoken by a predictive unit. Units without
+// their own protocol use the deployment's protocol.
+func (r *SeldonDeploymentSpec) GetPredictiveUnitProtocol(pu *PredictiveUnit) Protocol {
+	if pu.Protocol != "" {
+		return pu.Protocol
+	}
+	return r.Protocol
+}
+
 type Transport string
 
 const (
@@ -578,6 +621,51 @@ type Endpoint struct {
 	Type        EndpointType `json:"type,omitempty" protobuf:"int,3,opt,name=type"`
 	HttpPort    int32        `json:"httpPort,omitempty" protobuf:"int32,4,opt,name=httpPort"`
 	GrpcPort    int32        `json:"grpcPort,omitempty" protobuf:"int32,5,opt,name=grpcPort"`
+	TLS         *EndpointTLS `json:"tls,omitempty" protobuf:"bytes,6,opt,name=tls"`
+	// Name of a unix socket the unit listens on, which the service orchestrator calls instead of its ports.
+	// The socket is created in a directory shared by the containers of the service orchestrator's pod.
+	UnixSocket string `json:"unixSocket,omitempty" protobuf:"string,7,opt,name=unixSocket"`
+}
+
+// GetUnixSocketPath returns the path of the unit's unix socket, or an empty string if it doesn't use one.
+// Absolute paths are only used when running the service orchestrator outside the cluster.
+func (e *Endpoint) GetUnixSocketPath() string {
+	if e == nil || e.UnixSocket == "" {
+		return ""
+	}
+	if strings.HasPrefix(e.UnixSocket, "/") {
+		return e.UnixSocket
+	}
+	return UNIX_SOCKET_VOLUME_PATH + "/" + e.UnixSocket
+}
+
+// EndpointTLS configures TLS from the service orchestrator to a predictive unit
+type EndpointTLS struct {
+	// Secret with the client certificate, key and CA bundle, mounted into the service orchestrator
+	SecretName string `json:"secretName,omitempty" protobuf:"string,1,opt,name=secretName"`
+	// Client certificate file in the secret, tls.crt by default. Omitted from the secret for plain TLS.
+	CertFileName string `json:"certFileName,omitempty" protobuf:"string,2,opt,name=certFileName"`
+	// Client key file in the secret, tls.key by default
+	KeyFileName string `json:"keyFileName,omitempty" protobuf:"string,3,opt,name=keyFileName"`
+	// CA bundle file in the secret, ca.crt by default. The system roots are used if it is missing.
+	CAFileName string `json:"caFileName,omitempty" protobuf:"string,4,opt,name=caFileName"`
+	// Server name to verify instead of the endpoint host
+	ServerName string `json:"serverName,omitempty" protobuf:"string,5,opt,name=serverName"`
+	// Skip verification of the server certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty" protobuf:"bool,6,opt,name=insecureSkipVerify"`
+}
+
+// GetMountPath returns where the TLS secret is mounted in the service orchestrator
+func (t *EndpointTLS) GetMountPath() string {
+	return ENDPOINT_TLS_VOLUME_PATH + "/" + t.SecretName
+}
+
+// GetFilePath returns the path of a file from the TLS secret, or the default file name if none is given
+func (t *EndpointTLS) GetFilePath(fileName string, defaultFileName string) string {
+	if fileName == "" {
+		fileName = defaultFileName
+	}
+	return t.GetMountPath() + "/" + fileName
 }
 
 type ParmeterType string
@@ -609,6 +697,13 @@ type PredictiveUnit struct {
 	EnvSecretRefName        string                        `json:"envSecretRefName,omitempty" protobuf:"bytes,10,opt,name=envSecretRefName"`
 	StorageInitializerImage string                        `json:"storageInitializerImage,omitempty" protobuf:"bytes,11,opt,name=storageInitializerImage"`
 	Logger                  *Logger                       `json:"logger,omitempty" protobuf:"bytes,12,opt,name=logger"`
+	Protocol                Protocol                      `json:"protocol,omitempty" protobuf:"bytes,13,opt,name=protocol"`
+	// Maximum number of requests the service orchestrator sends to the unit at once
+	MaxConcurrency *int32 `json:"maxConcurrency,omitempty" protobuf:"int32,14,opt,name=maxConcurrency"`
+	// Number of further requests which can wait for the unit when maxConcurrency is reached before failing fast
+	MaxQueueLength *int32 `json:"maxQueueLength,omitempty" protobuf:"int32,15,opt,name=maxQueueLength"`
+	// Caches the unit's responses to requests seen before, for units whose responses depend only on the request
+	Cache *CacheSpec `json:"cache,omitempty" protobuf:"bytes,16,opt,name=cache"`
 }
 
 type LoggerMode string
@@ -17,8 +17,11 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/seldonio/seldon-core/operator/constants"
 	corev1 "k8s.io/api/core/v1"
@@ -107,7 +110,7 @@ func (r *SeldonDeploymentSpec) checkPredictiveUnits(pu *PredictiveUnit, p *Predi
 		c := GetContainerForPredictiveUnit(p, pu.Name)
 
 		//Current non tensorflow serving prepack servers can not handle tensorflow protocol
-		if r.Protocol == ProtocolTensorflow && (*pu.Implementation == PrepackSklearnName || *pu.Implementation == PrepackXGBoostName || *pu.Implementation == PrepackMLFlowName || *pu.Implementation == PrepackHuggingFaceName) {
+		if r.GetPredictiveUnitProtocol(pu) == ProtocolTensorflow && (*pu.Implementation == PrepackSklearnName || *pu.Implementation == PrepackXGBoostName || *pu.Implementation == PrepackMLFlowName || *pu.Implementation == PrepackHuggingFaceName) {
 			allErrs = append(allErrs, field.Invalid(fldPath, pu.Name, "Prepackaged server does not handle tensorflow protocol "+string(*pu.Implementation)))
 		}
 
@@ -126,12 +129,48 @@ func (r *SeldonDeploymentSpec) checkPredictiveUnits(pu *PredictiveUnit, p *Predi
 		}
 	}
 
+	if pu.Protocol != "" {
+		if !isValidProtocol(pu.Protocol) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("protocol"), pu.Protocol, "Invalid protocol"))
+		} else if !isSameProtocol(pu.Protocol, r.Protocol) {
+			if r.Transport == TransportGrpc {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("protocol"), pu.Protocol, "Predictive units can only use a different protocol to the deployment with the rest transport"))
+			}
+			if _, noEngine := p.Annotations[ANNOTATION_NO_ENGINE]; noEngine {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("protocol"), pu.Protocol, "Predictive units can only use a different protocol to the deployment when running with the engine"))
+			}
+		}
+	}
+
 	if pu.Logger != nil {
 		if pu.Logger.Mode == "" {
 			allErrs = append(allErrs, field.Invalid(fldPath, pu.Logger.Mode, "No logger mode specified"))
 		}
 	}
 
+	if pu.Endpoint != nil && pu.Endpoint.UnixSocket != "" {
+		if strings.Contains(pu.Endpoint.UnixSocket, "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint", "unixSocket"), pu.Endpoint.UnixSocket, "unixSocket must be a file name"))
+		} else if pu.Endpoint.ServiceHost != constants.DNSLocalHost {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoint", "unixSocket"), pu.Endpoint.UnixSocket, "unixSocket can only be used by units in the same pod as the service orchestrator"))
+		}
+	}
+
+	if pu.MaxConcurrency != nil && *pu.MaxConcurrency < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxConcurrency"), *pu.MaxConcurrency, "maxConcurrency must not be negative"))
+	}
+	if pu.MaxQueueLength != nil {
+		if *pu.MaxQueueLength < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxQueueLength"), *pu.MaxQueueLength, "maxQueueLength must not be negative"))
+		} else if pu.MaxConcurrency == nil || *pu.MaxConcurrency == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxQueueLength"), *pu.MaxQueueLength, "maxQueueLength requires maxConcurrency"))
+		}
+	}
+
+	if pu.Cache != nil {
+		allErrs = checkCache(pu.Cache, fldPath.Child("cache"), allErrs)
+	}
+
 	for i := 0; i < len(pu.Children); i++ {
 		allErrs = r.checkPredictiveUnits(&pu.Children[i], p, fldPath.Index(i), allErrs)
 	}
@@ -139,6 +178,25 @@ func (r *SeldonDeploymentSpec) checkPredictiveUnits(pu *PredictiveUnit, p *Predi
 	return allErrs
 }
 
+func isValidProtocol(protocol Protocol) bool {
+	return protocol == ProtocolSeldon || protocol == ProtocolTensorflow || protocol == ProtocolKFServing || protocol == ProtocolV2
+}
+
+// isSameProtocol checks whether two protocols are served by the same API. An empty protocol
+// is the seldon protocol and kfserving is an alias of v2.
+func isSameProtocol(a Protocol, b Protocol) bool {
+	normalise := func(protocol Protocol) Protocol {
+		switch protocol {
+		case "":
+			return ProtocolSeldon
+		case ProtocolKFServing:
+			return ProtocolV2
+		}
+		return protocol
+	}
+	return normalise(a) == normalise(b)
+}
+
 func checkTraffic(spec *SeldonDeploymentSpec, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
 	var trafficSum int32 = 0
 	var shadows int = 0
@@ -237,10 +295,38 @@ func (r *SeldonDeploymentSpec) validateShadow(allErrs field.ErrorList) field.Err
 	return allErrs
 }
 
+func checkWarmup(warmup *WarmupSpec, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if len(warmup.Payloads) == 0 && warmup.File == "" {
+		allErrs = append(allErrs, field.Required(fldPath, "warmup requires payloads or a file"))
+	}
+	for i, payload := range warmup.Payloads {
+		if !json.Valid([]byte(payload)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("payloads").Index(i), payload, "warmup payloads must be JSON"))
+		}
+	}
+	if warmup.Repeat != nil && *warmup.Repeat < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("repeat"), *warmup.Repeat, "repeat must be at least 1"))
+	}
+	if warmup.MaxAttempts != nil && *warmup.MaxAttempts < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxAttempts"), *warmup.MaxAttempts, "maxAttempts must be at least 1"))
+	}
+	return allErrs
+}
+
+func checkCache(cache *CacheSpec, fldPath *field.Path, allErrs field.ErrorList) field.ErrorList {
+	if ttl, err := time.ParseDuration(cache.TTL); err != nil || ttl <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), cache.TTL, "ttl must be a positive duration such as 10m"))
+	}
+	if cache.MaxEntries != nil && *cache.MaxEntries < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxEntries"), *cache.MaxEntries, "maxEntries must be at least 1"))
+	}
+	return allErrs
+}
+
 func (r *SeldonDeploymentSpec) ValidateSeldonDeployment() error {
 	var allErrs field.ErrorList
 
-	if r.Protocol != "" && !(r.Protocol == ProtocolSeldon || r.Protocol == ProtocolTensorflow || r.Protocol == ProtocolKFServing || r.Protocol == ProtocolV2) {
+	if r.Protocol != "" && !isValidProtocol(r.Protocol) {
 		fldPath := field.NewPath("spec")
 		allErrs = append(allErrs, field.Invalid(fldPath, r.Protocol, "Invalid protocol"))
 	}
@@ -284,6 +370,12 @@ func (r *SeldonDeploymentSpec) ValidateSeldonDeployment() error {
 		predictorNames[p.Name] = true
 
 		allErrs = r.checkPredictiveUnits(&p.Graph, &p, field.NewPath("spec").Child("predictors").Index(i).Child("graph"), allErrs)
+		if p.Warmup != nil {
+			allErrs = checkWarmup(p.Warmup, field.NewPath("spec").Child("predictors").Index(i).Child("warmup"), allErrs)
+		}
+		if p.Cache != nil {
+			allErrs = checkCache(p.Cache, field.NewPath("spec").Child("predictors").Index(i).Child("cache"), allErrs)
+		}
 	}
 
 	if len(transports) > 1 {
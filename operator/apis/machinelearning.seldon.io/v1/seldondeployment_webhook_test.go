@@ -1559,3 +1559,296 @@ func TestSvcNameAnnotationNotDuplicate(t *testing.T) {
 	err = spec.ValidateSeldonDeployment()
 	g.Expect(err).To(BeNil())
 }
+
+func TestValidatePredictiveUnitProtocol(t *testing.T) {
+	g := NewGomegaWithT(t)
+	impl := MODEL
+	createSpec := func(transport Transport, protocol Protocol) *SeldonDeploymentSpec {
+		return &SeldonDeploymentSpec{
+			Transport: transport,
+			Predictors: []PredictorSpec{
+				{
+					Name: "p1",
+					ComponentSpecs: []*SeldonPodSpec{
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier",
+									},
+									{
+										Image: "seldonio/mlserver:1.0",
+										Name:  "classifier2",
+									},
+								},
+							},
+						},
+					},
+					Graph: PredictiveUnit{
+						Name: "classifier",
+						Type: &impl,
+						Children: []PredictiveUnit{
+							{
+								Name:     "classifier2",
+								Type:     &impl,
+								Protocol: protocol,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	spec := createSpec(TransportRest, ProtocolV2)
+	spec.DefaultSeldonDeployment("mydep", "default")
+	g.Expect(spec.ValidateSeldonDeployment()).To(BeNil())
+
+	// Same protocol as the deployment is always allowed
+	spec = createSpec(TransportGrpc, ProtocolSeldon)
+	spec.DefaultSeldonDeployment("mydep", "default")
+	g.Expect(spec.ValidateSeldonDeployment()).To(BeNil())
+
+	for _, spec := range []*SeldonDeploymentSpec{createSpec(TransportGrpc, ProtocolV2), createSpec(TransportRest, "foo")} {
+		spec.DefaultSeldonDeployment("mydep", "default")
+		err := spec.ValidateSeldonDeployment()
+		g.Expect(err).ToNot(BeNil())
+		serr := err.(*errors.StatusError)
+		g.Expect(serr.Status().Code).To(Equal(int32(422)))
+		g.Expect(len(serr.Status().Details.Causes)).To(Equal(1))
+		g.Expect(serr.Status().Details.Causes[0].Type).To(Equal(v12.CauseTypeFieldValueInvalid))
+		g.Expect(serr.Status().Details.Causes[0].Field).To(Equal("spec.predictors[0].graph[0].protocol"))
+	}
+}
+
+func TestValidateMaxConcurrency(t *testing.T) {
+	g := NewGomegaWithT(t)
+	createSpec := func(maxConcurrency *int32, maxQueueLength *int32) *SeldonDeploymentSpec {
+		impl := MODEL
+		return &SeldonDeploymentSpec{
+			Predictors: []PredictorSpec{
+				{
+					Name: "p1",
+					ComponentSpecs: []*SeldonPodSpec{
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier",
+									},
+								},
+							},
+						},
+					},
+					Graph: PredictiveUnit{
+						Name:           "classifier",
+						Type:           &impl,
+						MaxConcurrency: maxConcurrency,
+						MaxQueueLength: maxQueueLength,
+					},
+				},
+			},
+		}
+	}
+	limit := func(i int32) *int32 {
+		return &i
+	}
+
+	spec := createSpec(limit(4), limit(10))
+	spec.DefaultSeldonDeployment("mydep", "default")
+	g.Expect(spec.ValidateSeldonDeployment()).To(BeNil())
+
+	tests := []struct {
+		spec  *SeldonDeploymentSpec
+		field string
+	}{
+		{createSpec(limit(-1), nil), "spec.predictors[0].graph.maxConcurrency"},
+		{createSpec(limit(4), limit(-1)), "spec.predictors[0].graph.maxQueueLength"},
+		{createSpec(nil, limit(10)), "spec.predictors[0].graph.maxQueueLength"},
+	}
+	for _, test := range tests {
+		test.spec.DefaultSeldonDeployment("mydep", "default")
+		err := test.spec.ValidateSeldonDeployment()
+		g.Expect(err).ToNot(BeNil())
+		serr := err.(*errors.StatusError)
+		g.Expect(len(serr.Status().Details.Causes)).To(Equal(1))
+		g.Expect(serr.Status().Details.Causes[0].Field).To(Equal(test.field))
+	}
+}
+
+func TestValidateUnixSocket(t *testing.T) {
+	g := NewGomegaWithT(t)
+	createSpec := func(unixSocket string, separatePod bool) *SeldonDeploymentSpec {
+		impl := MODEL
+		spec := &SeldonDeploymentSpec{
+			Predictors: []PredictorSpec{
+				{
+					Name: "p1",
+					ComponentSpecs: []*SeldonPodSpec{
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier",
+									},
+								},
+							},
+						},
+					},
+					Graph: PredictiveUnit{
+						Name:     "classifier",
+						Type:     &impl,
+						Endpoint: &Endpoint{UnixSocket: unixSocket},
+					},
+				},
+			},
+		}
+		if separatePod {
+			spec.Annotations = map[string]string{ANNOTATION_SEPARATE_ENGINE: "true"}
+		}
+		return spec
+	}
+
+	spec := createSpec("classifier.sock", false)
+	spec.DefaultSeldonDeployment("mydep", "default")
+	g.Expect(spec.ValidateSeldonDeployment()).To(BeNil())
+	g.Expect(spec.Predictors[0].Graph.Endpoint.GetUnixSocketPath()).To(Equal(UNIX_SOCKET_VOLUME_PATH + "/classifier.sock"))
+
+	for _, spec := range []*SeldonDeploymentSpec{createSpec("../classifier.sock", false), createSpec("classifier.sock", true)} {
+		spec.DefaultSeldonDeployment("mydep", "default")
+		err := spec.ValidateSeldonDeployment()
+		g.Expect(err).ToNot(BeNil())
+		serr := err.(*errors.StatusError)
+		g.Expect(len(serr.Status().Details.Causes)).To(Equal(1))
+		g.Expect(serr.Status().Details.Causes[0].Field).To(Equal("spec.predictors[0].graph.endpoint.unixSocket"))
+	}
+}
+
+func TestValidateWarmup(t *testing.T) {
+	g := NewGomegaWithT(t)
+	createSpec := func(warmup *WarmupSpec) *SeldonDeploymentSpec {
+		impl := MODEL
+		return &SeldonDeploymentSpec{
+			Predictors: []PredictorSpec{
+				{
+					Name: "p1",
+					ComponentSpecs: []*SeldonPodSpec{
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier",
+									},
+								},
+							},
+						},
+					},
+					Graph: PredictiveUnit{
+						Name: "classifier",
+						Type: &impl,
+					},
+					Warmup: warmup,
+				},
+			},
+		}
+	}
+	one := int32(1)
+	zero := int32(0)
+
+	for _, warmup := range []*WarmupSpec{
+		nil,
+		{Payloads: []string{`{"data":{"ndarray":[[1,2]]}}`}},
+		{File: "/warmup/payloads.jsonl", Repeat: &one, MaxAttempts: &one},
+	} {
+		spec := createSpec(warmup)
+		spec.DefaultSeldonDeployment("mydep", "default")
+		g.Expect(spec.ValidateSeldonDeployment()).To(BeNil())
+	}
+
+	tests := []struct {
+		warmup *WarmupSpec
+		field  string
+	}{
+		{warmup: &WarmupSpec{}, field: "spec.predictors[0].warmup"},
+		{warmup: &WarmupSpec{Payloads: []string{`{"data":`}}, field: "spec.predictors[0].warmup.payloads[0]"},
+		{warmup: &WarmupSpec{File: "payloads.jsonl", Repeat: &zero}, field: "spec.predictors[0].warmup.repeat"},
+		{warmup: &WarmupSpec{File: "payloads.jsonl", MaxAttempts: &zero}, field: "spec.predictors[0].warmup.maxAttempts"},
+	}
+	for _, tt := range tests {
+		spec := createSpec(tt.warmup)
+		spec.DefaultSeldonDeployment("mydep", "default")
+		err := spec.ValidateSeldonDeployment()
+		g.Expect(err).ToNot(BeNil())
+		serr := err.(*errors.StatusError)
+		g.Expect(len(serr.Status().Details.Causes)).To(Equal(1))
+		g.Expect(serr.Status().Details.Causes[0].Field).To(Equal(tt.field))
+	}
+}
+
+func TestValidateCache(t *testing.T) {
+	g := NewGomegaWithT(t)
+	createSpec := func(graphCache *CacheSpec, nodeCache *CacheSpec) *SeldonDeploymentSpec {
+		impl := MODEL
+		return &SeldonDeploymentSpec{
+			Predictors: []PredictorSpec{
+				{
+					Name: "p1",
+					ComponentSpecs: []*SeldonPodSpec{
+						{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Image: "seldonio/mock_classifier:1.0",
+										Name:  "classifier",
+									},
+								},
+							},
+						},
+					},
+					Graph: PredictiveUnit{
+						Name:  "classifier",
+						Type:  &impl,
+						Cache: nodeCache,
+					},
+					Cache: graphCache,
+				},
+			},
+		}
+	}
+	hundred := int32(100)
+	zero := int32(0)
+
+	for _, cache := range []*CacheSpec{
+		nil,
+		{TTL: "10m"},
+		{TTL: "30s", MaxEntries: &hundred, SkipLogging: true},
+	} {
+		spec := createSpec(cache, cache)
+		spec.DefaultSeldonDeployment("mydep", "default")
+		g.Expect(spec.ValidateSeldonDeployment()).To(BeNil())
+	}
+
+	tests := []struct {
+		graphCache *CacheSpec
+		nodeCache  *CacheSpec
+		field      string
+	}{
+		{graphCache: &CacheSpec{}, field: "spec.predictors[0].cache.ttl"},
+		{graphCache: &CacheSpec{TTL: "-1m"}, field: "spec.predictors[0].cache.ttl"},
+		{graphCache: &CacheSpec{TTL: "10m", MaxEntries: &zero}, field: "spec.predictors[0].cache.maxEntries"},
+		{nodeCache: &CacheSpec{TTL: "ten minutes"}, field: "spec.predictors[0].graph.cache.ttl"},
+	}
+	for _, tt := range tests {
+		spec := createSpec(tt.graphCache, tt.nodeCache)
+		spec.DefaultSeldonDeployment("mydep", "default")
+		err := spec.ValidateSeldonDeployment()
+		g.Expect(err).ToNot(BeNil())
+		serr := err.(*errors.StatusError)
+		g.Expect(len(serr.Status().Details.Causes)).To(Equal(1))
+		g.Expect(serr.Status().Details.Causes[0].Field).To(Equal(tt.field))
+	}
+}
@@ -31,6 +31,26 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheSpec) DeepCopyInto(out *CacheSpec) {
+	*out = *in
+	if in.MaxEntries != nil {
+		in, out := &in.MaxEntries, &out.MaxEntries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheSpec.
+func (in *CacheSpec) DeepCopy() *CacheSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentStatus) DeepCopyInto(out *DeploymentStatus) {
 	*out = *in
@@ -49,6 +69,11 @@ func (in *DeploymentStatus) DeepCopy() *DeploymentStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Endpoint) DeepCopyInto(out *Endpoint) {
 	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(EndpointTLS)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Endpoint.
@@ -61,6 +86,21 @@ func (in *Endpoint) DeepCopy() *Endpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointTLS) DeepCopyInto(out *EndpointTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointTLS.
+func (in *EndpointTLS) DeepCopy() *EndpointTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Explainer) DeepCopyInto(out *Explainer) {
 	*out = *in
@@ -75,7 +115,7 @@ func (in *Explainer) DeepCopyInto(out *Explainer) {
 	if in.Endpoint != nil {
 		in, out := &in.Endpoint, &out.Endpoint
 		*out = new(Endpoint)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Replicas != nil {
 		in, out := &in.Replicas, &out.Replicas
@@ -215,7 +255,7 @@ func (in *PredictiveUnit) DeepCopyInto(out *PredictiveUnit) {
 	if in.Endpoint != nil {
 		in, out := &in.Endpoint, &out.Endpoint
 		*out = new(Endpoint)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Parameters != nil {
 		in, out := &in.Parameters, &out.Parameters
@@ -227,6 +267,21 @@ func (in *PredictiveUnit) DeepCopyInto(out *PredictiveUnit) {
 		*out = new(Logger)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MaxConcurrency != nil {
+		in, out := &in.MaxConcurrency, &out.MaxConcurrency
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxQueueLength != nil {
+		in, out := &in.MaxQueueLength, &out.MaxQueueLength
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Cache != nil {
+		in, out := &in.Cache, &out.Cache
+		*out = new(CacheSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PredictiveUnit.
@@ -357,6 +412,16 @@ func (in *PredictorSpec) DeepCopyInto(out *PredictorSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Warmup != nil {
+		in, out := &in.Warmup, &out.Warmup
+		*out = new(WarmupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cache != nil {
+		in, out := &in.Cache, &out.Cache
+		*out = new(CacheSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PredictorSpec.
@@ -730,3 +795,33 @@ func (in *SvcOrchSpec) DeepCopy() *SvcOrchSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmupSpec) DeepCopyInto(out *WarmupSpec) {
+	*out = *in
+	if in.Payloads != nil {
+		in, out := &in.Payloads, &out.Payloads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Repeat != nil {
+		in, out := &in.Repeat, &out.Repeat
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxAttempts != nil {
+		in, out := &in.MaxAttempts, &out.MaxAttempts
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmupSpec.
+func (in *WarmupSpec) DeepCopy() *WarmupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -45,6 +45,7 @@ const (
 	ENV_EXECUTOR_REQUEST_LOGGER_WORK_QUEUE_SIZE  = "EXECUTOR_REQUEST_LOGGER_WORK_QUEUE_SIZE"
 	ENV_EXECUTOR_REQUEST_LOGGER_WRITE_TIMEOUT_MS = "EXECUTOR_REQUEST_LOGGER_WRITE_TIMEOUT_MS"
 	ENV_EXECUTOR_FULL_HEALTH_CHECKS              = "EXECUTOR_FULL_HEALTH_CHECKS"
+	ENV_EXECUTOR_GRPC_PROBES                     = "EXECUTOR_GRPC_PROBES"
 	ENV_EXECUTOR_USER                            = "EXECUTOR_CONTAINER_USER"
 	ENV_USE_EXECUTOR                             = "USE_EXECUTOR"
 
@@ -129,9 +130,84 @@ func addEngineToDeployment(mlDep *machinelearningv1.SeldonDeployment, p *machine
 				{Path: "annotations", FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations", APIVersion: "v1"}}}, DefaultMode: &defaultMode}}})
 	}
 
+	// Secrets used for TLS to the graph's endpoints
+	for i, secretName := range getEndpointTLSSecretNames(p) {
+		volName := machinelearningv1.ENDPOINT_TLS_VOLUME_NAME_PREFIX + strconv.Itoa(i)
+		volFound := false
+		for _, vol := range deploy.Spec.Template.Spec.Volumes {
+			if vol.Name == volName {
+				volFound = true
+			}
+		}
+		if !volFound {
+			deploy.Spec.Template.Spec.Volumes = append(deploy.Spec.Template.Spec.Volumes, corev1.Volume{Name: volName, VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: secretName}}})
+		}
+	}
+
+	// Directory shared with the units which the service orchestrator calls over unix sockets
+	if usesUnixSockets(p) {
+		volFound := false
+		for _, vol := range deploy.Spec.Template.Spec.Volumes {
+			if vol.Name == machinelearningv1.UNIX_SOCKET_VOLUME_NAME {
+				volFound = true
+			}
+		}
+		if !volFound {
+			deploy.Spec.Template.Spec.Volumes = append(deploy.Spec.Template.Spec.Volumes, corev1.Volume{Name: machinelearningv1.UNIX_SOCKET_VOLUME_NAME, VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+		}
+		for i := range deploy.Spec.Template.Spec.Containers {
+			con := &deploy.Spec.Template.Spec.Containers[i]
+			pu := machinelearningv1.GetPredictiveUnit(&p.Graph, con.Name)
+			if pu == nil || pu.Endpoint.GetUnixSocketPath() == "" {
+				continue
+			}
+			if !hasVolumeMount(con, machinelearningv1.UNIX_SOCKET_VOLUME_NAME) {
+				con.VolumeMounts = append(con.VolumeMounts, corev1.VolumeMount{
+					Name:      machinelearningv1.UNIX_SOCKET_VOLUME_NAME,
+					MountPath: machinelearningv1.UNIX_SOCKET_VOLUME_PATH,
+				})
+			}
+			con.Env = utils.SetEnvVar(con.Env, corev1.EnvVar{Name: machinelearningv1.ENV_PREDICTIVE_UNIT_UNIX_SOCKET, Value: pu.Endpoint.GetUnixSocketPath()}, false)
+		}
+	}
+
 	return nil
 }
 
+// usesUnixSockets returns whether any of the graph's endpoints are called over unix sockets
+func usesUnixSockets(p *machinelearningv1.PredictorSpec) bool {
+	for _, pu := range machinelearningv1.GetPredictiveUnitList(&p.Graph) {
+		if pu.Endpoint.GetUnixSocketPath() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVolumeMount(con *corev1.Container, name string) bool {
+	for _, mount := range con.VolumeMounts {
+		if mount.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getEndpointTLSSecretNames returns the distinct secrets referenced by the TLS settings of the graph's endpoints
+func getEndpointTLSSecretNames(p *machinelearningv1.PredictorSpec) []string {
+	var secretNames []string
+	found := map[string]bool{}
+	for _, pu := range machinelearningv1.GetPredictiveUnitList(&p.Graph) {
+		if pu.Endpoint != nil && pu.Endpoint.TLS != nil && pu.Endpoint.TLS.SecretName != "" && !found[pu.Endpoint.TLS.SecretName] {
+			found[pu.Endpoint.TLS.SecretName] = true
+			secretNames = append(secretNames, pu.Endpoint.TLS.SecretName)
+		}
+	}
+	return secretNames
+}
+
 func getExecutorHttpPort() (engine_http_port int, err error) {
 	// Get engine http port from environment or use default
 	engine_http_port = DEFAULT_EXECUTOR_CONTAINER_PORT
@@ -216,6 +292,13 @@ func createExecutorContainer(mlDep *machinelearningv1.SeldonDeployment, p *machi
 		probeScheme = corev1.URISchemeHTTPS
 	}
 
+	// Use the executor's gRPC health service for readiness of gRPC deployments. Native gRPC probes
+	// require Kubernetes >= 1.24 and don't support TLS so they are only used when enabled and TLS is off.
+	readinessHandler := corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromInt(http_port), Path: "/ready", Scheme: probeScheme}}
+	if transport == machinelearningv1.TransportGrpc && utils.IsEmptyTLS(p) && utils.GetEnvAsBool(ENV_EXECUTOR_GRPC_PROBES, false) {
+		readinessHandler = corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: int32(grpc_port)}}
+	}
+
 	loggerQSize := utils2.GetAnnotation(mlDep, machinelearningv1.ANNOTATION_LOGGER_WORK_QUEUE_SIZE, executorReqLoggerWorkQueueSize)
 	_, err := strconv.Atoi(loggerQSize)
 	if err != nil {
@@ -228,6 +311,27 @@ func createExecutorContainer(mlDep *machinelearningv1.SeldonDeployment, p *machi
 		return nil, fmt.Errorf("Failed to parse %s as integer for %s. %w", executorReqLoggerWriteTimeoutMs, ENV_EXECUTOR_REQUEST_LOGGER_WRITE_TIMEOUT_MS, err)
 	}
 
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      machinelearningv1.PODINFO_VOLUME_NAME,
+			MountPath: machinelearningv1.PODINFO_VOLUME_PATH,
+		},
+	}
+	for i, secretName := range getEndpointTLSSecretNames(p) {
+		tlsConfig := machinelearningv1.EndpointTLS{SecretName: secretName}
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      machinelearningv1.ENDPOINT_TLS_VOLUME_NAME_PREFIX + strconv.Itoa(i),
+			MountPath: tlsConfig.GetMountPath(),
+			ReadOnly:  true,
+		})
+	}
+	if usesUnixSockets(p) {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      machinelearningv1.UNIX_SOCKET_VOLUME_NAME,
+			MountPath: machinelearningv1.UNIX_SOCKET_VOLUME_PATH,
+		})
+	}
+
 	return &corev1.Container{
 		Name:  EngineContainerName,
 		Image: executorImage,
@@ -248,12 +352,7 @@ func createExecutorContainer(mlDep *machinelearningv1.SeldonDeployment, p *machi
 		ImagePullPolicy:          corev1.PullPolicy(utils.GetEnv("EXECUTOR_CONTAINER_IMAGE_PULL_POLICY", "IfNotPresent")),
 		TerminationMessagePath:   "/dev/termination-log",
 		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
-		VolumeMounts: []corev1.VolumeMount{
-			{
-				Name:      machinelearningv1.PODINFO_VOLUME_NAME,
-				MountPath: machinelearningv1.PODINFO_VOLUME_PATH,
-			},
-		},
+		VolumeMounts:             volumeMounts,
 		Env: []corev1.EnvVar{
 			{Name: "ENGINE_PREDICTOR", Value: predictorB64},
 			{Name: "REQUEST_LOGGER_DEFAULT_ENDPOINT", Value: utils.GetEnv("EXECUTOR_REQUEST_LOGGER_DEFAULT_ENDPOINT", "http://default-broker")},
@@ -263,7 +362,7 @@ func createExecutorContainer(mlDep *machinelearningv1.SeldonDeployment, p *machi
 			{ContainerPort: int32(http_port), Protocol: corev1.ProtocolTCP, Name: executorMetricsPortName},
 			{ContainerPort: int32(grpc_port), Protocol: corev1.ProtocolTCP, Name: constants.GrpcPortName},
 		},
-		ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromInt(http_port), Path: "/ready", Scheme: probeScheme}},
+		ReadinessProbe: &corev1.Probe{ProbeHandler: readinessHandler,
 			InitialDelaySeconds: 20,
 			PeriodSeconds:       5,
 			FailureThreshold:    3,
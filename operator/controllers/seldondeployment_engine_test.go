@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/gomega"
 	machinelearningv1 "github.com/seldonio/seldon-core/operator/apis/machinelearning.seldon.io/v1"
 	"github.com/seldonio/seldon-core/operator/constants"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -167,3 +168,102 @@ func TestEngineCreateLoggerAnnotation(t *testing.T) {
 	}
 	cleanEnvImagesExecutor()
 }
+
+func TestExecutorCreateGrpcProbes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cleanEnvImagesExecutor()
+	envExecutorImage = "executor"
+	mlDep := createTestSeldonDeployment()
+	mlDep.Spec.Transport = machinelearningv1.TransportGrpc
+
+	// Disabled by default
+	con, err := createExecutorContainer(mlDep, &mlDep.Spec.Predictors[0], "", 1, 2, &v1.ResourceRequirements{})
+	g.Expect(err).To(BeNil())
+	g.Expect(con.ReadinessProbe.HTTPGet).ToNot(BeNil())
+	g.Expect(con.ReadinessProbe.GRPC).To(BeNil())
+
+	t.Setenv(ENV_EXECUTOR_GRPC_PROBES, "true")
+	con, err = createExecutorContainer(mlDep, &mlDep.Spec.Predictors[0], "", 1, 2, &v1.ResourceRequirements{})
+	g.Expect(err).To(BeNil())
+	g.Expect(con.ReadinessProbe.HTTPGet).To(BeNil())
+	g.Expect(con.ReadinessProbe.GRPC.Port).To(Equal(int32(2)))
+	g.Expect(con.LivenessProbe.HTTPGet.Path).To(Equal("/live"))
+
+	// REST transport keeps the HTTP probe
+	mlDep.Spec.Transport = machinelearningv1.TransportRest
+	con, err = createExecutorContainer(mlDep, &mlDep.Spec.Predictors[0], "", 1, 2, &v1.ResourceRequirements{})
+	g.Expect(err).To(BeNil())
+	g.Expect(con.ReadinessProbe.HTTPGet.Path).To(Equal("/ready"))
+	cleanEnvImagesExecutor()
+}
+
+func TestExecutorEndpointTLSSecrets(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cleanEnvImagesExecutor()
+	envExecutorImage = "executor"
+	mlDep := createTestSeldonDeployment()
+	var modelType = machinelearningv1.MODEL
+	p := &mlDep.Spec.Predictors[0]
+	p.Graph.Endpoint.TLS = &machinelearningv1.EndpointTLS{SecretName: "model-tls"}
+	p.Graph.Children = []machinelearningv1.PredictiveUnit{
+		{Name: "a", Type: &modelType, Endpoint: &machinelearningv1.Endpoint{TLS: &machinelearningv1.EndpointTLS{SecretName: "model-tls"}}},
+		{Name: "b", Type: &modelType, Endpoint: &machinelearningv1.Endpoint{TLS: &machinelearningv1.EndpointTLS{SecretName: "other-tls"}}},
+		{Name: "c", Type: &modelType, Endpoint: &machinelearningv1.Endpoint{TLS: &machinelearningv1.EndpointTLS{}}},
+	}
+	g.Expect(getEndpointTLSSecretNames(p)).To(Equal([]string{"model-tls", "other-tls"}))
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{}},
+			Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}},
+		},
+	}
+	err := addEngineToDeployment(mlDep, p, 8000, 5001, "svc", deploy)
+	g.Expect(err).To(BeNil())
+
+	con := deploy.Spec.Template.Spec.Containers[0]
+	g.Expect(con.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: machinelearningv1.ENDPOINT_TLS_VOLUME_NAME_PREFIX + "0", MountPath: machinelearningv1.ENDPOINT_TLS_VOLUME_PATH + "/model-tls", ReadOnly: true}))
+	g.Expect(con.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: machinelearningv1.ENDPOINT_TLS_VOLUME_NAME_PREFIX + "1", MountPath: machinelearningv1.ENDPOINT_TLS_VOLUME_PATH + "/other-tls", ReadOnly: true}))
+	g.Expect(deploy.Spec.Template.Spec.Volumes).To(ContainElement(v1.Volume{Name: machinelearningv1.ENDPOINT_TLS_VOLUME_NAME_PREFIX + "1", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "other-tls"}}}))
+	cleanEnvImagesExecutor()
+}
+
+func TestExecutorUnixSockets(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cleanEnvImagesExecutor()
+	envExecutorImage = "executor"
+	mlDep := createTestSeldonDeployment()
+	var modelType = machinelearningv1.MODEL
+	p := &mlDep.Spec.Predictors[0]
+	g.Expect(usesUnixSockets(p)).To(BeFalse())
+	p.Graph.Children = []machinelearningv1.PredictiveUnit{
+		{Name: "a", Type: &modelType, Endpoint: &machinelearningv1.Endpoint{UnixSocket: "a.sock"}},
+		{Name: "b", Type: &modelType, Endpoint: &machinelearningv1.Endpoint{}},
+	}
+	g.Expect(usesUnixSockets(p)).To(BeTrue())
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{}},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+				Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "a"}, {Name: "b"}}},
+			},
+		},
+	}
+	err := addEngineToDeployment(mlDep, p, 8000, 5001, "svc", deploy)
+	g.Expect(err).To(BeNil())
+
+	mount := v1.VolumeMount{Name: machinelearningv1.UNIX_SOCKET_VOLUME_NAME, MountPath: machinelearningv1.UNIX_SOCKET_VOLUME_PATH}
+	containers := deploy.Spec.Template.Spec.Containers
+	g.Expect(containers).To(HaveLen(3))
+	g.Expect(containers[0].VolumeMounts).To(ContainElement(mount))
+	g.Expect(containers[0].Env).To(ContainElement(v1.EnvVar{Name: machinelearningv1.ENV_PREDICTIVE_UNIT_UNIX_SOCKET, Value: machinelearningv1.UNIX_SOCKET_VOLUME_PATH + "/a.sock"}))
+	g.Expect(containers[1].VolumeMounts).To(BeEmpty())
+	g.Expect(containers[2].Name).To(Equal(EngineContainerName))
+	g.Expect(containers[2].VolumeMounts).To(ContainElement(mount))
+	g.Expect(deploy.Spec.Template.Spec.Volumes).To(ContainElement(v1.Volume{Name: machinelearningv1.UNIX_SOCKET_VOLUME_NAME, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}))
+	cleanEnvImagesExecutor()
+}
@@ -95,23 +95,24 @@ func (pi *PrePackedInitialiser) addTFServerContainer(mlDepSpec *machinelearningv
 	pu.Type = &ty
 
 	c := utils.GetContainerForDeployment(deploy, pu.Name)
+	protocol := mlDepSpec.GetPredictiveUnitProtocol(pu)
 
 	var tfServingContainer *v1.Container
-	if mlDepSpec.Protocol == machinelearningv1.ProtocolTensorflow {
+	if protocol == machinelearningv1.ProtocolTensorflow {
 		tfServingContainer = c
 	} else {
-		c.Image = serverConfig.PrepackImageName(mlDepSpec.Protocol, pu)
+		c.Image = serverConfig.PrepackImageName(protocol, pu)
 		SetUriParamsForTFServingProxyContainer(pu, c)
 		tfServingContainer = utils.GetContainerForDeployment(deploy, constants.TFServingContainerName)
 	}
 
 	existing := tfServingContainer != nil
 	if !existing {
-		tfServingContainer = createTensorflowServingContainer(mlDepSpec, pu, mlDepSpec.Protocol == machinelearningv1.ProtocolTensorflow)
+		tfServingContainer = createTensorflowServingContainer(mlDepSpec, pu, protocol == machinelearningv1.ProtocolTensorflow)
 		deploy.Spec.Template.Spec.Containers = append(deploy.Spec.Template.Spec.Containers, *tfServingContainer)
 	} else {
 		// Update any missing fields
-		protoType := createTensorflowServingContainer(mlDepSpec, pu, mlDepSpec.Protocol == machinelearningv1.ProtocolTensorflow)
+		protoType := createTensorflowServingContainer(mlDepSpec, pu, protocol == machinelearningv1.ProtocolTensorflow)
 		if tfServingContainer.Image == "" {
 			tfServingContainer.Image = protoType.Image
 		}
@@ -214,7 +215,7 @@ func (pi *PrePackedInitialiser) addTritonServer(mlDepSpec *machinelearningv1.Sel
 		TerminationMessagePath:   "/dev/termination-log",
 		TerminationMessagePolicy: v1.TerminationMessageReadFile,
 	}
-	cServer.Image = serverConfig.PrepackImageName(mlDepSpec.Protocol, pu)
+	cServer.Image = serverConfig.PrepackImageName(mlDepSpec.GetPredictiveUnitProtocol(pu), pu)
 
 	envSecretRefName := extractEnvSecretRefName(pu)
 	if noStorage {
@@ -455,7 +456,7 @@ func (pi *PrePackedInitialiser) addModelServersAndInitContainers(mlDep *machinel
 				}
 			default:
 				// If protocol is V2, try to add container with MLServer
-				if mlDep.Spec.Protocol == machinelearningv1.ProtocolKFServing || mlDep.Spec.Protocol == machinelearningv1.ProtocolV2 {
+				if protocol := mlDep.Spec.GetPredictiveUnitProtocol(pu); protocol == machinelearningv1.ProtocolKFServing || protocol == machinelearningv1.ProtocolV2 {
 					err := pi.addMLServerDefault(pu, deploy)
 					if err != nil {
 						return err